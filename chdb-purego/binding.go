@@ -1,8 +1,10 @@
 package chdbpurego
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"sync"
 	"unsafe"
 
 	"github.com/ebitengine/purego"
@@ -68,12 +70,42 @@ var (
 	chdbResultError            func(result *chdb_result) string
 )
 
-func init() {
+// initializer runs the one-time loading of the native library, reporting the same result to every caller.
+type initializer struct {
+	once sync.Once
+	load func() error
+	err  error
+}
+
+func (i *initializer) do() error {
+	i.once.Do(func() {
+		i.err = i.load()
+	})
+	return i.err
+}
+
+var libInit = &initializer{load: loadLibrary}
+
+// Initialize loads the chDB native library and resolves its functions. It runs once per process:
+// later calls, including concurrent ones, return the result of the first call. Connections call it
+// implicitly, calling it at startup allows to report a missing or incompatible library early.
+// The library is looked up in CHDB_LIB_PATH, then in the PATH and in the usual install locations.
+func Initialize() error {
+	return libInit.do()
+}
+
+func loadLibrary() (err error) {
 	path := findLibrary()
 	libchdb, err := purego.Dlopen(path, purego.RTLD_NOW|purego.RTLD_GLOBAL)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("load chdb library %s: %w", path, err)
 	}
+	// RegisterLibFunc panics when a symbol is missing, e.g. with an outdated library
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("load chdb library %s: %v", path, r)
+		}
+	}()
 	purego.RegisterLibFunc(&queryStable, libchdb, "query_stable")
 	purego.RegisterLibFunc(&freeResult, libchdb, "free_result")
 	purego.RegisterLibFunc(&queryStableV2, libchdb, "query_stable_v2")
@@ -104,5 +136,5 @@ func init() {
 	purego.RegisterLibFunc(&chdbResultStorageRowsRead, libchdb, "chdb_result_storage_rows_read")
 	purego.RegisterLibFunc(&chdbResultStorageBytesRead, libchdb, "chdb_result_storage_bytes_read")
 	purego.RegisterLibFunc(&chdbResultError, libchdb, "chdb_result_error")
-
+	return nil
 }
@@ -0,0 +1,48 @@
+package chdbpurego
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestInitializerRunsOnce(t *testing.T) {
+	errLoad := errors.New("load failed")
+	calls := 0
+	loader := &initializer{load: func() error {
+		calls++
+		return errLoad
+	}}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = loader.do()
+		}(i)
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("expected the library to be loaded once, got %d", calls)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, errLoad) {
+			t.Errorf("call %d: expected the load error, got %v", i, err)
+		}
+	}
+	if err := loader.do(); !errors.Is(err, errLoad) {
+		t.Errorf("expected later calls to report the load error, got %v", err)
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	first := Initialize()
+	if second := Initialize(); second != first {
+		t.Errorf("expected Initialize to report the same result, got %v then %v", first, second)
+	}
+	if first != nil {
+		t.Skipf("chdb library not available: %s", first)
+	}
+}
@@ -1,11 +1,11 @@
 package chdbpurego
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
@@ -59,7 +59,7 @@ func (c *result) Elapsed() float64 {
 func (c *result) Error() error {
 	if c.chdb_result != nil {
 		if s := chdbResultError(c.chdb_result); s != "" {
-			return errors.New(s)
+			return newQueryError(s)
 		}
 	}
 	return nil
@@ -90,6 +90,15 @@ func (c *result) RowsRead() uint64 {
 	return 0
 }
 
+// Stats implements ChdbResult.
+func (c *result) Stats() QueryStats {
+	return QueryStats{
+		Elapsed:   time.Duration(c.Elapsed() * float64(time.Second)),
+		RowsRead:  c.RowsRead(),
+		BytesRead: c.BytesRead(),
+	}
+}
+
 // String implements ChdbResult.
 func (c *result) String() string {
 	ret := c.Buf()
@@ -115,6 +124,7 @@ func newChdbConn(conn *chdb_connection) ChdbConn {
 func (c *connection) Close() {
 	if c.conn != nil {
 		chdbCloseConn(c.conn)
+		c.conn = nil
 	}
 }
 
@@ -133,7 +143,7 @@ func (c *connection) Query(queryStr string, formatStr string) (result ChdbResult
 	}
 	errMsg := chdbResultError(res)
 	if errMsg != "" {
-		return nil, errors.New(errMsg)
+		return nil, newQueryError(errMsg)
 	}
 
 	return newChdbResult(res), nil
@@ -154,7 +164,7 @@ func (c *connection) QueryStreaming(queryStr string, formatStr string) (result C
 		return newStreamingResult(c.conn, res), nil
 	}
 	if s := chdbResultError(res); s != "" {
-		return nil, errors.New(s)
+		return nil, newQueryError(s)
 	}
 
 	return newStreamingResult(c.conn, res), nil
@@ -186,6 +196,9 @@ func (c *connection) Ready() bool {
 //   - Creating a new session will close the existing one.
 //   - You need to ensure that the path exists before creating a new session. Or you can use NewConnectionFromConnString.
 func NewConnection(argc int, argv []string) (ChdbConn, error) {
+	if err := Initialize(); err != nil {
+		return nil, err
+	}
 	var new_argv []string
 	if (argc > 0 && argv[0] != "clickhouse") || argc == 0 {
 		new_argv = make([]string, argc+1)
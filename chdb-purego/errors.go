@@ -0,0 +1,38 @@
+package chdbpurego
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// QueryError is the error returned when chDB fails to run a query.
+// Code and Name hold the ClickHouse error code and name, e.g. 60 and "UNKNOWN_TABLE",
+// when they can be parsed from the message; they are left empty otherwise.
+type QueryError struct {
+	Code    int
+	Name    string
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
+
+var (
+	errorCodeRe = regexp.MustCompile(`Code: (\d+)\.`)
+	errorNameRe = regexp.MustCompile(`\(([A-Z][A-Z0-9_]+)\)`)
+)
+
+// newQueryError parses an error message in the standard ClickHouse format:
+//
+//	Code: 60. DB::Exception: Unknown table expression identifier 'x'. (UNKNOWN_TABLE)
+func newQueryError(msg string) *QueryError {
+	e := &QueryError{Message: msg}
+	if m := errorCodeRe.FindStringSubmatch(msg); m != nil {
+		e.Code, _ = strconv.Atoi(m[1])
+	}
+	if m := errorNameRe.FindAllStringSubmatch(msg, -1); m != nil {
+		e.Name = m[len(m)-1][1]
+	}
+	return e
+}
@@ -0,0 +1,26 @@
+package chdbpurego
+
+import (
+	"testing"
+)
+
+func TestNewQueryError(t *testing.T) {
+	tests := []struct {
+		msg  string
+		code int
+		name string
+	}{
+		{"Code: 60. DB::Exception: Unknown table expression identifier 'missing' in scope SELECT * FROM missing. (UNKNOWN_TABLE)", 60, "UNKNOWN_TABLE"},
+		{"Code: 62. DB::Exception: Syntax error: failed at position 1 ('SELEC'): SELEC 1. Expected one of: Query. (SYNTAX_ERROR) (version 24.5.1.1)", 62, "SYNTAX_ERROR"},
+		{"something went wrong", 0, ""},
+	}
+	for _, tt := range tests {
+		err := newQueryError(tt.msg)
+		if err.Code != tt.code || err.Name != tt.name {
+			t.Errorf("newQueryError(%q) = %d %s, expected %d %s", tt.msg, err.Code, err.Name, tt.code, tt.name)
+		}
+		if err.Error() != tt.msg {
+			t.Errorf("expected the message to be kept, got %s", err.Error())
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package chdbpurego
 
-import "errors"
+import "sync"
 
 type streamingResult struct {
 	curConn  *chdb_connection
 	stream   *chdb_result
 	curChunk ChdbResult
+
+	// mu guards the fields below and the release of the stream, which may be freed by another goroutine during a fetch.
+	mu        sync.Mutex
+	fetching  bool
+	cancelled bool
 }
 
 func newStreamingResult(conn *chdb_connection, cRes *chdb_result) ChdbStreamResult {
@@ -28,19 +33,37 @@ func newStreamingResult(conn *chdb_connection, cRes *chdb_result) ChdbStreamResu
 
 // Error implements ChdbStreamResult.
 func (c *streamingResult) Error() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream == nil {
+		return nil
+	}
 	if s := chdbResultError(c.stream); s != "" {
-		return errors.New(s)
+		return newQueryError(s)
 	}
 	return nil
 }
 
 // Free implements ChdbStreamResult.
+// It cancels the query, and is safe to call while GetNext is running in another goroutine:
+// the running fetch is interrupted and the stream is released once it returns.
 func (c *streamingResult) Free() {
-	if c.curConn != nil && c.stream != nil {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.curConn != nil && c.stream != nil && !c.cancelled {
 		chdbStreamCancelQuery(c.curConn, c.stream)
-		chdbDestroyQueryResult(c.stream)
 	}
+	c.cancelled = true
+	if !c.fetching {
+		c.release()
+	}
+}
 
+// release destroys the stream and the current chunk. It must be called with mu held.
+func (c *streamingResult) release() {
+	if c.stream != nil {
+		chdbDestroyQueryResult(c.stream)
+	}
 	c.stream = nil
 	if c.curChunk != nil {
 		c.curChunk.Free()
@@ -49,24 +72,40 @@ func (c *streamingResult) Free() {
 }
 
 // Cancel implements ChdbStreamResult.
+// Like Free, it is safe to call while GetNext is running in another goroutine, e.g. when a context is cancelled.
 func (c *streamingResult) Cancel() {
 	c.Free()
 }
 
 // GetNext implements ChdbStreamResult.
 func (c *streamingResult) GetNext() ChdbResult {
-	if c.curChunk == nil {
-		nextChunk := chdbStreamFetchResult(c.curConn.internal_data, c.stream)
-		if nextChunk == nil {
-			return nil
-		}
-		c.curChunk = newChdbResult(nextChunk)
-		return c.curChunk
+	c.mu.Lock()
+	if c.stream == nil || c.cancelled {
+		c.mu.Unlock()
+		return nil
 	}
 	// free the current chunk before getting the next one
-	c.curChunk.Free()
-	c.curChunk = nil
-	nextChunk := chdbStreamFetchResult(c.curConn.internal_data, c.stream)
+	if c.curChunk != nil {
+		c.curChunk.Free()
+		c.curChunk = nil
+	}
+	c.fetching = true
+	stream := c.stream
+	c.mu.Unlock()
+
+	nextChunk := chdbStreamFetchResult(c.curConn.internal_data, stream)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetching = false
+	if c.cancelled {
+		// the stream was freed while fetching
+		if nextChunk != nil {
+			chdbDestroyQueryResult(nextChunk)
+		}
+		c.release()
+		return nil
+	}
 	if nextChunk == nil {
 		return nil
 	}
@@ -1,6 +1,9 @@
 package chdbpurego
 
-import "unsafe"
+import (
+	"time"
+	"unsafe"
+)
 
 // old local result struct. for reference:
 // https://github.com/chdb-io/chdb/blob/main/programs/local/chdb.h#L29
@@ -62,6 +65,23 @@ type ChdbResult interface {
 	Free()
 }
 
+// ChdbResultStats is implemented by the results of chDB alongside ChdbResult. It is kept out of ChdbResult so that
+// the implementations of ChdbResult outside of this package don't have to provide it, see chdb.ResultStats.
+type ChdbResultStats interface {
+	// Statistics of the query, gathering its elapsed time and the rows and bytes it read, e.g. to log its cost
+	Stats() QueryStats
+}
+
+// QueryStats holds the statistics chDB reports for the result of a query.
+type QueryStats struct {
+	// Elapsed is the time chDB took to run the query.
+	Elapsed time.Duration
+	// RowsRead is the number of rows read by the query.
+	RowsRead uint64
+	// BytesRead is the number of bytes read by the query.
+	BytesRead uint64
+}
+
 type ChdbStreamResult interface {
 	// GetNext returns the next chunk of data from the stream.
 	// The chunk is a ChdbResult object that can be used to read the data.
@@ -70,6 +90,7 @@ type ChdbStreamResult interface {
 	// Error returns the error message if there was an error during the streaming process.
 	Error() error
 	// Cancel cancels the streaming process and frees the underlying memory.
+	// It may be called from another goroutine to interrupt a running GetNext, which then returns nil.
 	Cancel()
 	// Free frees the underlying memory and closes the stream.
 	Free()
@@ -0,0 +1,156 @@
+package chdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+)
+
+// defaultBlockSize is the number of rows of the blocks inserted by an Appender, unless set with WithBlockSize.
+const defaultBlockSize = 65536
+
+// ErrAppenderClosed is returned when appending rows to an Appender after Close was called.
+var ErrAppenderClosed = errors.New("appender is closed")
+
+// Appender buffers rows appended to a table and inserts them in blocks. Each block is written in the Native format
+// to a temporary file and inserted with INSERT ... SELECT from the file table function, which avoids building large
+// VALUES statements. An Appender is not safe for concurrent use.
+type Appender struct {
+	s         *Session
+	table     string
+	columns   []string
+	writer    *native.Writer
+	blockSize int
+	rows      [][]any
+	buf       []byte
+	inserted  int64
+	closed    bool
+}
+
+// AppenderOption configures an Appender.
+type AppenderOption func(*Appender)
+
+// WithBlockSize sets the number of rows of the blocks inserted by an Appender, 65536 by default.
+func WithBlockSize(n int) AppenderOption {
+	return func(a *Appender) {
+		if n > 0 {
+			a.blockSize = n
+		}
+	}
+}
+
+// WithAppendColumns restricts the columns an Appender inserts, in the order of the values of the appended rows.
+// The other columns of the table get their default values.
+func WithAppendColumns(columns ...string) AppenderOption {
+	return func(a *Appender) {
+		a.columns = columns
+	}
+}
+
+// Appender returns an appender of rows to table. The types of the columns are read from the table once, and the
+// appended values are converted to them, e.g. any Go integer to an Int32 column as long as it fits, time.Time values
+// to dates, strings to UUID, IP or Decimal columns and Go maps to Map columns. By default every column but the
+// MATERIALIZED and ALIAS ones is inserted, in the order of the table.
+func (s *Session) Appender(table string, opts ...AppenderOption) (*Appender, error) {
+	a := &Appender{s: s, table: table, blockSize: defaultBlockSize}
+	for _, opt := range opts {
+		opt(a)
+	}
+	types := map[string]string{}
+	var insertable []string
+	err := s.ForEachJSONRow("DESCRIBE TABLE "+quoteIdentifier(table), func(row json.RawMessage) error {
+		var col struct {
+			Name        string `json:"name"`
+			Type        string `json:"type"`
+			DefaultType string `json:"default_type"`
+		}
+		if err := json.Unmarshal(row, &col); err != nil {
+			return err
+		}
+		types[col.Name] = col.Type
+		if col.DefaultType != "MATERIALIZED" && col.DefaultType != "ALIAS" {
+			insertable = append(insertable, col.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe table %s: %w", table, err)
+	}
+	if a.columns == nil {
+		a.columns = insertable
+	}
+	columnTypes := make([]string, len(a.columns))
+	for i, name := range a.columns {
+		typ, ok := types[name]
+		if !ok {
+			return nil, fmt.Errorf("table %s has no column %s", table, name)
+		}
+		columnTypes[i] = typ
+	}
+	if a.writer, err = native.NewWriter(a.columns, columnTypes); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Append buffers a row holding a value for every column, nil for NULL, inserting the buffered rows once they fill
+// a block. The values are only read when the block is inserted, so the slices and maps among them must not be
+// modified until then.
+func (a *Appender) Append(values ...any) error {
+	if a.closed {
+		return ErrAppenderClosed
+	}
+	if len(values) != len(a.columns) {
+		return fmt.Errorf("%d values for the %d columns of %s", len(values), len(a.columns), a.table)
+	}
+	a.rows = append(a.rows, append([]any(nil), values...))
+	if len(a.rows) >= a.blockSize {
+		return a.Flush()
+	}
+	return nil
+}
+
+// Flush inserts the buffered rows. The rows of a block which can't be inserted, e.g. because a value doesn't fit
+// the type of its column, stay buffered, so that Flush can be retried once the table accepts them. Discard drops
+// them otherwise.
+func (a *Appender) Flush() error {
+	if len(a.rows) == 0 {
+		return nil
+	}
+	var err error
+	if a.buf, err = a.writer.AppendBlock(a.buf[:0], a.rows); err != nil {
+		return fmt.Errorf("append to %s: %w", a.table, err)
+	}
+	_, err = a.s.insertFromFile(a.table, a.columns, "Native", func(w io.Writer) error {
+		_, err := w.Write(a.buf)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	a.inserted += int64(len(a.rows))
+	a.rows = a.rows[:0]
+	return nil
+}
+
+// Discard drops the buffered rows without inserting them, e.g. after Flush failed on one of their values.
+func (a *Appender) Discard() {
+	a.rows = a.rows[:0]
+}
+
+// Inserted returns the number of rows inserted so far, excluding the buffered ones.
+func (a *Appender) Inserted() int64 {
+	return a.inserted
+}
+
+// Close inserts the buffered rows. Rows can't be appended afterwards.
+func (a *Appender) Close() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	return a.Flush()
+}
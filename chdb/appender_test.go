@@ -0,0 +1,87 @@
+package chdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppender(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestAppender")
+	if _, err := sess.Exec(`CREATE TABLE TestAppender (id UInt32, name LowCardinality(String), score Nullable(Float64),
+		tags Array(String), at DateTime64(3), doubled UInt64 MATERIALIZED id * 2) ENGINE = MergeTree ORDER BY id`); err != nil {
+		t.Fatalf("create table fail, err: %s", err)
+	}
+	defer sess.Query("DROP TABLE IF EXISTS TestAppender")
+
+	a, err := sess.Appender("TestAppender", WithBlockSize(2))
+	if err != nil {
+		t.Fatalf("create appender fail, err: %s", err)
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	score := 1.5
+	for i := 0; i < 5; i++ {
+		var s *float64
+		if i%2 == 0 {
+			s = &score
+		}
+		if err := a.Append(i, "n"+string(rune('a'+i)), s, []string{"t"}, at); err != nil {
+			t.Fatalf("append fail, err: %s", err)
+		}
+	}
+	if a.Inserted() != 4 {
+		t.Errorf("expected 4 rows inserted before Close, got %d", a.Inserted())
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("close fail, err: %s", err)
+	}
+	if a.Inserted() != 5 {
+		t.Errorf("expected 5 rows inserted, got %d", a.Inserted())
+	}
+	if err := a.Append(5, "x", nil, nil, at); err != ErrAppenderClosed {
+		t.Errorf("expected ErrAppenderClosed, got %v", err)
+	}
+
+	ret, err := sess.Query("SELECT id, name, score, tags, toUnixTimestamp64Milli(at), doubled FROM TestAppender ORDER BY id", "TSVRaw")
+	if err != nil {
+		t.Fatalf("select fail, err: %s", err)
+	}
+	got := strings.Split(strings.TrimSuffix(ret.String(), "\n"), "\n")
+	ret.Free()
+	expected := []string{
+		"0\tna\t1.5\t['t']\t1704164645006\t0",
+		"1\tnb\t\\N\t['t']\t1704164645006\t2",
+		"2\tnc\t1.5\t['t']\t1704164645006\t4",
+		"3\tnd\t\\N\t['t']\t1704164645006\t6",
+		"4\tne\t1.5\t['t']\t1704164645006\t8",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected rows %q, got %q", expected, got)
+	}
+
+	partial, err := sess.Appender("TestAppender", WithAppendColumns("name", "id"))
+	if err != nil {
+		t.Fatalf("create appender fail, err: %s", err)
+	}
+	if err := partial.Append("z", uint8(9)); err != nil {
+		t.Fatalf("append fail, err: %s", err)
+	}
+	if err := partial.Append("overflow", -1); err != nil {
+		t.Fatalf("append fail, err: %s", err)
+	}
+	if err := partial.Close(); err == nil || !strings.Contains(err.Error(), "negative value") {
+		t.Errorf("expected an error for a negative UInt32, got %v", err)
+	}
+	if err := partial.Flush(); err == nil {
+		t.Errorf("expected the failed rows to stay buffered")
+	}
+	partial.Discard()
+	if err := partial.Flush(); err != nil || partial.Inserted() != 0 {
+		t.Errorf("expected no rows after Discard, got %d inserted, err: %v", partial.Inserted(), err)
+	}
+	if _, err := sess.Appender("TestAppender", WithAppendColumns("missing")); err == nil {
+		t.Errorf("expected an error for a missing column")
+	}
+}
@@ -0,0 +1,142 @@
+package chdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// QueryArrow runs the query in streaming mode and returns a reader of its result as Arrow records, decoded from
+// the ArrowStream chunks as they are produced. The reader must be released once done with, which stops the query
+// if it is still running. Like any RecordReader, the current record is only valid until the next call to Next,
+// unless it is retained. Results without any row have an empty schema.
+func (s *Session) QueryArrow(queryStr string) (array.RecordReader, error) {
+	stream, err := s.QueryStream(queryStr, "ArrowStream")
+	if err != nil {
+		return nil, err
+	}
+	r := &arrowRecordReader{stream: stream}
+	r.refCount.Store(1)
+	if err := r.nextChunk(); err != nil && err != io.EOF {
+		r.Release()
+		return nil, err
+	}
+	if r.reader != nil {
+		r.schema = r.reader.Schema()
+	} else {
+		r.schema = arrow.NewSchema(nil, nil)
+	}
+	return r, nil
+}
+
+// arrowRecordReader reads the records of the chunks of an ArrowStream stream, each of them a complete Arrow stream.
+type arrowRecordReader struct {
+	refCount atomic.Int64
+	stream   chdbpurego.ChdbStreamResult
+	chunk    chdbpurego.ChdbResult
+	reader   *ipc.Reader
+	schema   *arrow.Schema
+	record   arrow.Record
+	err      error
+}
+
+func (r *arrowRecordReader) Retain() {
+	r.refCount.Add(1)
+}
+
+// Release frees the stream once the last reference is released.
+func (r *arrowRecordReader) Release() {
+	if r.refCount.Add(-1) != 0 {
+		return
+	}
+	r.record = nil
+	if r.reader != nil {
+		r.reader.Release()
+		r.reader = nil
+	}
+	if r.chunk != nil {
+		r.chunk.Free()
+		r.chunk = nil
+	}
+	if r.stream != nil {
+		r.stream.Free()
+		r.stream = nil
+	}
+}
+
+func (r *arrowRecordReader) Schema() *arrow.Schema {
+	return r.schema
+}
+
+func (r *arrowRecordReader) Next() bool {
+	r.record = nil
+	for r.err == nil && r.stream != nil {
+		if r.reader != nil {
+			if r.reader.Next() {
+				r.record = r.reader.Record()
+				return true
+			}
+			if err := r.reader.Err(); err != nil && err != io.EOF {
+				r.err = err
+				return false
+			}
+		}
+		if err := r.nextChunk(); err != nil {
+			if err != io.EOF {
+				r.err = err
+			}
+			return false
+		}
+	}
+	return false
+}
+
+func (r *arrowRecordReader) Record() arrow.Record {
+	return r.record
+}
+
+func (r *arrowRecordReader) Err() error {
+	return r.err
+}
+
+// nextChunk opens the reader of the next chunk with rows, freeing the current one, and returns io.EOF once the
+// stream is exhausted.
+func (r *arrowRecordReader) nextChunk() error {
+	for {
+		if r.reader != nil {
+			r.reader.Release()
+			r.reader = nil
+		}
+		if r.chunk != nil {
+			r.chunk.Free()
+		}
+		r.chunk = r.stream.GetNext()
+		if r.chunk == nil {
+			if err := r.stream.Error(); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+		if err := r.chunk.Error(); err != nil {
+			return fmt.Errorf("error in chunk: %w", err)
+		}
+		if r.chunk.RowsRead() == 0 && r.chunk.Len() == 0 {
+			return io.EOF
+		}
+		if r.chunk.Len() == 0 {
+			continue
+		}
+		reader, err := ipc.NewReader(bytes.NewReader(r.chunk.Buf()))
+		if err != nil {
+			return fmt.Errorf("read arrow stream: %w", err)
+		}
+		r.reader = reader
+		return nil
+	}
+}
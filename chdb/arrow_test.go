@@ -0,0 +1,47 @@
+package chdb
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+)
+
+func TestQueryArrow(t *testing.T) {
+	sess := testSession(t)
+	rr, err := sess.QueryArrow("SELECT number AS n, toString(number) AS s FROM numbers(100000)")
+	if err != nil {
+		t.Fatalf("QueryArrow fail, err: %s", err)
+	}
+	defer rr.Release()
+	if fields := rr.Schema().Fields(); len(fields) != 2 || fields[0].Name != "n" || fields[1].Name != "s" {
+		t.Fatalf("unexpected schema %s", rr.Schema())
+	}
+	var rows, sum uint64
+	for rr.Next() {
+		rec := rr.Record()
+		n := rec.Column(0).(*array.Uint64)
+		for i := 0; i < n.Len(); i++ {
+			sum += n.Value(i)
+		}
+		rows += uint64(rec.NumRows())
+	}
+	if err := rr.Err(); err != nil {
+		t.Fatalf("read records fail, err: %s", err)
+	}
+	if rows != 100000 || sum != 100000*99999/2 {
+		t.Errorf("expected 100000 rows summing to %d, got %d rows summing to %d", 100000*99999/2, rows, sum)
+	}
+
+	empty, err := sess.QueryArrow("SELECT number FROM numbers(10) WHERE number > 100")
+	if err != nil {
+		t.Fatalf("QueryArrow fail, err: %s", err)
+	}
+	defer empty.Release()
+	if empty.Next() {
+		t.Errorf("expected no records")
+	}
+
+	if _, err := sess.QueryArrow("SELECT * FROM missing_table"); err == nil {
+		t.Errorf("expected an error for a missing table")
+	}
+}
@@ -0,0 +1,159 @@
+package chdb
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Backup writes a gzip-compressed tar archive of the data directory of the session to w, to be restored with
+// RestoreSession, e.g. on another machine. Like MergeFrom, the session connection is closed while the directory is
+// archived, so that the archive is consistent, and reopened afterwards: running queries are waited for, and new ones
+// wait for the backup to complete. Snapshots of the session are closed first, so that their databases are not
+// archived. If the connection can't be reopened, the session is closed.
+func (s *Session) Backup(w io.Writer) error {
+	if s.closed.Load() {
+		return ErrSessionClosed
+	}
+	if s.snapshot != nil {
+		return errors.New("a snapshot can't be backed up, back up its session instead")
+	}
+	if s.path == "" {
+		return errors.New("an in-memory session has no data directory to back up")
+	}
+
+	return s.withConnClosed(func() error {
+		return writeArchive(w, s.path)
+	})
+}
+
+// RestoreSession extracts an archive written by Session.Backup into path, which must not exist or be an empty
+// directory, and opens a session on it with OpenSession and the given options. It fails with ErrConnectionInUse
+// while another session is open, since chDB supports a single connection per process.
+func RestoreSession(r io.Reader, path string, opts ...Option) (*Session, error) {
+	if connInUse() {
+		return nil, ErrConnectionInUse
+	}
+	if path == "" {
+		return nil, errors.New("restore needs a path")
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("restore into %s: directory is not empty", path)
+	}
+	if err := extractArchive(r, path); err != nil {
+		return nil, fmt.Errorf("restore into %s: %w", path, err)
+	}
+	return OpenSession(path, opts...)
+}
+
+// writeArchive writes the regular files and directories of dir as a gzip-compressed tar archive. Symbolic links are
+// skipped: the data directory only holds the links of the tables of Atomic databases to the store directory, which
+// ClickHouse recreates when it loads them.
+func writeArchive(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractArchive extracts a gzip-compressed tar archive into dir. Entries escaping dir are rejected.
+func extractArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid archive entry %q", header.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			err = extractFile(tr, target, header.FileInfo().Mode().Perm())
+		default:
+			err = fmt.Errorf("unsupported archive entry %q", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractFile writes the content of the current archive entry to a new file.
+func extractFile(r io.Reader, path string, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,101 @@
+package chdb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession(filepath.Join(t.TempDir(), "chdb_backup"))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	if _, err := sess.Exec("CREATE TABLE TestBackup (id UInt64, name String) ENGINE = MergeTree ORDER BY id"); err != nil {
+		t.Fatalf("create table fail, err: %s", err)
+	}
+	if _, err := sess.Exec("INSERT INTO TestBackup SELECT number, toString(number) FROM numbers(100)"); err != nil {
+		t.Fatalf("insert fail, err: %s", err)
+	}
+	var archive bytes.Buffer
+	if err := sess.Backup(&archive); err != nil {
+		t.Fatalf("Backup fail, err: %s", err)
+	}
+	// the session is still usable after the backup
+	var count int
+	if err := sess.QueryScalar("SELECT count() FROM TestBackup", &count); err != nil || count != 100 {
+		t.Errorf("expected 100 rows after the backup, got %d, err: %v", count, err)
+	}
+	if _, err := RestoreSession(bytes.NewReader(archive.Bytes()), t.TempDir()); !errors.Is(err, ErrConnectionInUse) {
+		t.Errorf("expected ErrConnectionInUse restoring while a session is open, got %v", err)
+	}
+	sess.Close()
+
+	restorePath := filepath.Join(t.TempDir(), "chdb_restored")
+	restored, err := RestoreSession(bytes.NewReader(archive.Bytes()), restorePath)
+	if err != nil {
+		t.Fatalf("RestoreSession fail, err: %s", err)
+	}
+	defer restored.Close()
+	if restored.Path() != restorePath {
+		t.Errorf("expected session path %s, got %s", restorePath, restored.Path())
+	}
+	var sum uint64
+	if err := restored.QueryRow("SELECT count(), sum(id) FROM TestBackup", &count, &sum); err != nil {
+		t.Fatalf("QueryRow fail, err: %s", err)
+	}
+	if count != 100 || sum != 4950 {
+		t.Errorf("expected 100 rows summing to 4950, got %d rows summing to %d", count, sum)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "store", "abc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "store", "abc", "data.bin"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(src, "store", "abc"), filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := writeArchive(&archive, src); err != nil {
+		t.Fatalf("writeArchive fail, err: %s", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "restored")
+	if err := extractArchive(&archive, dst); err != nil {
+		t.Fatalf("extractArchive fail, err: %s", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "store", "abc", "data.bin")); err != nil || string(data) != "data" {
+		t.Errorf("expected the file to be restored, got %q, err: %v", data, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "link")); !os.IsNotExist(err) {
+		t.Errorf("expected symbolic links to be skipped, got %v", err)
+	}
+}
+
+func TestExtractArchiveRejectsEscapingEntries(t *testing.T) {
+	for _, name := range []string{"../evil", "/etc/evil", "a/../../evil"} {
+		var archive bytes.Buffer
+		gz := gzip.NewWriter(&archive)
+		tw := tar.NewWriter(gz)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte("x"))
+		tw.Close()
+		gz.Close()
+		if err := extractArchive(&archive, t.TempDir()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
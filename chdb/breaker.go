@@ -0,0 +1,71 @@
+package chdb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Query and QueryStream, without running the query, while the circuit breaker
+// set with WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WithCircuitBreaker makes the session stop running queries after the given number of consecutive query failures:
+// queries fail with ErrCircuitOpen for the cooldown period. Once the cooldown has elapsed a single query is let
+// through to test the recovery, closing the breaker when it succeeds and opening it again for another cooldown
+// when it fails. Queries rejected before reaching chDB, e.g. with ErrInvalidFormat, are not counted.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(s *Session) {
+		if failures > 0 {
+			s.breaker = &circuitBreaker{threshold: failures, cooldown: cooldown, now: time.Now}
+		}
+	}
+}
+
+// circuitBreaker counts consecutive query failures. A nil breaker lets every query through.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	// probing is set while the single query let through after the cooldown is running.
+	probing bool
+}
+
+// allow returns ErrCircuitOpen when the query must not run. Every allowed query must be followed by a call to done.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// done records the outcome of an allowed query.
+func (b *circuitBreaker) done(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
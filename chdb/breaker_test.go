@@ -0,0 +1,84 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := &circuitBreaker{threshold: 2, cooldown: time.Second, now: func() time.Time { return now }}
+	errQuery := errors.New("query failed")
+
+	for i := 0; i < 2; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatalf("expected query %d to be allowed, got %s", i, err)
+		}
+		b.done(errQuery)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after 2 failures, got %v", err)
+	}
+
+	// the first query after the cooldown fails and opens the breaker again
+	now = now.Add(time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected a probe query after the cooldown, got %s", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a single probe query, got %v", err)
+	}
+	b.done(errQuery)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after a failed probe, got %v", err)
+	}
+
+	// a successful probe closes it
+	now = now.Add(time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected a probe query after the cooldown, got %s", err)
+	}
+	b.done(nil)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected the breaker to be closed, got %s", err)
+	}
+	b.done(errQuery)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected a single failure to keep the breaker closed, got %s", err)
+	}
+}
+
+func TestSessionWithCircuitBreaker(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession("", WithCircuitBreaker(2, 100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := sess.Query("SELECT * FROM TestCircuitBreakerMissing"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected a query error, got %v", err)
+		}
+	}
+	if _, err := sess.Query("SELECT 1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if _, err := sess.QueryStream("SELECT 1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen for streaming queries, got %v", err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	ret, err := sess.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("expected the breaker to recover after the cooldown, err: %s", err)
+	}
+	if ret.String() != "1\n" {
+		t.Errorf("expected 1, got %s", ret.String())
+	}
+	if _, err := sess.Query("SELECT 2"); err != nil {
+		t.Fatalf("expected the breaker to be closed, err: %s", err)
+	}
+}
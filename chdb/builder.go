@@ -0,0 +1,194 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+// FileSource is a dataset stored in local files, read with the file table function.
+type FileSource struct {
+	// Path of the files, which may hold globs such as logs/*.parquet. Relative paths are made absolute.
+	Path string
+	// Format of the files, detected from the path extension when empty.
+	Format string
+	// Structure of the files, e.g. "id UInt64, name String", inferred from the data when empty.
+	Structure string
+}
+
+// SQL returns the file table function call reading the source, with its arguments escaped.
+func (src FileSource) SQL() string {
+	path := src.Path
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return "file(" + strings.Join(append([]string{quoteString(path)}, formatArgs(src.Format, src.Structure)...), ", ") + ")"
+}
+
+func (src FileSource) collection() ([][2]string, error) {
+	return nil, errors.New("file sources can't be stored in a named collection")
+}
+
+// SelectBuilder builds a SELECT query, quoting identifiers and rendering values as escaped literals.
+// Its methods return the builder itself, to be chained, e.g.
+//
+//	From(FileSource{Path: "logs/*.parquet"}).Select("level", "message").Where("ts >= ? AND level IN ?", since, levels).Limit(10)
+//
+// Errors, e.g. values which can't be rendered, are reported by Build.
+type SelectBuilder struct {
+	from    string
+	columns []string
+	where   []string
+	groupBy []string
+	orderBy []string
+	limit   int
+	offset  int
+	err     error
+}
+
+// From starts a query reading the source, e.g. a FileSource, an S3Source or a URLSource.
+func From(src Source) *SelectBuilder {
+	return &SelectBuilder{from: src.SQL(), limit: -1}
+}
+
+// FromTable starts a query reading the table, whose name may be qualified with a database, e.g. "db.table".
+func FromTable(name string) *SelectBuilder {
+	return &SelectBuilder{from: quoteIdentifier(name), limit: -1}
+}
+
+// Select adds columns to the result, quoted as identifiers. All the columns are selected when none is added.
+func (b *SelectBuilder) Select(columns ...string) *SelectBuilder {
+	for _, c := range columns {
+		b.columns = append(b.columns, quoteIdentifier(c))
+	}
+	return b
+}
+
+// SelectExpr adds an expression to the result, e.g. "count() AS n", with its ? placeholders replaced like Where does.
+// The expression itself is not escaped.
+func (b *SelectBuilder) SelectExpr(expr string, args ...any) *SelectBuilder {
+	b.columns = append(b.columns, b.bind(expr, args))
+	return b
+}
+
+// Where adds a condition, combined with the previous ones with AND. Its ? placeholders are replaced with the
+// arguments rendered as literals, e.g. strings are quoted and escaped, time.Time values become DateTime64
+// literals and slices become arrays, for IN conditions. Question marks inside string literals, quoted
+// identifiers and comments are left as is.
+func (b *SelectBuilder) Where(cond string, args ...any) *SelectBuilder {
+	b.where = append(b.where, "("+b.bind(cond, args)+")")
+	return b
+}
+
+// GroupBy adds columns to the GROUP BY clause, quoted as identifiers.
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	for _, c := range columns {
+		b.groupBy = append(b.groupBy, quoteIdentifier(c))
+	}
+	return b
+}
+
+// OrderBy adds a column to the ORDER BY clause, quoted as an identifier, in descending order with desc.
+func (b *SelectBuilder) OrderBy(column string, desc bool) *SelectBuilder {
+	order := quoteIdentifier(column)
+	if desc {
+		order += " DESC"
+	}
+	b.orderBy = append(b.orderBy, order)
+	return b
+}
+
+// Limit limits the number of rows of the result.
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+// Offset skips the first n rows of the result.
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = n
+	return b
+}
+
+// Build returns the SQL of the query, or the first error met while building it.
+func (b *SelectBuilder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	if len(b.columns) == 0 {
+		sb.WriteString("*")
+	} else {
+		sb.WriteString(strings.Join(b.columns, ", "))
+	}
+	sb.WriteString(" FROM " + b.from)
+	if len(b.where) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(b.where, " AND "))
+	}
+	if len(b.groupBy) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(b.groupBy, ", "))
+	}
+	if len(b.orderBy) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+	if b.limit >= 0 {
+		sb.WriteString(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		sb.WriteString(" OFFSET " + strconv.Itoa(b.offset))
+	}
+	return sb.String(), nil
+}
+
+// bind replaces the ? placeholders of expr with the literals of args, recording the first error in b.
+func (b *SelectBuilder) bind(expr string, args []any) string {
+	var (
+		out strings.Builder
+		n   int
+	)
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end := sqlfmt.QuotedEnd(expr, i)
+			out.WriteString(expr[i:end])
+			i = end
+		case sqlfmt.CommentEnd(expr, i) > i:
+			end := sqlfmt.CommentEnd(expr, i)
+			out.WriteString(expr[i:end])
+			i = end
+		case c == '?':
+			if n >= len(args) {
+				b.setErr(fmt.Errorf("missing argument %d of %q", n+1, expr))
+				return expr
+			}
+			lit, err := sqlfmt.Literal(reflect.ValueOf(args[n]))
+			if err != nil {
+				b.setErr(fmt.Errorf("argument %d of %q: %w", n+1, expr, err))
+				return expr
+			}
+			out.WriteString(lit)
+			n++
+			i++
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	if n < len(args) {
+		b.setErr(fmt.Errorf("%d arguments for the %d placeholders of %q", len(args), n, expr))
+	}
+	return out.String()
+}
+
+// setErr records err unless an error was already recorded.
+func (b *SelectBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
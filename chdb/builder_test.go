@@ -0,0 +1,72 @@
+package chdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectBuilder(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	query, err := FromTable("db.logs").
+		Select("level", "user name").
+		SelectExpr("count() AS n").
+		Where("ts >= ? AND level IN ?", since, []string{"warn", "it's"}).
+		Where("message NOT LIKE '%?%' -- ?\n AND host = ?", "a`b").
+		GroupBy("level", "user name").
+		OrderBy("n", true).
+		Limit(10).
+		Offset(20).
+		Build()
+	if err != nil {
+		t.Fatalf("Build fail, err: %s", err)
+	}
+	expected := "SELECT `level`, `user name`, count() AS n FROM `db`.`logs`" +
+		" WHERE (ts >= toDateTime64('2024-01-02 03:04:05.000000000', 9, 'UTC') AND level IN ['warn', 'it\\'s'])" +
+		" AND (message NOT LIKE '%?%' -- ?\n AND host = 'a`b')" +
+		" GROUP BY `level`, `user name` ORDER BY `n` DESC LIMIT 10 OFFSET 20"
+	if query != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, query)
+	}
+
+	query, err = From(FileSource{Path: "/data/x.parquet"}).Build()
+	if err != nil || query != "SELECT * FROM file('/data/x.parquet')" {
+		t.Errorf("unexpected query %q, err: %v", query, err)
+	}
+	query, err = From(S3Source{Bucket: "b", Key: "k.csv", Format: "CSV"}).Select("id").Limit(0).Build()
+	if err != nil || query != "SELECT `id` FROM s3('https://b.s3.amazonaws.com/k.csv', NOSIGN, 'CSV') LIMIT 0" {
+		t.Errorf("unexpected query %q, err: %v", query, err)
+	}
+
+	for _, b := range []*SelectBuilder{
+		FromTable("t").Where("a = ? AND b = ?", 1),
+		FromTable("t").Where("a = ?", 1, 2),
+		FromTable("t").Where("a = ?", make(chan int)),
+	} {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("expected an error for %+v", b)
+		}
+	}
+}
+
+func TestSelectBuilderQuery(t *testing.T) {
+	sess := testSession(t)
+
+	file := filepath.Join(t.TempDir(), "builder.parquet")
+	if _, err := sess.Exec(fmt.Sprintf("INSERT INTO FUNCTION file(%s, 'Parquet') SELECT number AS id, toString(number) AS name FROM numbers(10)", quoteString(file))); err != nil {
+		t.Fatalf("write %s fail, err: %s", file, err)
+	}
+	query, err := From(FileSource{Path: file}).Select("name").Where("id >= ? AND name != ?", 5, "it's").OrderBy("id", true).Limit(2).Build()
+	if err != nil {
+		t.Fatalf("Build fail, err: %s", err)
+	}
+	values, err := sess.QuerySingleColumn(query)
+	if err != nil {
+		t.Fatalf("QuerySingleColumn fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(values, []any{"9", "8"}) {
+		t.Errorf("expected 9 and 8, got %v", values)
+	}
+}
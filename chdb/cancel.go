@@ -0,0 +1,155 @@
+package chdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// ErrQueryKilled is the error of a query interrupted by KillQuery.
+var ErrQueryKilled = errors.New("query killed")
+
+// ErrQueryNotFound is returned by KillQuery when no query with the given id is running.
+var ErrQueryNotFound = errors.New("query not found")
+
+type queryIDKey struct{}
+
+// WithQueryID returns a copy of ctx identifying the query run with it by QueryContext, so that it can be interrupted
+// from another goroutine with KillQuery. The id is only known to the session: chDB doesn't let the ClickHouse
+// query_id be set, so the query isn't killed with KILL QUERY but by cancelling its stream.
+func WithQueryID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, queryIDKey{}, id)
+}
+
+// QueryContext runs the query like Query, interrupting it when ctx is done, in which case the context error is
+// returned. The query runs in streaming mode and the result gathers the data of all its chunks, so formats whose
+// chunks are complete documents, such as Parquet or Arrow, are rejected with ErrChunkedFormat: use QueryStream to
+// read them chunk by chunk. The query can also be interrupted with KillQuery when ctx carries an id set with
+// WithQueryID. Options such as WithProgress configure the query.
+func (s *Session) QueryContext(ctx context.Context, queryStr, format string, opts ...QueryOption) (chdbpurego.ChdbResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f := s.outputFormat([]string{format}); chunkedFormats[f] {
+		return nil, fmt.Errorf("%w: %s", ErrChunkedFormat, f)
+	}
+	var cfg queryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if id, ok := ctx.Value(queryIDKey{}).(string); ok {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		if err := s.registerQuery(id, cancel); err != nil {
+			return nil, err
+		}
+		defer s.unregisterQuery(id)
+	}
+	if ctx.Done() == nil && cfg.progress == nil {
+		return s.Query(queryStr, format)
+	}
+	var totalRows uint64
+	if cfg.progress != nil {
+		totalRows = s.estimateTotalRows(queryStr)
+	}
+
+	stream, err := s.QueryStream(queryStr, format)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Free()
+	stop := context.AfterFunc(ctx, stream.Cancel)
+	defer stop()
+
+	res := &bufferResult{}
+	start := time.Now()
+	for {
+		chunk := stream.GetNext()
+		if chunk == nil {
+			break
+		}
+		rowsRead, bytesRead := chunk.RowsRead(), chunk.BytesRead()
+		done, err := handleChunk(chunk, func(buf []byte) error {
+			res.buf.Write(buf)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+		res.rowsRead += rowsRead
+		res.bytesRead += bytesRead
+		if cfg.progress != nil {
+			cfg.progress(Progress{RowsRead: res.rowsRead, BytesRead: res.bytesRead, TotalRows: totalRows, Elapsed: time.Since(start)})
+		}
+	}
+	res.elapsed = time.Since(start)
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	if err := stream.Error(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// KillQuery interrupts the query run by QueryContext with the given id, which then returns ErrQueryKilled.
+// It returns ErrQueryNotFound when no such query is running.
+func (s *Session) KillQuery(id string) error {
+	s.queriesMu.Lock()
+	cancel, ok := s.queries[id]
+	s.queriesMu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+	cancel(ErrQueryKilled)
+	return nil
+}
+
+// registerQuery records the cancel function of a running query, whose id must be unique.
+func (s *Session) registerQuery(id string, cancel context.CancelCauseFunc) error {
+	s.queriesMu.Lock()
+	defer s.queriesMu.Unlock()
+	if _, ok := s.queries[id]; ok {
+		return fmt.Errorf("a query with id %s is already running", id)
+	}
+	if s.queries == nil {
+		s.queries = map[string]context.CancelCauseFunc{}
+	}
+	s.queries[id] = cancel
+	return nil
+}
+
+func (s *Session) unregisterQuery(id string) {
+	s.queriesMu.Lock()
+	defer s.queriesMu.Unlock()
+	delete(s.queries, id)
+}
+
+// bufferResult is a query result gathered from the chunks of a stream.
+type bufferResult struct {
+	buf       bytes.Buffer
+	elapsed   time.Duration
+	rowsRead  uint64
+	bytesRead uint64
+}
+
+func (r *bufferResult) Buf() []byte       { return r.buf.Bytes() }
+func (r *bufferResult) String() string    { return r.buf.String() }
+func (r *bufferResult) Len() int          { return r.buf.Len() }
+func (r *bufferResult) Elapsed() float64  { return r.elapsed.Seconds() }
+func (r *bufferResult) RowsRead() uint64  { return r.rowsRead }
+func (r *bufferResult) BytesRead() uint64 { return r.bytesRead }
+func (r *bufferResult) Error() error      { return nil }
+func (r *bufferResult) Free()             {}
+
+func (r *bufferResult) Stats() QueryStats {
+	return QueryStats{Elapsed: r.elapsed, RowsRead: r.rowsRead, BytesRead: r.bytesRead}
+}
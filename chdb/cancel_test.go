@@ -0,0 +1,81 @@
+package chdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryContext(t *testing.T) {
+	sess := testSession(t)
+
+	ret, err := sess.QueryContext(context.Background(), "SELECT number FROM numbers(3)", "CSV")
+	if err != nil {
+		t.Fatalf("QueryContext fail, err: %s", err)
+	}
+	if ret.String() != "0\n1\n2\n" {
+		t.Errorf("unexpected result %q", ret.String())
+	}
+	if _, err := sess.QueryContext(context.Background(), "SELECT 1", "Parquet"); !errors.Is(err, ErrChunkedFormat) {
+		t.Errorf("expected ErrChunkedFormat, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ret, err = sess.QueryContext(ctx, "SELECT number FROM numbers(100000)", "CSV")
+	if err != nil {
+		t.Fatalf("QueryContext fail, err: %s", err)
+	}
+	if ret.RowsRead() != 100000 || ret.Len() == 0 {
+		t.Errorf("expected 100000 rows, got %d", ret.RowsRead())
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = sess.QueryContext(ctx, "SELECT sleepEachRow(0.01) FROM numbers(100000) SETTINGS max_block_size = 1", "CSV")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("expected the query to be interrupted, it ran for %s", time.Since(start))
+	}
+}
+
+func TestKillQuery(t *testing.T) {
+	sess := testSession(t)
+
+	if err := sess.KillQuery("missing"); !errors.Is(err, ErrQueryNotFound) {
+		t.Errorf("expected ErrQueryNotFound, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		ctx := WithQueryID(context.Background(), "TestKillQuery")
+		_, err := sess.QueryContext(ctx, "SELECT sleepEachRow(0.01) FROM numbers(100000) SETTINGS max_block_size = 1", "CSV")
+		done <- err
+	}()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		err := sess.KillQuery("TestKillQuery")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the query was never registered, err: %s", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrQueryKilled) {
+			t.Errorf("expected ErrQueryKilled, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the killed query didn't return")
+	}
+	if err := sess.KillQuery("TestKillQuery"); !errors.Is(err, ErrQueryNotFound) {
+		t.Errorf("expected the query to be unregistered, got %v", err)
+	}
+}
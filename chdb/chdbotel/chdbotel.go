@@ -0,0 +1,146 @@
+// Package chdbotel traces chdb queries with OpenTelemetry, so that they appear in distributed traces alongside the
+// other database calls of an application.
+//
+// WrapSession wraps a chdb.Session and NewConnector or WrapConnector wrap the connector of the database/sql driver.
+// Every query runs in a client span, child of the span of its context, named after the operation of the query, e.g.
+// SELECT or INSERT. The span records the query, its output format, the rows and bytes chDB reports reading, or
+// writing for statements run with Exec, and the error of the query if any.
+//
+// The package is a module of its own, github.com/chdb-io/chdb-go/chdb/chdbotel, so that applications which don't
+// trace their queries don't depend on OpenTelemetry.
+package chdbotel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer creating the spans.
+const instrumentationName = "github.com/chdb-io/chdb-go/chdb/chdbotel"
+
+// Attributes of the spans.
+const (
+	systemKey       = attribute.Key("db.system")
+	operationKey    = attribute.Key("db.operation.name")
+	queryTextKey    = attribute.Key("db.query.text")
+	formatKey       = attribute.Key("chdb.format")
+	rowsReadKey     = attribute.Key("chdb.rows_read")
+	bytesReadKey    = attribute.Key("chdb.bytes_read")
+	rowsWrittenKey  = attribute.Key("chdb.rows_written")
+	bytesWrittenKey = attribute.Key("chdb.bytes_written")
+)
+
+// system is the value of the db.system attribute, and the name of the spans of queries without an operation.
+const system = "chdb"
+
+// Option configures the tracing of WrapSession, NewConnector and WrapConnector.
+type Option func(*config)
+
+type config struct {
+	provider  trace.TracerProvider
+	tracer    trace.Tracer
+	attrs     []attribute.KeyValue
+	queryText bool
+}
+
+// WithTracerProvider sets the provider of the tracer creating the spans. The global provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithAttributes adds attributes to every span, e.g. the name of the database.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// WithoutQueryText leaves the text of the queries out of the spans, e.g. when they hold sensitive values.
+func WithoutQueryText() Option {
+	return func(c *config) {
+		c.queryText = false
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{queryText: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.provider == nil {
+		c.provider = otel.GetTracerProvider()
+	}
+	c.tracer = c.provider.Tracer(instrumentationName)
+	return c
+}
+
+// start starts the span of a query.
+func (c *config) start(ctx context.Context, query, format string) (context.Context, trace.Span) {
+	attrs := make([]attribute.KeyValue, 0, 4+len(c.attrs))
+	attrs = append(attrs, systemKey.String(system))
+	name := operation(query)
+	if name != "" {
+		attrs = append(attrs, operationKey.String(name))
+	} else {
+		name = system
+	}
+	if c.queryText {
+		attrs = append(attrs, queryTextKey.String(query))
+	}
+	if format != "" {
+		attrs = append(attrs, formatKey.String(format))
+	}
+	attrs = append(attrs, c.attrs...)
+	return c.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// setQueryStats records the statistics of the result of a query in its span.
+func setQueryStats(span trace.Span, stats chdb.QueryStats) {
+	span.SetAttributes(rowsReadKey.Int64(int64(stats.RowsRead)), bytesReadKey.Int64(int64(stats.BytesRead)))
+}
+
+// setExecStats records the statistics of a statement in its span.
+func setExecStats(span trace.Span, stats chdb.ExecResult) {
+	span.SetAttributes(rowsWrittenKey.Int64(int64(stats.RowsWritten)), bytesWrittenKey.Int64(int64(stats.BytesWritten)))
+}
+
+// end ends the span of a query, recording its error if any.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operation returns the first keyword of the query, e.g. SELECT, skipping the leading whitespace and comments.
+// It returns an empty string when the query doesn't start with a keyword.
+func operation(query string) string {
+	i := 0
+	for i < len(query) {
+		if end := sqlfmt.CommentEnd(query, i); end > i {
+			i = end
+		} else if c := query[i]; c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '(' {
+			i++
+		} else {
+			break
+		}
+	}
+	start := i
+	for i < len(query) {
+		if c := query[i]; (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			break
+		}
+		i++
+	}
+	return strings.ToUpper(query[start:i])
+}
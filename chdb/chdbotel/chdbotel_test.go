@@ -0,0 +1,164 @@
+package chdbotel
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbdriver "github.com/chdb-io/chdb-go/chdb/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOperation(t *testing.T) {
+	for query, expected := range map[string]string{
+		"select 1":                           "SELECT",
+		"  -- comment\n/* block */ INSERT x": "INSERT",
+		"(SELECT 1) UNION ALL (SELECT 2)":    "SELECT",
+		"":                                   "",
+		"1 + 1":                              "",
+	} {
+		if got := operation(query); got != expected {
+			t.Errorf("%q: expected %q, got %q", query, expected, got)
+		}
+	}
+}
+
+// fakeConnector serves connections returning rows of a single column and failing the queries of the table missing.
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return chdbdriver.Driver{} }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (fakeConn) Format() string                      { return "Parquet" }
+
+func (fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if query == "SELECT * FROM missing" {
+		return nil, errors.New("unknown table")
+	}
+	return &fakeRows{remaining: 3}, nil
+}
+
+type fakeRows struct {
+	remaining int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.remaining == 0 {
+		return io.EOF
+	}
+	r.remaining--
+	dest[0] = int64(r.remaining)
+	return nil
+}
+func (r *fakeRows) Stats() chdb.QueryStats { return chdb.QueryStats{RowsRead: 3, BytesRead: 24} }
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestConnector(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	db := sql.OpenDB(WrapConnector(fakeConnector{}, WithTracerProvider(provider), WithAttributes(attribute.String("db.namespace", "test"))))
+	defer db.Close()
+
+	rows, err := db.Query("SELECT n FROM numbers")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	for rows.Next() {
+		if len(recorder.Ended()) != 0 {
+			t.Fatalf("span ended before the rows were closed")
+		}
+	}
+	rows.Close()
+	if _, err := db.Query("SELECT * FROM missing"); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "SELECT" {
+		t.Errorf("expected a SELECT span, got %s", spans[0].Name())
+	}
+	attrs := attributes(spans[0])
+	for key, expected := range map[attribute.Key]attribute.Value{
+		systemKey:      attribute.StringValue("chdb"),
+		queryTextKey:   attribute.StringValue("SELECT n FROM numbers"),
+		formatKey:      attribute.StringValue("Parquet"),
+		rowsReadKey:    attribute.Int64Value(3),
+		bytesReadKey:   attribute.Int64Value(24),
+		"db.namespace": attribute.StringValue("test"),
+	} {
+		if attrs[key] != expected {
+			t.Errorf("%s: expected %v, got %v", key, expected.Emit(), attrs[key].Emit())
+		}
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Errorf("unexpected error status %s", spans[0].Status().Description)
+	}
+	if spans[1].Status().Code != codes.Error || spans[1].Status().Description != "unknown table" {
+		t.Errorf("expected the error of the query, got %+v", spans[1].Status())
+	}
+}
+
+func TestSession(t *testing.T) {
+	sess, err := chdb.NewSession()
+	if err != nil {
+		t.Fatalf("NewSession fail, err: %s", err)
+	}
+	defer sess.Cleanup()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	traced := WrapSession(sess, WithTracerProvider(provider), WithoutQueryText())
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	res, err := traced.Query(ctx, "SELECT number FROM numbers(10)", "CSV")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	res.Free()
+	if _, err := traced.Exec(ctx, "SELECT * FROM missing_table"); err == nil {
+		t.Fatalf("expected an error")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	for _, span := range spans[:2] {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: expected a child of the parent span", span.Name())
+		}
+		if _, ok := attributes(span)[queryTextKey]; ok {
+			t.Errorf("%s: unexpected query text", span.Name())
+		}
+	}
+	if attrs := attributes(spans[0]); attrs[rowsReadKey].AsInt64() != 10 || attrs[formatKey].AsString() != "CSV" {
+		t.Errorf("unexpected attributes %v", spans[0].Attributes())
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("expected an error status, got %+v", spans[1].Status())
+	}
+}
@@ -0,0 +1,231 @@
+package chdbotel
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+
+	chdbdriver "github.com/chdb-io/chdb-go/chdb/driver"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewConnector returns a connector of the chdb driver for the given connection string, whose queries are traced with
+// the given options. Use it with sql.OpenDB:
+//
+//	connector, err := chdbotel.NewConnector("session=/tmp/chdb;driverType=PARQUET")
+//	if err != nil {
+//		return err
+//	}
+//	db := sql.OpenDB(connector)
+func NewConnector(name string, opts ...Option) (driver.Connector, error) {
+	c, err := chdbdriver.Driver{}.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return WrapConnector(c, opts...), nil
+}
+
+// WrapConnector returns c, e.g. a connector of chdbdriver.NewConnector, with its queries traced with the given
+// options. The queries run with QueryContext end their span when their rows are closed.
+//
+// The connections, statements and rows of the returned connector wrap those of c. The extensions of the chdb driver,
+// such as chdbdriver.RowsStats, are reached through their Unwrap method, e.g. within sql.Conn.Raw.
+func WrapConnector(c driver.Connector, opts ...Option) driver.Connector {
+	return &connector{Connector: c, cfg: newConfig(opts)}
+}
+
+type connector struct {
+	driver.Connector
+	cfg *config
+}
+
+// Connect returns a traced connection of the wrapped connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var format string
+	if f, ok := cn.(interface{ Format() string }); ok {
+		format = f.Format()
+	}
+	return &conn{Conn: cn, cfg: c.cfg, format: format}, nil
+}
+
+type conn struct {
+	driver.Conn
+	cfg    *config
+	format string // output format of the queries
+}
+
+// Unwrap returns the wrapped connection.
+func (c *conn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+// QueryContext runs the query in a span, which ends when the rows are closed.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.cfg.start(ctx, query, c.format)
+	r, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		end(span, err)
+		return nil, err
+	}
+	return &rows{Rows: r, span: span}, nil
+}
+
+// ExecContext runs the statement in a span.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.cfg.start(ctx, query, "")
+	res, err := execer.ExecContext(ctx, query, args)
+	if s, ok := res.(chdbdriver.StatsResult); ok && err == nil {
+		setExecStats(span, s.Stats())
+	}
+	end(span, err)
+	return res, err
+}
+
+// PrepareContext returns a statement whose queries are traced.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		st  driver.Stmt
+		err error
+	)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = preparer.PrepareContext(ctx, query)
+	} else {
+		st, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, conn: c, query: query}, nil
+}
+
+// CheckNamedValue converts the arguments like the wrapped connection does.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	conn  *conn
+	query string
+}
+
+// Unwrap returns the wrapped statement.
+func (s *stmt) Unwrap() driver.Stmt {
+	return s.Stmt
+}
+
+// QueryContext runs the statement in a span, which ends when the rows are closed.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := s.conn.cfg.start(ctx, s.query, s.conn.format)
+	r, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		end(span, err)
+		return nil, err
+	}
+	return &rows{Rows: r, span: span}, nil
+}
+
+// ExecContext runs the statement in a span.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := s.conn.cfg.start(ctx, s.query, "")
+	res, err := execer.ExecContext(ctx, args)
+	if st, ok := res.(chdbdriver.StatsResult); ok && err == nil {
+		setExecStats(span, st.Stats())
+	}
+	end(span, err)
+	return res, err
+}
+
+// CheckNamedValue converts the arguments like the wrapped statement does.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// rows ends the span of its query when closed, recording the statistics of the query and the error met reading
+// the rows if any. It forwards the column type interfaces of database/sql to the wrapped rows.
+type rows struct {
+	driver.Rows
+	span trace.Span
+	err  error // error returned by Next
+}
+
+// Unwrap returns the wrapped rows.
+func (r *rows) Unwrap() driver.Rows {
+	return r.Rows
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return err
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	if s, ok := r.Rows.(chdbdriver.RowsStats); ok {
+		setQueryStats(r.span, s.Stats())
+	}
+	if r.err != nil {
+		end(r.span, r.err)
+	} else {
+		end(r.span, err)
+	}
+	return err
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return t.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(any)).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if t, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return t.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return t.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return t.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
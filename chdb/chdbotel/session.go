@@ -0,0 +1,57 @@
+package chdbotel
+
+import (
+	"context"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// Session is a chdb.Session whose Query, QueryContext and Exec methods run in a span. Its other methods, promoted
+// from chdb.Session, are not traced.
+type Session struct {
+	*chdb.Session
+	cfg *config
+}
+
+// WrapSession returns sess traced with the given options.
+func WrapSession(sess *chdb.Session, opts ...Option) *Session {
+	return &Session{Session: sess, cfg: newConfig(opts)}
+}
+
+// Query runs the query like chdb.Session.Query, in a span child of the span of ctx.
+func (s *Session) Query(ctx context.Context, queryStr string, outputFormats ...string) (chdbpurego.ChdbResult, error) {
+	format := s.DefaultFormat()
+	if len(outputFormats) > 0 {
+		format = outputFormats[0]
+	}
+	_, span := s.cfg.start(ctx, queryStr, format)
+	res, err := s.Session.Query(queryStr, outputFormats...)
+	if err == nil {
+		setQueryStats(span, chdb.ResultStats(res))
+	}
+	end(span, err)
+	return res, err
+}
+
+// QueryContext runs the query like chdb.Session.QueryContext, in a span child of the span of ctx.
+func (s *Session) QueryContext(ctx context.Context, queryStr, format string, opts ...chdb.QueryOption) (chdbpurego.ChdbResult, error) {
+	ctx, span := s.cfg.start(ctx, queryStr, format)
+	res, err := s.Session.QueryContext(ctx, queryStr, format, opts...)
+	if err == nil {
+		setQueryStats(span, chdb.ResultStats(res))
+	}
+	end(span, err)
+	return res, err
+}
+
+// Exec runs the statement like chdb.Session.Exec, in a span child of the span of ctx.
+func (s *Session) Exec(ctx context.Context, queryStr string) (chdb.ExecResult, error) {
+	_, span := s.cfg.start(ctx, queryStr, "")
+	res, err := s.Session.Exec(queryStr)
+	if err == nil {
+		setExecStats(span, res)
+	}
+	end(span, err)
+	return res, err
+}
@@ -0,0 +1,66 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// QuerySingleColumn runs a query returning exactly one column, e.g. SELECT id FROM t, and returns the values of
+// that column for all rows. Values are decoded like the database/sql driver does, e.g. UInt64 as uint64 and
+// Nullable columns with nil for NULL. Queries returning more than one column are rejected.
+func (s *Session) QuerySingleColumn(queryStr string) ([]any, error) {
+	values := []any{}
+	err := s.forEachRow(queryStr, func(fields []parquet.Field) error {
+		if len(fields) != 1 {
+			return fmt.Errorf("expected a single column, query returned %d", len(fields))
+		}
+		return nil
+	}, func(row []any) error {
+		values = append(values, row[0])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// errStopRows stops the stream of rows once the first one was read.
+var errStopRows = errors.New("stop reading rows")
+
+// QueryRow runs the query and stores the values of the columns of its first row into dest, which must hold
+// a pointer per column. Values are converted like database/sql Scan does, e.g. a UInt64 into an *int or a *string,
+// and NULL into pointers. The other rows are not read. It returns ErrEmptyResult when the query returns no rows.
+func (s *Session) QueryRow(queryStr string, dest ...any) error {
+	read := false
+	err := s.forEachRow(queryStr, func(fields []parquet.Field) error {
+		if len(fields) != len(dest) {
+			return fmt.Errorf("expected %d columns, query returned %d", len(dest), len(fields))
+		}
+		return nil
+	}, func(row []any) error {
+		for i, v := range row {
+			if err := pqconv.Assign(dest[i], v); err != nil {
+				return fmt.Errorf("column %d: %w", i, err)
+			}
+		}
+		read = true
+		return errStopRows
+	})
+	if err != nil && err != errStopRows {
+		return err
+	}
+	if !read {
+		return ErrEmptyResult
+	}
+	return nil
+}
+
+// QueryScalar runs a query returning a single value, e.g. SELECT count() FROM t, and stores it into dest like
+// QueryRow does. Queries returning more than one column are rejected.
+func (s *Session) QueryScalar(queryStr string, dest any) error {
+	return s.QueryRow(queryStr, dest)
+}
@@ -0,0 +1,65 @@
+package chdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuerySingleColumn(t *testing.T) {
+	sess := testSession(t)
+
+	values, err := sess.QuerySingleColumn("SELECT number FROM numbers(5)")
+	if err != nil {
+		t.Fatalf("QuerySingleColumn fail, err: %s", err)
+	}
+	expected := []any{uint64(0), uint64(1), uint64(2), uint64(3), uint64(4)}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	values, err = sess.QuerySingleColumn("SELECT if(number = 1, NULL, toString(number)) FROM numbers(3)")
+	if err != nil {
+		t.Fatalf("QuerySingleColumn fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(values, []any{"0", nil, "2"}) {
+		t.Errorf("expected [0 <nil> 2], got %v", values)
+	}
+
+	if _, err := sess.QuerySingleColumn("SELECT number, toString(number) FROM numbers(3)"); err == nil {
+		t.Errorf("expected an error for a query returning two columns")
+	}
+}
+
+func TestQueryRow(t *testing.T) {
+	sess := testSession(t)
+
+	var (
+		id    int
+		name  string
+		score *float64
+	)
+	if err := sess.QueryRow("SELECT number, toString(number), NULL::Nullable(Float64) FROM numbers(10) WHERE number > 4", &id, &name, &score); err != nil {
+		t.Fatalf("QueryRow fail, err: %s", err)
+	}
+	if id != 5 || name != "5" || score != nil {
+		t.Errorf("expected 5, 5 and nil, got %d, %s and %v", id, name, score)
+	}
+	if err := sess.QueryRow("SELECT 1, 2", &id); err == nil {
+		t.Errorf("expected an error for a column count mismatch")
+	}
+	if err := sess.QueryRow("SELECT number FROM numbers(10) WHERE number > 100", &id); err != ErrEmptyResult {
+		t.Errorf("expected ErrEmptyResult, got %v", err)
+	}
+
+	var count int64
+	if err := sess.QueryScalar("SELECT count() FROM numbers(1000000)", &count); err != nil || count != 1000000 {
+		t.Errorf("expected a count of 1000000, got %d, err: %v", count, err)
+	}
+	var text string
+	if err := sess.QueryScalar("SELECT 42", &text); err != nil || text != "42" {
+		t.Errorf("expected 42 as text, got %q, err: %v", text, err)
+	}
+	if err := sess.QueryScalar("SELECT 'a'", &count); err == nil {
+		t.Errorf("expected an error converting a string to an integer")
+	}
+}
@@ -0,0 +1,81 @@
+package chdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// ColumnMeta describes a single column of a query result: its name, ClickHouse type,
+// nullability, the Go type its values are decoded into and the names of its tuple elements.
+type ColumnMeta = pqconv.ColumnMeta
+
+// QueryColumns returns the metadata of the columns produced by the given query.
+// The query is executed in streaming mode and only its first chunk is read. The types of the columns are
+// those reported by DESCRIBE, e.g. LowCardinality(String) for columns Parquet holds as plain strings, or
+// mapped from Parquet when the query can't be described.
+func (s *Session) QueryColumns(queryStr string) ([]ColumnMeta, error) {
+	types := map[string]string{}
+	if described, err := s.DescribeQuery(queryStr); err == nil {
+		for _, c := range described {
+			types[c.Name] = c.Type
+		}
+	}
+	stream, err := s.QueryStream(queryStr, "Parquet")
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Free()
+
+	chunk := stream.GetNext()
+	if chunk == nil {
+		return nil, fmt.Errorf("result is nil")
+	}
+	defer chunk.Free()
+	if err := chunk.Error(); err != nil {
+		return nil, err
+	}
+	buf := chunk.Buf()
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("result is nil")
+	}
+	if err := pqconv.CheckChunk(buf); err != nil {
+		return nil, err
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		return nil, err
+	}
+	columns := pqconv.Columns(file.Schema().Fields())
+	pqconv.SetTypes(columns, types)
+	return columns, nil
+}
+
+// ColumnInfo describes a column as reported by DESCRIBE.
+type ColumnInfo struct {
+	Name string `json:"name"`
+	// ClickHouse type of the column, e.g. "Nullable(UInt32)".
+	Type string `json:"type"`
+}
+
+// DescribeQuery returns the names and ClickHouse types of the columns produced by the given query,
+// using DESCRIBE on it as a subquery: the query is analyzed but not executed.
+func (s *Session) DescribeQuery(queryStr string) ([]ColumnInfo, error) {
+	queryStr = trimQuery(queryStr)
+	var columns []ColumnInfo
+	err := s.ForEachJSONRow("DESCRIBE (\n"+queryStr+"\n)", func(row json.RawMessage) error {
+		var col ColumnInfo
+		if err := json.Unmarshal(row, &col); err != nil {
+			return err
+		}
+		columns = append(columns, col)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
@@ -0,0 +1,165 @@
+package chdb
+
+import (
+	"sync/atomic"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// defaultMaxConcurrentQueries serializes the queries of a session, as the native connection doesn't support
+// running queries concurrently.
+const defaultMaxConcurrentQueries = 1
+
+// WithMaxConcurrentQueries sets how many calls may run at the same time on the native connection of the session,
+// the others waiting for their turn. It defaults to 1, which serializes the queries: only raise it with a chDB
+// library that supports concurrent queries on a single connection.
+func WithMaxConcurrentQueries(n int) Option {
+	return func(s *Session) {
+		if n > 0 {
+			s.maxQueries = n
+		}
+	}
+}
+
+// limitedConn limits the number of calls running at the same time on a connection. Queries hold a slot while they
+// run, and streams hold one while they fetch a chunk, so that the chunks of a stream may be read in between other
+// queries. Once closed, its calls and the fetches of its streams fail with ErrSessionClosed instead of using the
+// freed native connection.
+type limitedConn struct {
+	chdbpurego.ChdbConn
+	slots  chan struct{}
+	closed atomic.Bool  // set by Close while holding every slot
+	gen    atomic.Int64 // incremented when the native connection is replaced by reopen
+}
+
+func newLimitedConn(conn chdbpurego.ChdbConn, slots chan struct{}) *limitedConn {
+	return &limitedConn{ChdbConn: conn, slots: slots}
+}
+
+func (c *limitedConn) Query(queryStr string, formatStr string) (chdbpurego.ChdbResult, error) {
+	c.slots <- struct{}{}
+	defer func() { <-c.slots }()
+	if c.closed.Load() {
+		return nil, ErrSessionClosed
+	}
+	return c.ChdbConn.Query(queryStr, formatStr)
+}
+
+func (c *limitedConn) QueryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	c.slots <- struct{}{}
+	defer func() { <-c.slots }()
+	if c.closed.Load() {
+		return nil, ErrSessionClosed
+	}
+	return c.queryStreaming(queryStr, formatStr)
+}
+
+// queryStreaming starts a stream on the native connection, whose slots must be held, limiting its fetches.
+func (c *limitedConn) queryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	stream, err := c.ChdbConn.QueryStreaming(queryStr, formatStr)
+	if err != nil || stream == nil {
+		return stream, err
+	}
+	s := &limitedStream{ChdbStreamResult: stream, conn: c}
+	s.gen = c.gen.Load()
+	return s, nil
+}
+
+// Close waits for all the running calls to return before closing the connection. The calls waiting for a slot then
+// fail with ErrSessionClosed.
+func (c *limitedConn) Close() {
+	c.acquireAll()
+	defer c.releaseAll()
+	if c.closed.Swap(true) {
+		return
+	}
+	c.ChdbConn.Close()
+}
+
+// exclusive runs fn while holding every slot, so that no other call runs on the connection until fn returns, e.g.
+// while it switches the current database for a query. The calls of fn go to the native connection through held,
+// as the ones of c would wait for a slot forever.
+func (c *limitedConn) exclusive(fn func(held chdbpurego.ChdbConn) error) error {
+	c.acquireAll()
+	defer c.releaseAll()
+	if c.closed.Load() {
+		return ErrSessionClosed
+	}
+	return fn(heldConn{c})
+}
+
+// heldConn runs the calls of a limitedConn whose slots are all held by exclusive. Its streams still hold a slot
+// while fetching a chunk once exclusive returns.
+type heldConn struct {
+	*limitedConn
+}
+
+func (c heldConn) Query(queryStr string, formatStr string) (chdbpurego.ChdbResult, error) {
+	return c.ChdbConn.Query(queryStr, formatStr)
+}
+
+func (c heldConn) QueryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	return c.queryStreaming(queryStr, formatStr)
+}
+
+// reopen closes the native connection, runs fn, e.g. to let chDB open another database, and replaces the connection
+// with the one returned by open. Every slot is held meanwhile: running calls are waited for, and new ones wait for
+// the new connection. The streams of the closed connection fail with ErrSessionClosed. If open fails, the connection
+// stays closed.
+func (c *limitedConn) reopen(fn func() error, open func() (chdbpurego.ChdbConn, error)) (fnErr, openErr error) {
+	c.acquireAll()
+	defer c.releaseAll()
+	if c.closed.Load() {
+		return nil, ErrSessionClosed
+	}
+	c.ChdbConn.Close()
+	c.gen.Add(1)
+	fnErr = fn()
+	conn, err := open()
+	if err != nil {
+		c.closed.Store(true)
+		return fnErr, err
+	}
+	c.ChdbConn = conn
+	return fnErr, nil
+}
+
+// acquireAll waits for the running calls to return and holds every slot, so that no call runs until releaseAll.
+func (c *limitedConn) acquireAll() {
+	for i := 0; i < cap(c.slots); i++ {
+		c.slots <- struct{}{}
+	}
+}
+
+func (c *limitedConn) releaseAll() {
+	for i := 0; i < cap(c.slots); i++ {
+		<-c.slots
+	}
+}
+
+// limitedStream holds a slot of its connection while fetching a chunk.
+// Cancel and Free don't wait for a slot, so that they can interrupt a running fetch.
+type limitedStream struct {
+	chdbpurego.ChdbStreamResult
+	conn        *limitedConn
+	gen         int64       // generation of the native connection of the stream
+	interrupted atomic.Bool // whether a fetch found the connection closed or replaced
+}
+
+// GetNext returns nil once the connection is closed or replaced, Error then returning ErrSessionClosed.
+func (s *limitedStream) GetNext() chdbpurego.ChdbResult {
+	s.conn.slots <- struct{}{}
+	defer func() { <-s.conn.slots }()
+	if s.conn.closed.Load() || s.conn.gen.Load() != s.gen {
+		s.interrupted.Store(true)
+		return nil
+	}
+	return s.ChdbStreamResult.GetNext()
+}
+
+func (s *limitedStream) Error() error {
+	if s.interrupted.Load() {
+		return ErrSessionClosed
+	}
+	return s.ChdbStreamResult.Error()
+}
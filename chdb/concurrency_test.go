@@ -0,0 +1,243 @@
+package chdb
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// busyConn is a fake connection recording the highest number of queries running at the same time.
+type busyConn struct {
+	running, peak atomic.Int32
+}
+
+func (c *busyConn) Query(queryStr string, formatStr string) (chdbpurego.ChdbResult, error) {
+	n := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return nil, nil
+}
+
+func (c *busyConn) QueryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	return nil, nil
+}
+
+func (c *busyConn) Ready() bool { return true }
+
+func (c *busyConn) Close() {}
+
+func TestLimitedConn(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		busy := &busyConn{}
+		conn := newLimitedConn(busy, make(chan struct{}, limit))
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn.Query("SELECT 1", "CSV")
+			}()
+		}
+		wg.Wait()
+		if peak := busy.peak.Load(); peak < 1 || int(peak) > limit {
+			t.Errorf("limit %d: expected at most %d concurrent queries, got %d", limit, limit, peak)
+		}
+		conn.Close()
+		if len(conn.slots) != 0 {
+			t.Errorf("limit %d: expected Close to release the slots, %d are held", limit, len(conn.slots))
+		}
+	}
+}
+
+func TestSessionConcurrentQueries(t *testing.T) {
+	sess := testSession(t)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := sess.Query("SELECT sum(number) FROM numbers(1000)")
+			if err != nil {
+				errs <- err
+				return
+			}
+			if res.String() != "499500\n" {
+				t.Errorf("unexpected result %q", res.String())
+			}
+			res.Free()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Query fail, err: %s", err)
+	}
+}
+
+// TestSessionCloseWhileQuerying closes a session while queries run on it, for the race detector to check that
+// the closed state of the session is not raced on.
+func TestSessionCloseWhileQuerying(t *testing.T) {
+	slots := make(chan struct{}, 4)
+	sess := &Session{conn: newLimitedConn(&busyConn{}, slots), slots: slots, defaultFormat: defaultOutputFormat, cleanup: func(string) error { return nil }}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := sess.Query("SELECT 1"); err != nil {
+					if !errors.Is(err, ErrSessionClosed) {
+						t.Errorf("expected ErrSessionClosed, got %v", err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	sess.Close()
+	wg.Wait()
+	if _, err := sess.QueryStream("SELECT 1"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from QueryStream, got %v", err)
+	}
+}
+
+// chunkStream is a fake stream serving empty chunks.
+type chunkStream struct{}
+
+func (chunkStream) GetNext() chdbpurego.ChdbResult { return &bufferResult{} }
+func (chunkStream) Error() error                   { return nil }
+func (chunkStream) Cancel()                        {}
+func (chunkStream) Free()                          {}
+
+type streamingConn struct {
+	busyConn
+}
+
+func (c *streamingConn) QueryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	return chunkStream{}, nil
+}
+
+func TestLimitedConnClosed(t *testing.T) {
+	conn := newLimitedConn(&streamingConn{}, make(chan struct{}, 2))
+	stream, err := conn.QueryStreaming("SELECT 1", "CSV")
+	if err != nil {
+		t.Fatalf("QueryStreaming fail, err: %s", err)
+	}
+	if stream.GetNext() == nil {
+		t.Fatalf("expected a chunk before Close")
+	}
+	conn.Close()
+	conn.Close()
+
+	if _, err := conn.Query("SELECT 1", "CSV"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from Query, got %v", err)
+	}
+	if _, err := conn.QueryStreaming("SELECT 1", "CSV"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from QueryStreaming, got %v", err)
+	}
+	if chunk := stream.GetNext(); chunk != nil {
+		t.Errorf("expected no chunk from a closed connection")
+	}
+	if err := stream.Error(); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from the stream, got %v", err)
+	}
+}
+
+func TestLimitedConnReopen(t *testing.T) {
+	old, replacement := &streamingConn{}, &busyConn{}
+	conn := newLimitedConn(old, make(chan struct{}, 2))
+	stream, err := conn.QueryStreaming("SELECT 1", "CSV")
+	if err != nil {
+		t.Fatalf("QueryStreaming fail, err: %s", err)
+	}
+
+	done := make(chan struct{})
+	fnErr, openErr := conn.reopen(func() error {
+		go func() {
+			// waits for the connection to be reopened
+			conn.Query("SELECT 1", "CSV")
+			close(done)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-done:
+			t.Errorf("a query ran while the connection was closed")
+		default:
+		}
+		return errors.New("export failed")
+	}, func() (chdbpurego.ChdbConn, error) {
+		return replacement, nil
+	})
+	if fnErr == nil || openErr != nil {
+		t.Fatalf("expected the error of fn only, got %v, %v", fnErr, openErr)
+	}
+	<-done
+	if replacement.peak.Load() != 1 {
+		t.Errorf("expected the waiting query to run on the new connection")
+	}
+	if stream.GetNext() != nil || !errors.Is(stream.Error(), ErrSessionClosed) {
+		t.Errorf("expected the stream of the replaced connection to fail with ErrSessionClosed")
+	}
+
+	_, openErr = conn.reopen(func() error { return nil }, func() (chdbpurego.ChdbConn, error) {
+		return nil, errors.New("open failed")
+	})
+	if openErr == nil {
+		t.Fatalf("expected the open error")
+	}
+	if _, err := conn.Query("SELECT 1", "CSV"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed after a failed reopen, got %v", err)
+	}
+}
+
+func TestLimitedConnExclusive(t *testing.T) {
+	busy := &streamingConn{}
+	conn := newLimitedConn(busy, make(chan struct{}, 3))
+	done := make(chan struct{})
+	var stream chdbpurego.ChdbStreamResult
+	err := conn.exclusive(func(held chdbpurego.ChdbConn) error {
+		go func() {
+			// waits for exclusive to return
+			conn.Query("SELECT 1", "CSV")
+			close(done)
+		}()
+		if _, err := held.Query("USE db", "CSV"); err != nil {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-done:
+			t.Errorf("a query ran while every slot was held")
+		default:
+		}
+		var err error
+		stream, err = held.QueryStreaming("SELECT 1", "CSV")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("exclusive fail, err: %s", err)
+	}
+	<-done
+	if stream.GetNext() == nil {
+		t.Errorf("expected a chunk from the stream started by exclusive")
+	}
+	if len(conn.slots) != 0 {
+		t.Errorf("expected the stream to release its slot, %d are held", len(conn.slots))
+	}
+	conn.Close()
+	if err := conn.exclusive(func(chdbpurego.ChdbConn) error { return nil }); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed once closed, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package chdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CountRows returns the number of rows the given query produces, without transferring them.
+// The query is wrapped as a subquery of SELECT count(), so ORDER BY and LIMIT clauses keep their meaning;
+// trailing semicolons are removed.
+func (s *Session) CountRows(queryStr string) (int64, error) {
+	queryStr = trimQuery(queryStr)
+	if queryStr == "" {
+		return 0, fmt.Errorf("empty query")
+	}
+	// the newline keeps a trailing line comment from swallowing the closing parenthesis
+	res, err := s.Query("SELECT count() FROM (\n"+queryStr+"\n)", "TSVRaw")
+	if err != nil {
+		return 0, err
+	}
+	defer res.Free()
+	return strconv.ParseInt(strings.TrimSpace(res.String()), 10, 64)
+}
+
+// trimQuery removes the surrounding spaces and the trailing semicolons of a query, so that it can be used as a subquery.
+func trimQuery(queryStr string) string {
+	queryStr = strings.TrimSpace(queryStr)
+	for strings.HasSuffix(queryStr, ";") {
+		queryStr = strings.TrimSpace(strings.TrimSuffix(queryStr, ";"))
+	}
+	return queryStr
+}
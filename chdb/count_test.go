@@ -0,0 +1,32 @@
+package chdb
+
+import (
+	"testing"
+)
+
+func TestCountRows(t *testing.T) {
+	sess := testSession(t)
+	tests := []struct {
+		query    string
+		expected int64
+	}{
+		{"SELECT number FROM numbers(100)", 100},
+		{"SELECT number FROM numbers(100) ORDER BY number DESC LIMIT 10", 10},
+		{"SELECT number FROM numbers(100) WHERE number % 2 = 0;", 50},
+		{"SELECT number FROM numbers(100) LIMIT 5 ; ;", 5},
+		{"SELECT number FROM numbers(100) -- trailing comment", 100},
+	}
+	for _, tt := range tests {
+		n, err := sess.CountRows(tt.query)
+		if err != nil {
+			t.Fatalf("CountRows(%q) fail, err: %s", tt.query, err)
+		}
+		if n != tt.expected {
+			t.Errorf("CountRows(%q) = %d, expected %d", tt.query, n, tt.expected)
+		}
+	}
+
+	if _, err := sess.CountRows(" ; "); err == nil {
+		t.Errorf("expected an error counting an empty query")
+	}
+}
@@ -0,0 +1,36 @@
+package chdb
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// DiskUsage returns the total size in bytes of the files under the session directory, e.g. to alert before
+// the disk fills up. It returns zero for in-memory sessions, which have no directory.
+func (s *Session) DiskUsage() (int64, error) {
+	if s.closed.Load() {
+		return 0, ErrSessionClosed
+	}
+	if s.path == "" {
+		return 0, nil
+	}
+	var total int64
+	err := filepath.WalkDir(s.path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
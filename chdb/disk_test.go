@@ -0,0 +1,45 @@
+package chdb
+
+import (
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	sess := testSession(t)
+
+	before, err := sess.DiskUsage()
+	if err != nil {
+		t.Fatalf("DiskUsage fail, err: %s", err)
+	}
+	if _, err := sess.Query("CREATE TABLE TestDiskUsage (id UInt64, name String) ENGINE = MergeTree ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("INSERT INTO TestDiskUsage SELECT number, toString(number) FROM numbers(100000)"); err != nil {
+		t.Fatal(err)
+	}
+	after, err := sess.DiskUsage()
+	if err != nil {
+		t.Fatalf("DiskUsage fail, err: %s", err)
+	}
+	if after <= 0 || after <= before {
+		t.Errorf("expected the disk usage to grow after inserting data, got %d then %d", before, after)
+	}
+}
+
+func TestDiskUsageInMemory(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession("", WithConnStr(":memory:"))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	size, err := sess.DiskUsage()
+	if err != nil {
+		t.Fatalf("DiskUsage fail, err: %s", err)
+	}
+	if size != 0 {
+		t.Errorf("expected zero for an in-memory session, got %d", size)
+	}
+}
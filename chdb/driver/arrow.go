@@ -0,0 +1,260 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+)
+
+// arrowRows reads ArrowStream results. The result is either a single buffer, or the chunks of a stream,
+// each of them a complete Arrow stream.
+type arrowRows struct {
+	streamRows
+	result     chdbpurego.ChdbResult       // current chunk
+	stream     chdbpurego.ChdbStreamResult // nil for buffered results
+	reader     *ipc.Reader
+	record     arrow.Record
+	recordRow  int
+	fields     []arrow.Field // fields reported by the rows
+	projection []int         // indexes of fields in the chunk schema, nil when not projected
+	useUnsafe  bool          // the byte slices reference the record buffers
+	rowsConfig
+}
+
+func newArrowRows(result chdbpurego.ChdbResult, stream chdbpurego.ChdbStreamResult, useUnsafe bool, opts []RowsOption) (*arrowRows, error) {
+	rows := &arrowRows{result: result, stream: stream, useUnsafe: useUnsafe, rowsConfig: newRowsConfig(opts)}
+	rows.columns = rows.arrowColumns
+	if err := rows.openReader(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// openReader reads the schema of the current chunk. Empty chunks have no schema and no rows.
+func (r *arrowRows) openReader() error {
+	if len(r.result.Buf()) == 0 {
+		return nil
+	}
+	reader, err := ipc.NewReader(bytes.NewReader(r.result.Buf()))
+	if err != nil {
+		return fmt.Errorf("read arrow stream: %w", err)
+	}
+	fields := reader.Schema().Fields()
+	projection, err := r.arrowProjection(fields)
+	if err != nil {
+		reader.Release()
+		return err
+	}
+	if projection != nil {
+		projected := make([]arrow.Field, len(projection))
+		for i, index := range projection {
+			projected[i] = fields[index]
+		}
+		fields = projected
+	}
+	r.reader, r.fields, r.projection = reader, fields, projection
+	return nil
+}
+
+// arrowProjection returns the indexes of the projected columns in fields, or nil when the rows are not projected.
+func (r *arrowRows) arrowProjection(fields []arrow.Field) ([]int, error) {
+	if r.rowsConfig.projection == nil {
+		return nil, nil
+	}
+	indexes := make([]int, len(r.rowsConfig.projection))
+	for i, name := range r.rowsConfig.projection {
+		indexes[i] = -1
+		for j, f := range fields {
+			if f.Name == name {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, fmt.Errorf("projected column %q not found in the result", name)
+		}
+	}
+	return indexes, nil
+}
+
+func (r *arrowRows) Columns() []string {
+	out := make([]string, len(r.fields))
+	for i, f := range r.fields {
+		out[i] = f.Name
+	}
+	return out
+}
+
+// Close releases the result, see streamRows.close.
+func (r *arrowRows) Close() error {
+	return r.close(func() {
+		r.record = nil
+		if r.reader != nil {
+			r.reader.Release()
+			r.reader = nil
+		}
+		if r.stream != nil {
+			r.stream.Free()
+			r.stream = nil
+		} else {
+			r.result.Free()
+		}
+		r.result = nil
+	})
+}
+
+func (r *arrowRows) Next(dest []driver.Value) error {
+	return r.read(func() error {
+		return r.next(dest)
+	})
+}
+
+func (r *arrowRows) next(dest []driver.Value) error {
+	for r.record == nil || r.recordRow == int(r.record.NumRows()) {
+		if r.reader != nil {
+			if r.reader.Next() {
+				r.record, r.recordRow = r.reader.Record(), 0
+				continue
+			}
+			if err := r.reader.Err(); err != nil && err != io.EOF {
+				return err
+			}
+		}
+		if err := r.nextChunk(); err != nil {
+			return err
+		}
+	}
+	decoder := r.decoder()
+	for i := range r.fields {
+		column := i
+		if r.projection != nil {
+			column = r.projection[i]
+		}
+		v, err := decoder.value(r.record.Column(column), r.recordRow)
+		if err == nil && v != nil && r.isUUID(r.fields[i]) {
+			v, err = decoder.uuid(v)
+		}
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	r.recordRow++
+	return nil
+}
+
+// nextChunk moves to the next chunk of the stream, returning io.EOF once it is exhausted.
+func (r *arrowRows) nextChunk() error {
+	if r.stream == nil {
+		return io.EOF
+	}
+	r.record = nil
+	if r.reader != nil {
+		r.reader.Release()
+		r.reader = nil
+	}
+	if r.result == nil {
+		return io.EOF
+	}
+	// free the previous chunk
+	r.result.Free()
+	r.result = r.stream.GetNext()
+	if r.result == nil {
+		return io.EOF
+	}
+	if r.result.Error() != nil {
+		return fmt.Errorf("error in chunk: %s", r.result.Error())
+	}
+	if r.result.RowsRead() == 0 {
+		return io.EOF
+	}
+	return r.openReader()
+}
+
+func (r *arrowRows) ColumnTypeDatabaseTypeName(index int) string {
+	if _, ok := r.columnTypes[r.fields[index].Name]; ok {
+		return r.databaseTypeName(r.fields[index].Name, "")
+	}
+	return arrowClickHouseType(r.fields[index], r.unwrapLowCardinality)
+}
+
+func (r *arrowRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.fields[index].Nullable, true
+}
+
+func (r *arrowRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	switch t := r.fields[index].Type.(type) {
+	case *arrow.Decimal128Type:
+		return int64(t.Precision), int64(t.Scale), true
+	case *arrow.Decimal256Type:
+		return int64(t.Precision), int64(t.Scale), true
+	case *arrow.TimestampType:
+		if p := timestampPrecision(t); p > 0 {
+			return int64(p), 0, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (r *arrowRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.scanType(r.fields[index])
+}
+
+// decoder returns the decoder of the values of the rows.
+func (r *arrowRows) decoder() arrowDecoder {
+	return arrowDecoder{unsafeBytes: r.useUnsafe && !r.resultCopy, widenUnsigned: r.widenUnsigned, location: r.location, uuidStrings: r.uuidStrings, decimalStrings: r.decimalStrings}
+}
+
+// isUUID reports whether f is a UUID column. Arrow has no UUID type, so UUID columns are only known when described.
+func (r *arrowRows) isUUID(f arrow.Field) bool {
+	return baseType(r.columnTypes[f.Name]) == "UUID"
+}
+
+// scanType returns the scan type of f, see arrowScanType, taking the described UUID columns into account.
+func (r *arrowRows) scanType(f arrow.Field) reflect.Type {
+	if !r.isUUID(f) {
+		decoder := r.decoder()
+		return arrowScanType(f, &decoder)
+	}
+	t := uuidType
+	if r.uuidStrings {
+		t = reflect.TypeOf("")
+	}
+	if f.Nullable {
+		return reflect.PointerTo(t)
+	}
+	return t
+}
+
+func (r *arrowRows) ColumnTypeElementNames(index int) []string {
+	return arrowElementNames(r.fields[index].Type)
+}
+
+// ColumnTypeEnumValues returns nil: Arrow results hold enums as plain integers.
+func (r *arrowRows) ColumnTypeEnumValues(index int) map[string]int16 {
+	return nil
+}
+
+// arrowColumns returns the metadata of the reported columns, see ColumnMetadata. ParquetType is left empty.
+func (r *arrowRows) arrowColumns() []chdb.ColumnMeta {
+	out := make([]chdb.ColumnMeta, len(r.fields))
+	for i, f := range r.fields {
+		out[i] = chdb.ColumnMeta{
+			Name:         f.Name,
+			Type:         arrowClickHouseType(f, false),
+			Nullable:     f.Nullable,
+			ScanType:     r.scanType(f),
+			ElementNames: arrowElementNames(f.Type),
+		}
+	}
+	pqconv.SetTypes(out, r.columnTypes)
+	return out
+}
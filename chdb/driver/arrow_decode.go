@@ -0,0 +1,450 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+	"github.com/google/uuid"
+)
+
+// arrowDecoder converts the values of Arrow arrays into Go values, following the conversions of the Parquet decoder.
+type arrowDecoder struct {
+	// unsafeBytes makes decoded byte slices reference the Arrow buffers instead of copying them.
+	unsafeBytes   bool
+	widenUnsigned bool
+	// location is the time zone of timestamps whose type has none, UTC when nil.
+	location *time.Location
+	// uuidStrings decodes UUID values as their canonical string instead of a uuid.UUID.
+	uuidStrings bool
+	// decimalStrings decodes decimals as their exact decimal text, e.g. "-12.34", instead of a *big.Rat.
+	decimalStrings bool
+}
+
+// value decodes the i-th value of an array. NULL values are decoded as nil.
+func (d *arrowDecoder) value(arr arrow.Array, i int) (any, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.Int8:
+		return a.Value(i), nil
+	case *array.Int16:
+		return a.Value(i), nil
+	case *array.Int32:
+		return a.Value(i), nil
+	case *array.Int64:
+		return a.Value(i), nil
+	case *array.Uint8:
+		if d.widenUnsigned {
+			return int16(a.Value(i)), nil
+		}
+		return a.Value(i), nil
+	case *array.Uint16:
+		if d.widenUnsigned {
+			return int32(a.Value(i)), nil
+		}
+		return a.Value(i), nil
+	case *array.Uint32:
+		if d.widenUnsigned {
+			return int64(a.Value(i)), nil
+		}
+		return a.Value(i), nil
+	case *array.Uint64:
+		if d.widenUnsigned && a.Value(i) <= math.MaxInt64 {
+			return int64(a.Value(i)), nil
+		}
+		return a.Value(i), nil
+	case *array.Float32:
+		return a.Value(i), nil
+	case *array.Float64:
+		return a.Value(i), nil
+	case *array.String:
+		return strings.Clone(a.Value(i)), nil
+	case *array.LargeString:
+		return strings.Clone(a.Value(i)), nil
+	case *array.Binary:
+		return d.bytes(a.Value(i)), nil
+	case *array.LargeBinary:
+		return d.bytes(a.Value(i)), nil
+	case *array.FixedSizeBinary:
+		return d.bytes(a.Value(i)), nil
+	case *array.Date32:
+		return a.Value(i).ToTime(), nil
+	case *array.Date64:
+		return a.Value(i).ToTime(), nil
+	case *array.Timestamp:
+		t := a.DataType().(*arrow.TimestampType)
+		loc, err := d.timestampLocation(t)
+		if err != nil {
+			return nil, err
+		}
+		return a.Value(i).ToTime(t.Unit).In(loc), nil
+	case *array.Decimal128:
+		scale := a.DataType().(*arrow.Decimal128Type).Scale
+		if d.decimalStrings {
+			return a.Value(i).ToString(scale), nil
+		}
+		return decimalRat(a.Value(i).BigInt(), scale), nil
+	case *array.Decimal256:
+		scale := a.DataType().(*arrow.Decimal256Type).Scale
+		if d.decimalStrings {
+			return a.Value(i).ToString(scale), nil
+		}
+		return decimalRat(a.Value(i).BigInt(), scale), nil
+	case *array.Dictionary:
+		return d.value(a.Dictionary(), a.GetValueIndex(i))
+	case *array.Map:
+		return d.mapValue(a, i)
+	case array.ListLike:
+		return d.list(a, i)
+	case *array.Struct:
+		values := make([]any, a.NumField())
+		for j := range values {
+			v, err := d.value(a.Field(j), i)
+			if err != nil {
+				return nil, err
+			}
+			values[j] = v
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("could not cast to type: %s", arr.DataType())
+}
+
+func (d *arrowDecoder) bytes(b []byte) []byte {
+	if d.unsafeBytes {
+		return b
+	}
+	return bytes.Clone(b)
+}
+
+// uuid decodes a value of a UUID column, held as 16 bytes or as its canonical string, into a uuid.UUID.
+func (d *arrowDecoder) uuid(v any) (any, error) {
+	var u uuid.UUID
+	var err error
+	switch v := v.(type) {
+	case []byte:
+		u, err = uuid.FromBytes(v)
+	case string:
+		u, err = uuid.Parse(v)
+	default:
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode uuid: %w", err)
+	}
+	if d.uuidStrings {
+		return u.String(), nil
+	}
+	return u, nil
+}
+
+// decimalRat returns the value of an unscaled decimal with scale fractional digits.
+func decimalRat(unscaled *big.Int, scale int32) *big.Rat {
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+}
+
+// list decodes the i-th list of an array into a slice, typed when the element type is known.
+func (d *arrowDecoder) list(a array.ListLike, i int) (any, error) {
+	start, end := a.ValueOffsets(i)
+	values := a.ListValues()
+	var out reflect.Value
+	if t := d.elementType(a.DataType().(arrow.ListLikeType).ElemField()); t != nil {
+		out = reflect.MakeSlice(reflect.SliceOf(t), int(end-start), int(end-start))
+	} else {
+		out = reflect.ValueOf(make([]any, end-start))
+	}
+	for j := start; j < end; j++ {
+		v, err := d.value(values, int(j))
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		dst := out.Index(int(j - start))
+		if dst.Kind() == reflect.Pointer {
+			// nullable elements of typed slices are stored as pointers, leaving nil for NULL
+			p := reflect.New(dst.Type().Elem())
+			dst.Set(p)
+			dst = p.Elem()
+		}
+		dst.Set(reflect.ValueOf(v))
+	}
+	return out.Interface(), nil
+}
+
+// timestampLocation returns the time zone of the values of a timestamp type: the time zone of the type when it has
+// one, as ClickHouse sets for DateTime columns, and the location of the decoder otherwise.
+func (d *arrowDecoder) timestampLocation(t *arrow.TimestampType) (*time.Location, error) {
+	if t.TimeZone != "" {
+		return native.Location(t.TimeZone)
+	}
+	if d.location != nil {
+		return d.location, nil
+	}
+	return time.UTC, nil
+}
+
+// mapValue decodes the i-th map of an array, see mapType.
+func (d *arrowDecoder) mapValue(a *array.Map, i int) (any, error) {
+	t := d.mapType(a.DataType().(*arrow.MapType))
+	start, end := a.ValueOffsets(i)
+	keys, items := a.Keys(), a.Items()
+	out := reflect.MakeMapWithSize(t, int(end-start))
+	for j := int(start); j < int(end); j++ {
+		k, err := d.value(keys, j)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(items, j)
+		if err != nil {
+			return nil, err
+		}
+		key := reflect.ValueOf(k)
+		if t.Key().Kind() == reflect.String && key.Kind() != reflect.String {
+			if b, ok := k.([]byte); ok {
+				key = reflect.ValueOf(string(b))
+			} else {
+				key = reflect.ValueOf(fmt.Sprint(k))
+			}
+		}
+		value := reflect.New(t.Elem()).Elem()
+		if v != nil {
+			if value.Kind() == reflect.Pointer {
+				value.Set(reflect.New(value.Type().Elem()))
+				value.Elem().Set(reflect.ValueOf(v))
+			} else {
+				value.Set(reflect.ValueOf(v))
+			}
+		}
+		out.SetMapIndex(key, value)
+	}
+	return out.Interface(), nil
+}
+
+// mapType returns the type of the maps a Map column is decoded into: a typed map when the types of its keys and
+// values are known, e.g. map[string]uint8 for Map(String, UInt8), and map[K]any otherwise. Keys which can't be
+// map keys, e.g. the []byte of FixedString keys, are converted to strings.
+func (d *arrowDecoder) mapType(t *arrow.MapType) reflect.Type {
+	keyType := d.valueType(t.KeyType())
+	if keyType == nil || !keyType.Comparable() {
+		keyType = reflect.TypeOf("")
+	}
+	valueType := d.valueType(t.ItemType())
+	if valueType == nil {
+		valueType = reflect.TypeOf((*any)(nil)).Elem()
+	} else if t.ItemField().Nullable && valueType.Kind() != reflect.Slice {
+		valueType = reflect.PointerTo(valueType)
+	}
+	return reflect.MapOf(keyType, valueType)
+}
+
+// elementType returns the Go type of the elements of a typed slice of list elements, a pointer for nullable ones.
+// It returns nil when the elements are decoded into a []any.
+func (d *arrowDecoder) elementType(f arrow.Field) reflect.Type {
+	t := d.valueType(f.Type)
+	if t == nil || t.Kind() == reflect.Slice && t != bytesType {
+		return nil
+	}
+	if f.Nullable {
+		return reflect.PointerTo(t)
+	}
+	return t
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+	uuidType  = reflect.TypeOf(uuid.UUID{})
+	ratType   = reflect.TypeOf((*big.Rat)(nil))
+)
+
+// valueType returns the Go type value decodes non-null values of the given type into,
+// or nil when it is unsupported or not always the same.
+func (d *arrowDecoder) valueType(t arrow.DataType) reflect.Type {
+	switch t := t.(type) {
+	case *arrow.BooleanType:
+		return reflect.TypeOf(false)
+	case *arrow.Int8Type:
+		return reflect.TypeOf(int8(0))
+	case *arrow.Int16Type:
+		return reflect.TypeOf(int16(0))
+	case *arrow.Int32Type:
+		return reflect.TypeOf(int32(0))
+	case *arrow.Int64Type:
+		return reflect.TypeOf(int64(0))
+	case *arrow.Uint8Type:
+		if d.widenUnsigned {
+			return reflect.TypeOf(int16(0))
+		}
+		return reflect.TypeOf(uint8(0))
+	case *arrow.Uint16Type:
+		if d.widenUnsigned {
+			return reflect.TypeOf(int32(0))
+		}
+		return reflect.TypeOf(uint16(0))
+	case *arrow.Uint32Type:
+		if d.widenUnsigned {
+			return reflect.TypeOf(int64(0))
+		}
+		return reflect.TypeOf(uint32(0))
+	case *arrow.Uint64Type:
+		// widened values are int64 or uint64 depending on whether they fit
+		if d.widenUnsigned {
+			return nil
+		}
+		return reflect.TypeOf(uint64(0))
+	case *arrow.Float32Type:
+		return reflect.TypeOf(float32(0))
+	case *arrow.Float64Type:
+		return reflect.TypeOf(float64(0))
+	case *arrow.Decimal128Type, *arrow.Decimal256Type:
+		if d.decimalStrings {
+			return reflect.TypeOf("")
+		}
+		return ratType
+	case *arrow.StringType, *arrow.LargeStringType:
+		return reflect.TypeOf("")
+	case *arrow.BinaryType, *arrow.LargeBinaryType, *arrow.FixedSizeBinaryType:
+		return bytesType
+	case *arrow.Date32Type, *arrow.Date64Type, *arrow.TimestampType:
+		return timeType
+	case *arrow.DictionaryType:
+		return d.valueType(t.ValueType)
+	case *arrow.MapType:
+		return d.mapType(t)
+	case arrow.ListLikeType:
+		if elem := d.elementType(t.ElemField()); elem != nil {
+			return reflect.SliceOf(elem)
+		}
+		return reflect.TypeOf([]any(nil))
+	case *arrow.StructType:
+		return reflect.TypeOf([]any(nil))
+	}
+	return nil
+}
+
+// arrowScanType returns the type of the values d decodes for a column, a pointer for nullable scalar columns.
+func arrowScanType(f arrow.Field, d *arrowDecoder) reflect.Type {
+	t := d.valueType(f.Type)
+	if t == nil {
+		return reflect.TypeOf((*any)(nil)).Elem()
+	}
+	if f.Nullable && t.Kind() != reflect.Slice {
+		return reflect.PointerTo(t)
+	}
+	return t
+}
+
+// arrowClickHouseType maps an Arrow field back to the ClickHouse type that produced it.
+// LowCardinality columns are reported as LowCardinality(T) unless unwrapped.
+func arrowClickHouseType(f arrow.Field, unwrapLowCardinality bool) string {
+	name := arrowBaseType(f.Type, unwrapLowCardinality)
+	if f.Nullable {
+		if strings.HasPrefix(name, "LowCardinality(") {
+			return "LowCardinality(Nullable(" + strings.TrimPrefix(name, "LowCardinality(") + ")"
+		}
+		return "Nullable(" + name + ")"
+	}
+	return name
+}
+
+func arrowBaseType(t arrow.DataType, unwrapLowCardinality bool) string {
+	switch t := t.(type) {
+	case *arrow.BooleanType:
+		return "Bool"
+	case *arrow.Int8Type:
+		return "Int8"
+	case *arrow.Int16Type:
+		return "Int16"
+	case *arrow.Int32Type:
+		return "Int32"
+	case *arrow.Int64Type:
+		return "Int64"
+	case *arrow.Uint8Type:
+		return "UInt8"
+	case *arrow.Uint16Type:
+		return "UInt16"
+	case *arrow.Uint32Type:
+		return "UInt32"
+	case *arrow.Uint64Type:
+		return "UInt64"
+	case *arrow.Float32Type:
+		return "Float32"
+	case *arrow.Float64Type:
+		return "Float64"
+	case *arrow.StringType, *arrow.LargeStringType, *arrow.BinaryType, *arrow.LargeBinaryType:
+		return "String"
+	case *arrow.FixedSizeBinaryType:
+		return fmt.Sprintf("FixedString(%d)", t.ByteWidth)
+	case *arrow.Date32Type, *arrow.Date64Type:
+		return "Date32"
+	case *arrow.TimestampType:
+		if t.Unit == arrow.Second {
+			return "DateTime"
+		}
+		return fmt.Sprintf("DateTime64(%d)", timestampPrecision(t))
+	case *arrow.Decimal128Type:
+		return fmt.Sprintf("Decimal(%d, %d)", t.Precision, t.Scale)
+	case *arrow.Decimal256Type:
+		return fmt.Sprintf("Decimal(%d, %d)", t.Precision, t.Scale)
+	case *arrow.DictionaryType:
+		if unwrapLowCardinality {
+			return arrowBaseType(t.ValueType, unwrapLowCardinality)
+		}
+		return "LowCardinality(" + arrowBaseType(t.ValueType, unwrapLowCardinality) + ")"
+	case *arrow.MapType:
+		return "Map(" + arrowClickHouseType(t.KeyField(), unwrapLowCardinality) + ", " + arrowClickHouseType(t.ItemField(), unwrapLowCardinality) + ")"
+	case arrow.ListLikeType:
+		return "Array(" + arrowClickHouseType(t.ElemField(), unwrapLowCardinality) + ")"
+	case *arrow.StructType:
+		elems := make([]string, t.NumFields())
+		for i, f := range t.Fields() {
+			elems[i] = arrowClickHouseType(f, unwrapLowCardinality)
+		}
+		return "Tuple(" + strings.Join(elems, ", ") + ")"
+	}
+	return t.String()
+}
+
+// arrowElementNames returns the names of the fields of a struct type holding a tuple, or of the structs of a list of
+// them. It returns nil for other types.
+func arrowElementNames(t arrow.DataType) []string {
+	switch t := t.(type) {
+	case *arrow.MapType:
+		return nil
+	case arrow.ListLikeType:
+		return arrowElementNames(t.Elem())
+	case *arrow.StructType:
+		names := make([]string, t.NumFields())
+		for i, f := range t.Fields() {
+			names[i] = f.Name
+		}
+		return names
+	}
+	return nil
+}
+
+// timestampPrecision returns the number of fractional digits of a timestamp type.
+func timestampPrecision(t *arrow.TimestampType) int {
+	switch t.Unit {
+	case arrow.Millisecond:
+		return 3
+	case arrow.Microsecond:
+		return 6
+	case arrow.Nanosecond:
+		return 9
+	}
+	return 0
+}
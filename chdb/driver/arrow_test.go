@@ -0,0 +1,319 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/google/uuid"
+)
+
+// arrowStream builds an Arrow stream holding a single record, built with the given function.
+func arrowStream(t *testing.T, schema *arrow.Schema, build func(b *array.RecordBuilder)) []byte {
+	t.Helper()
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	build(b)
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	if err := w.Write(rec); err != nil {
+		t.Fatalf("write arrow record fail, err: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close arrow writer fail, err: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestArrowRows(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Uint32},
+		{Name: "price", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}},
+		{Name: "ts", Type: &arrow.TimestampType{Unit: arrow.Millisecond}, Nullable: true},
+		{Name: "tags", Type: arrow.ListOfNonNullable(arrow.BinaryTypes.String)},
+		{Name: "pair", Type: arrow.StructOf(arrow.Field{Name: "a", Type: arrow.PrimitiveTypes.Int8}, arrow.Field{Name: "b", Type: arrow.BinaryTypes.Binary})},
+	}, nil)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	buf := arrowStream(t, schema, func(b *array.RecordBuilder) {
+		b.Field(0).(*array.Uint32Builder).AppendValues([]uint32{1, 2}, nil)
+		b.Field(1).(*array.Decimal128Builder).AppendValues([]decimal128.Num{decimal128.FromI64(1234), decimal128.FromI64(-5)}, nil)
+		tsb := b.Field(2).(*array.TimestampBuilder)
+		tsb.Append(arrow.Timestamp(ts.UnixMilli()))
+		tsb.AppendNull()
+		lb := b.Field(3).(*array.ListBuilder)
+		lb.Append(true)
+		lb.ValueBuilder().(*array.StringBuilder).AppendValues([]string{"a", "b"}, nil)
+		lb.Append(true)
+		sb := b.Field(4).(*array.StructBuilder)
+		for i := 0; i < 2; i++ {
+			sb.Append(true)
+			sb.FieldBuilder(0).(*array.Int8Builder).Append(int8(i))
+			sb.FieldBuilder(1).(*array.BinaryBuilder).Append([]byte{byte(i)})
+		}
+	})
+
+	rows, err := ARROW.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	if !reflect.DeepEqual(rows.Columns(), []string{"id", "price", "ts", "tags", "pair"}) {
+		t.Errorf("unexpected columns %v", rows.Columns())
+	}
+	typed := rows.(driver.RowsColumnTypeDatabaseTypeName)
+	for i, expected := range []string{"UInt32", "Decimal(10, 2)", "Nullable(DateTime64(3))", "Array(String)", "Tuple(Int8, String)"} {
+		if got := typed.ColumnTypeDatabaseTypeName(i); got != expected {
+			t.Errorf("column %d: expected type %s, got %s", i, expected, got)
+		}
+	}
+	if p, s, ok := rows.(driver.RowsColumnTypePrecisionScale).ColumnTypePrecisionScale(1); !ok || p != 10 || s != 2 {
+		t.Errorf("expected precision 10 and scale 2, got %d, %d, %t", p, s, ok)
+	}
+	if names := rows.(RowsColumnTypeElementNames).ColumnTypeElementNames(4); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("expected element names [a b], got %q", names)
+	}
+
+	expected := [][]driver.Value{
+		{uint32(1), big.NewRat(1234, 100), ts, []string{"a", "b"}, []any{int8(0), []byte{0}}},
+		{uint32(2), big.NewRat(-5, 100), nil, []string{}, []any{int8(1), []byte{1}}},
+	}
+	values := make([]driver.Value, 5)
+	for i, row := range expected {
+		if err := rows.Next(values); err != nil {
+			t.Fatalf("row %d: Next fail, err: %s", i, err)
+		}
+		if !reflect.DeepEqual(values, row) {
+			t.Errorf("row %d: expected %#v, got %#v", i, row, values)
+		}
+	}
+	if err := rows.Next(values); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	rows, err = ARROW.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false, WithDecimalStrings())
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	if got := rows.(driver.RowsColumnTypeScanType).ColumnTypeScanType(1); got != reflect.TypeOf("") {
+		t.Errorf("expected the string scan type, got %s", got)
+	}
+	if err := rows.Next(values); err != nil || values[1] != "12.34" {
+		t.Errorf("expected 12.34, got %#v, err: %v", values[1], err)
+	}
+}
+
+func TestArrowDescribedTypes(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "ip", Type: arrow.PrimitiveTypes.Uint32},
+		{Name: "n", Type: arrow.PrimitiveTypes.Uint32},
+	}, nil)
+	buf := arrowStream(t, schema, func(b *array.RecordBuilder) {
+		b.Field(0).(*array.Uint32Builder).Append(1)
+		b.Field(1).(*array.Uint32Builder).Append(2)
+	})
+	rows, err := ARROW.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false, withColumnTypes(map[string]string{"ip": "IPv4"}))
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	typed := rows.(driver.RowsColumnTypeDatabaseTypeName)
+	if typed.ColumnTypeDatabaseTypeName(0) != "IPv4" || typed.ColumnTypeDatabaseTypeName(1) != "UInt32" {
+		t.Errorf("expected IPv4 and UInt32, got %s and %s", typed.ColumnTypeDatabaseTypeName(0), typed.ColumnTypeDatabaseTypeName(1))
+	}
+	if meta := rows.(*arrowRows).ColumnMetadata(); meta[0].Type != "IPv4" || meta[0].ScanType != reflect.TypeOf(uint32(0)) {
+		t.Errorf("unexpected metadata %+v", meta[0])
+	}
+}
+
+func TestArrowUUID(t *testing.T) {
+	u := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}, Nullable: true},
+	}, nil)
+	buf := arrowStream(t, schema, func(b *array.RecordBuilder) {
+		fb := b.Field(0).(*array.FixedSizeBinaryBuilder)
+		fb.Append(u[:])
+		fb.AppendNull()
+	})
+	types := withColumnTypes(map[string]string{"id": "Nullable(UUID)"})
+	for _, tc := range []struct {
+		opts     []RowsOption
+		expected driver.Value
+		scanType reflect.Type
+	}{
+		{[]RowsOption{types}, u, reflect.TypeOf((*uuid.UUID)(nil))},
+		{[]RowsOption{types, WithUUIDStrings()}, u.String(), reflect.TypeOf((*string)(nil))},
+	} {
+		rows, err := ARROW.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false, tc.opts...)
+		if err != nil {
+			t.Fatalf("prepare rows fail, err: %s", err)
+		}
+		if got := rows.(driver.RowsColumnTypeScanType).ColumnTypeScanType(0); got != tc.scanType {
+			t.Errorf("expected the %s scan type, got %s", tc.scanType, got)
+		}
+		values := make([]driver.Value, 1)
+		for _, expected := range []driver.Value{tc.expected, nil} {
+			if err := rows.Next(values); err != nil {
+				t.Fatalf("Next fail, err: %s", err)
+			}
+			if values[0] != expected {
+				t.Errorf("expected %#v, got %#v", expected, values[0])
+			}
+		}
+		rows.Close()
+	}
+}
+
+func TestArrowTimestampLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %s", err)
+	}
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "plain", Type: &arrow.TimestampType{Unit: arrow.Millisecond}},
+		{Name: "tokyo", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "Asia/Tokyo"}},
+	}, nil)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	buf := arrowStream(t, schema, func(b *array.RecordBuilder) {
+		b.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(ts.UnixMilli()))
+		b.Field(1).(*array.TimestampBuilder).Append(arrow.Timestamp(ts.UnixMilli()))
+	})
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	rows, err := ARROW.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false, WithLocation(loc))
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	values := make([]driver.Value, 2)
+	if err := rows.Next(values); err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	for i, expected := range []*time.Location{loc, tokyo} {
+		got := values[i].(time.Time)
+		if !got.Equal(ts) || got.Location().String() != expected.String() {
+			t.Errorf("column %d: expected %v in %s, got %v", i, ts, expected, got)
+		}
+	}
+}
+
+func TestArrowMapRows(t *testing.T) {
+	mapType := arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Uint8)
+	mapType.SetItemNullable(true)
+	schema := arrow.NewSchema([]arrow.Field{{Name: "m", Type: mapType}}, nil)
+	buf := arrowStream(t, schema, func(b *array.RecordBuilder) {
+		mb := b.Field(0).(*array.MapBuilder)
+		mb.Append(true)
+		mb.KeyBuilder().(*array.StringBuilder).AppendValues([]string{"a", "b"}, nil)
+		mb.ItemBuilder().(*array.Uint8Builder).AppendValues([]uint8{1, 0}, []bool{true, false})
+		mb.Append(true)
+	})
+
+	rows, err := ARROW.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	if got := rows.(driver.RowsColumnTypeScanType).ColumnTypeScanType(0); got != reflect.TypeOf(map[string]*uint8{}) {
+		t.Errorf("expected map[string]*uint8, got %v", got)
+	}
+	one := uint8(1)
+	for i, expected := range []map[string]*uint8{{"a": &one, "b": nil}, {}} {
+		values := make([]driver.Value, 1)
+		if err := rows.Next(values); err != nil {
+			t.Fatalf("row %d: Next fail, err: %s", i, err)
+		}
+		if !reflect.DeepEqual(values[0], expected) {
+			t.Errorf("row %d: expected %v, got %#v", i, expected, values[0])
+		}
+	}
+}
+
+func TestArrowStreamingRows(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Uint64},
+		{Name: "name", Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int8, ValueType: arrow.BinaryTypes.String}},
+	}, nil)
+	chunk := func(ids []uint64, names []string) *chunkResult {
+		return &chunkResult{buf: arrowStream(t, schema, func(b *array.RecordBuilder) {
+			b.Field(0).(*array.Uint64Builder).AppendValues(ids, nil)
+			for _, name := range names {
+				if err := b.Field(1).(*array.BinaryDictionaryBuilder).AppendString(name); err != nil {
+					t.Fatalf("append dictionary value fail, err: %s", err)
+				}
+			}
+		})}
+	}
+	stream := &chunkStream{chunks: []*chunkResult{chunk([]uint64{1, 2}, []string{"a", "a"}), chunk([]uint64{3}, []string{"b"})}}
+
+	rows, err := ARROW.PrepareStreamingRows(stream, defaultBufferSize, false, WithProjection([]string{"name", "id"}), WithWidenUnsigned())
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	if got := rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(0); got != "LowCardinality(String)" {
+		t.Errorf("expected LowCardinality(String), got %s", got)
+	}
+	var got [][]driver.Value
+	for {
+		values := make([]driver.Value, 2)
+		if err := rows.Next(values); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Next fail, err: %s", err)
+		}
+		got = append(got, values)
+	}
+	expected := [][]driver.Value{{"a", int64(1)}, {"a", int64(2)}, {"b", int64(3)}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestDbWithArrow(t *testing.T) {
+	db, err := sql.Open("chdb", "driverType=ARROW")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT number, toDecimal64(number, 3) AS d, [number] AS arr FROM numbers(3)")
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	defer rows.Close()
+	var count int
+	for rows.Next() {
+		var (
+			n   uint64
+			d   *big.Rat
+			arr []uint64
+		)
+		if err := rows.Scan(&n, &d, &arr); err != nil {
+			t.Fatalf("scan fail, err: %s", err)
+		}
+		if n != uint64(count) || d.Cmp(big.NewRat(int64(count), 1)) != 0 || !reflect.DeepEqual(arr, []uint64{n}) {
+			t.Errorf("row %d: unexpected values %d, %s, %v", count, n, d, arr)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows fail, err: %s", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 rows, got %d", count)
+	}
+}
@@ -0,0 +1,77 @@
+package chdbdriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// queryStreaming runs the query through the streaming API, which is interrupted when ctx is done.
+// The rows then report the context error.
+func (c *conn) queryStreaming(ctx context.Context, query string) (driver.Rows, error) {
+	// describe the query before streaming it, the stream may hold the connection until it is read
+	opts := c.rowsOptions(query)
+	result, err := c.streamFun(query, c.driverType.GetFormat(), c.udfPath)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, result.Cancel)
+	streamType := c.driverType
+	if streamType == PARQUET {
+		streamType = PARQUET_STREAMING
+	}
+	rows, err := streamType.PrepareStreamingRows(result, c.bufferSize, c.useUnsafe, opts...)
+	if err != nil {
+		stop()
+		result.Free()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	if streaming, ok := rows.(streamBaser); ok {
+		base := streaming.streamBase()
+		base.ctx, base.stopCancel = ctx, stop
+	}
+	return rows, nil
+}
+
+// execCancellable runs the statement through the streaming API, so that it is interrupted when ctx is done.
+func (c *conn) execCancellable(ctx context.Context, query string) (driver.Result, error) {
+	result, err := c.streamFun(query, c.driverType.String(), c.udfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Free()
+	stop := context.AfterFunc(ctx, result.Cancel)
+	defer stop()
+
+	res := &execResult{}
+	start := time.Now()
+	for {
+		chunk := result.GetNext()
+		if chunk == nil {
+			break
+		}
+		err, rowsRead, bytesRead := chunk.Error(), chunk.RowsRead(), chunk.BytesRead()
+		empty := rowsRead == 0 && chunk.Len() == 0
+		chunk.Free()
+		if err != nil {
+			return nil, err
+		}
+		if empty {
+			break
+		}
+		res.stats.RowsWritten += rowsRead
+		res.stats.BytesWritten += bytesRead
+	}
+	res.stats.Elapsed = time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := result.Error(); err != nil {
+		return nil, fmt.Errorf("error in stream: %w", err)
+	}
+	return res, nil
+}
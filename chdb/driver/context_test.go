@@ -0,0 +1,98 @@
+package chdbdriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// blockingStream is a fake stream whose first GetNext blocks until the stream is cancelled.
+type blockingStream struct {
+	once      sync.Once
+	cancelled chan struct{}
+}
+
+func newBlockingStream() *blockingStream {
+	return &blockingStream{cancelled: make(chan struct{})}
+}
+
+func (s *blockingStream) GetNext() chdbpurego.ChdbResult {
+	<-s.cancelled
+	return nil
+}
+func (s *blockingStream) Error() error { return nil }
+func (s *blockingStream) Cancel()      { s.once.Do(func() { close(s.cancelled) }) }
+func (s *blockingStream) Free()        { s.Cancel() }
+
+func TestQueryContextCancelInterruptsStream(t *testing.T) {
+	for _, driverType := range []DriverType{PARQUET, PARQUET_STREAMING} {
+		stream := newBlockingStream()
+		cn := &conn{driverType: driverType, bufferSize: defaultBufferSize, isStreaming: driverType.SupportStreaming()}
+		cn.streamFun = func(string, ...string) (chdbpurego.ChdbStreamResult, error) { return stream, nil }
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := cn.QueryContext(ctx, "SELECT 1", nil)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected context.DeadlineExceeded, got %v", driverType, err)
+		}
+
+		if _, err := cn.ExecContext(ctx, "SELECT 1", nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected an expired context to be rejected, got %v", driverType, err)
+		}
+	}
+}
+
+func TestExecContextCancelInterruptsStream(t *testing.T) {
+	stream := newBlockingStream()
+	cn := &conn{driverType: PARQUET, bufferSize: defaultBufferSize}
+	cn.streamFun = func(string, ...string) (chdbpurego.ChdbStreamResult, error) { return stream, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := cn.ExecContext(ctx, "INSERT INTO t SELECT 1", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDbQueryContextCancel(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING", "NATIVE"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		start := time.Now()
+		rows, err := db.QueryContext(ctx, "SELECT count() FROM numbers(100000000000)")
+		if err == nil {
+			for rows.Next() {
+			}
+			err = rows.Err()
+			rows.Close()
+		}
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected context.DeadlineExceeded, got %v", driverType, err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("%s: expected the query to be interrupted, took %s", driverType, elapsed)
+		}
+
+		// the connection is still usable afterwards
+		var n uint64
+		if err := db.QueryRowContext(context.Background(), "SELECT 42").Scan(&n); err != nil || n != 42 {
+			t.Errorf("%s: expected 42 after a cancelled query, got %d, err: %v", driverType, n, err)
+		}
+		db.Close()
+	}
+}
+
+var _ driver.QueryerContext = (*conn)(nil)
+var _ driver.ExecerContext = (*conn)(nil)
@@ -0,0 +1,60 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// WithDateTime64Strings makes DateTime64(P) columns scan as strings formatted like ClickHouse does,
+// e.g. "2024-01-02 03:04:05.12" for DateTime64(2), with exactly P fractional digits, and report string as their
+// scan type. Times are formatted in UTC, which is how they are decoded. Parquet results only record whether their
+// timestamps hold milliseconds, microseconds or nanoseconds, so with the Parquet driver types the query is described
+// first, like with WithDescribedTypes, to learn P.
+func WithDateTime64Strings() RowsOption {
+	return func(c *rowsConfig) {
+		c.timeStrings = true
+	}
+}
+
+// timestampPrecision returns the precision P of a DateTime64(P) column of Parquet rows, read from its described type
+// when known, and 0 for other columns.
+func (c *rowsConfig) timestampPrecision(f parquet.Field) int {
+	return pqconv.TimestampPrecision(f, c.columnTypes[f.Name()])
+}
+
+// precisionScale reports the precision P of DateTime64(P) columns, with a scale of 0,
+// and the precision and the scale of Decimal(P, S) columns.
+func (c *rowsConfig) precisionScale(f parquet.Field) (precision, scale int64, ok bool) {
+	if p := c.timestampPrecision(f); p > 0 {
+		return int64(p), 0, true
+	}
+	if p, s, ok := pqconv.DecimalPrecisionScale(f); ok {
+		return int64(p), int64(s), true
+	}
+	return 0, 0, false
+}
+
+// formatTimes formats the values of the DateTime64 columns of a decoded row, when enabled.
+func (c *rowsConfig) formatTimes(fields []parquet.Field, dest []driver.Value) {
+	if !c.timeStrings {
+		return
+	}
+	for i, f := range fields {
+		t, ok := dest[i].(time.Time)
+		if !ok {
+			continue
+		}
+		if p := c.timestampPrecision(f); p > 0 {
+			dest[i] = formatDateTime64(t, p)
+		}
+	}
+}
+
+// formatDateTime64 formats t with exactly precision fractional digits.
+func formatDateTime64(t time.Time, precision int) string {
+	return t.Format("2006-01-02 15:04:05." + strings.Repeat("0", precision))
+}
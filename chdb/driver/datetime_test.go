@@ -0,0 +1,145 @@
+package chdbdriver
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDbDateTime64Precision(t *testing.T) {
+	query := `SELECT toDateTime64('2024-01-02 03:04:05.120000000', 3, 'UTC') AS ms,
+		toDateTime64('2024-01-02 03:04:05.123400000', 6, 'UTC') AS us,
+		toDateTime64('2024-01-02 03:04:05.123456789', 9, 'UTC') AS ns,
+		toDateTime64('2024-01-02 03:04:05.120000000', 2, 'UTC') AS cs,
+		toDateTime64('2024-01-02 03:04:05.123400000', 4, 'UTC') AS tenth_ms`
+	expected := []struct {
+		precision int64
+		str       string
+	}{
+		{3, "2024-01-02 03:04:05.120"},
+		{6, "2024-01-02 03:04:05.123400"},
+		{9, "2024-01-02 03:04:05.123456789"},
+		{2, "2024-01-02 03:04:05.12"},
+		{4, "2024-01-02 03:04:05.1234"},
+	}
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING", "NATIVE"} {
+		connector, err := NewConnector(fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType), WithDateTime64Strings())
+		if err != nil {
+			t.Fatalf("create connector fail, err: %s", err)
+		}
+		db := sql.OpenDB(connector)
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("run Query fail, err: %s", err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("get column types fail, err: %s", err)
+		}
+		got := make([]string, len(expected))
+		if !rows.Next() {
+			t.Fatalf("%s: expected a row", driverType)
+		}
+		if err := rows.Scan(&got[0], &got[1], &got[2], &got[3], &got[4]); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		rows.Close()
+		for i, exp := range expected {
+			precision, _, ok := types[i].DecimalSize()
+			if !ok || precision != exp.precision {
+				t.Errorf("%s: column %s expected precision %d, got %d", driverType, types[i].Name(), exp.precision, precision)
+			}
+			if got[i] != exp.str {
+				t.Errorf("%s: column %s expected %s, got %s", driverType, types[i].Name(), exp.str, got[i])
+			}
+			if types[i].ScanType() != reflect.TypeOf("") {
+				t.Errorf("%s: column %s expected a string scan type, got %v", driverType, types[i].Name(), types[i].ScanType())
+			}
+		}
+		db.Close()
+	}
+}
+
+func TestDbDates(t *testing.T) {
+	query := "SELECT toDate('2024-01-02') AS day, toDate32('1900-01-01') AS old, CAST(NULL AS Nullable(Date32)) AS missing"
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING", "NATIVE", "ARROW"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var (
+			day, old time.Time
+			missing  *time.Time
+		)
+		err = db.QueryRow(query).Scan(&day, &old, &missing)
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if !day.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: unexpected Date %v", driverType, day)
+		}
+		if !old.Equal(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: unexpected Date32 %v", driverType, old)
+		}
+		if missing != nil {
+			t.Errorf("%s: expected NULL, got %v", driverType, *missing)
+		}
+	}
+}
+
+func TestDbTimeZones(t *testing.T) {
+	query := "SELECT toDateTime64('2024-01-02 03:04:05.006', 3, 'UTC')::DateTime64(3) AS plain, " +
+		"toDateTime64('2024-01-02 03:04:05.006', 3, 'UTC')::DateTime64(3, 'Asia/Tokyo') AS tokyo"
+	instant := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	// tz sets the session_timezone setting, which makes the connector open its own session
+	withoutSharedSession(t)
+	for _, driverType := range []string{"PARQUET", "NATIVE", "ARROW"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("driverType=%s;tz=America/New_York", driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var plain, tokyo time.Time
+		var sessionTZ string
+		err = db.QueryRow(query).Scan(&plain, &tokyo)
+		if err == nil {
+			err = db.QueryRow("SELECT timezone()").Scan(&sessionTZ)
+		}
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if !plain.Equal(instant) || !tokyo.Equal(instant) {
+			t.Errorf("%s: expected %v, got %v and %v", driverType, instant, plain, tokyo)
+		}
+		if sessionTZ != "America/New_York" {
+			t.Errorf("%s: expected the queries to run in the tz time zone, got %s", driverType, sessionTZ)
+		}
+		if driverType != "ARROW" && plain.Location().String() != "America/New_York" {
+			t.Errorf("%s: expected the tz location, got %s", driverType, plain.Location())
+		}
+		// Parquet results don't carry the time zones of their columns
+		if driverType != "PARQUET" && tokyo.Location().String() != "Asia/Tokyo" {
+			t.Errorf("%s: expected the column time zone, got %s", driverType, tokyo.Location())
+		}
+	}
+
+	if _, err := NewConnect(map[string]string{timeZoneKey: "Not/AZone"}); err == nil {
+		t.Errorf("expected an error for an unknown time zone")
+	}
+}
+
+func TestFormatDateTime64(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 100000000, time.UTC)
+	for precision, expected := range map[int]string{
+		3: "2024-01-02 03:04:05.100",
+		6: "2024-01-02 03:04:05.100000",
+		9: "2024-01-02 03:04:05.100000000",
+	} {
+		if got := formatDateTime64(ts, precision); got != expected {
+			t.Errorf("formatDateTime64(%d) = %s, expected %s", precision, got, expected)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const defaultDedupLimit = 1_000_000
+
+// ErrDedupLimit is returned by Next when the amount of keys tracked for deduplication exceeds the configured limit.
+var ErrDedupLimit = errors.New("deduplication key limit reached")
+
+// WithDedupKey makes streaming rows skip every row whose values for the given columns were already returned.
+// Keys are tracked in memory, up to the limit set with WithDedupLimit. It only applies to the PARQUET_STREAMING
+// driver type, and to the PARQUET queries run with a cancellable context, which are streamed.
+func WithDedupKey(columns []string) RowsOption {
+	return func(c *rowsConfig) {
+		c.dedupColumns = columns
+	}
+}
+
+// WithDedupLimit bounds the amount of keys tracked by WithDedupKey. Once the limit is reached, Next returns
+// ErrDedupLimit, or stops deduplicating and returns the remaining rows as is if passthrough is set.
+func WithDedupLimit(maxKeys int, passthrough bool) RowsOption {
+	return func(c *rowsConfig) {
+		c.dedupLimit = maxKeys
+		c.dedupPassthrough = passthrough
+	}
+}
+
+// deduper tracks the keys of the rows already returned.
+type deduper struct {
+	indexes     []int
+	seen        map[string]struct{}
+	limit       int
+	passthrough bool
+	disabled    bool
+}
+
+func newDeduper(cfg *rowsConfig, columns []string) (*deduper, error) {
+	d := &deduper{
+		seen:        make(map[string]struct{}),
+		limit:       cfg.dedupLimit,
+		passthrough: cfg.dedupPassthrough,
+	}
+	if d.limit <= 0 {
+		d.limit = defaultDedupLimit
+	}
+	for _, name := range cfg.dedupColumns {
+		idx := -1
+		for i, c := range columns {
+			if c == name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("dedup column %s not found", name)
+		}
+		d.indexes = append(d.indexes, idx)
+	}
+	return d, nil
+}
+
+// duplicate reports whether the key of the row was already seen, and records it otherwise.
+func (d *deduper) duplicate(dest []driver.Value) (bool, error) {
+	if d.disabled {
+		return false, nil
+	}
+	var key strings.Builder
+	for _, idx := range d.indexes {
+		fmt.Fprintf(&key, "%#v\x00", dest[idx])
+	}
+	if _, ok := d.seen[key.String()]; ok {
+		return true, nil
+	}
+	if len(d.seen) >= d.limit {
+		if !d.passthrough {
+			return false, ErrDedupLimit
+		}
+		// stop tracking keys, the remaining rows are returned as is
+		d.disabled = true
+		d.seen = nil
+		return false, nil
+	}
+	d.seen[key.String()] = struct{}{}
+	return false, nil
+}
@@ -0,0 +1,78 @@
+package chdbdriver
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// dedupQuery returns 1000 distinct keys repeated in every block, so that duplicates span chunk boundaries.
+const dedupQuery = "SELECT number % 1000 AS k, 'x' AS v FROM numbers(20000) SETTINGS max_block_size = 1000"
+
+func queryDedupKeys(t *testing.T, opts ...RowsOption) ([]int, error) {
+	t.Helper()
+	connector, err := NewConnector(fmt.Sprintf("session=%s;driverType=%s;bufferSize=100", session.ConnStr(), "PARQUET_STREAMING"), opts...)
+	if err != nil {
+		t.Fatalf("create connector fail, err: %s", err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	rows, err := db.Query(dedupQuery)
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	defer rows.Close()
+	var keys []int
+	for rows.Next() {
+		var (
+			k int
+			v string
+		)
+		if err := rows.Scan(&k, &v); err != nil {
+			t.Fatalf("scan fail, err: %s", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func TestStreamingDedupKey(t *testing.T) {
+	keys, err := queryDedupKeys(t, WithDedupKey([]string{"k"}))
+	if err != nil {
+		t.Fatalf("iteration fail, err: %s", err)
+	}
+	if len(keys) != 1000 {
+		t.Errorf("expected 1000 distinct rows, got %d", len(keys))
+	}
+	seen := map[int]bool{}
+	for _, k := range keys {
+		if seen[k] {
+			t.Fatalf("key %d returned twice", k)
+		}
+		seen[k] = true
+	}
+}
+
+func TestStreamingDedupLimit(t *testing.T) {
+	_, err := queryDedupKeys(t, WithDedupKey([]string{"k"}), WithDedupLimit(10, false))
+	if !errors.Is(err, ErrDedupLimit) {
+		t.Errorf("expected ErrDedupLimit, got %v", err)
+	}
+
+	keys, err := queryDedupKeys(t, WithDedupKey([]string{"k"}), WithDedupLimit(10, true))
+	if err != nil {
+		t.Fatalf("iteration fail, err: %s", err)
+	}
+	// the limit is reached within the first block, every row after it is returned as is
+	if len(keys) != 20000 {
+		t.Errorf("expected 20000 rows in passthrough mode, got %d", len(keys))
+	}
+}
+
+func TestStreamingDedupUnknownColumn(t *testing.T) {
+	_, err := queryDedupKeys(t, WithDedupKey([]string{"missing"}))
+	if err == nil {
+		t.Errorf("expected an error for an unknown dedup column")
+	}
+}
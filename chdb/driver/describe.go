@@ -0,0 +1,280 @@
+package chdbdriver
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// WithDescribedTypes makes the Parquet and Arrow driver types run DESCRIBE on every query before running it, to
+// learn the ClickHouse types of its columns, which their results don't record. ColumnTypeDatabaseTypeName and
+// ColumnMetadata then report the described types, e.g. Int16 rather than the Parquet INT(16,false), or
+// LowCardinality(String) for columns which still scan as strings. With the Parquet driver types, IPv4 and IPv6
+// columns, held as UInt32 values and 16 byte arrays, are also decoded as netip.Addr values, and JSON columns held
+// as strings are decoded as selected by WithJSONAs. It costs an extra query, and queries which can't be described
+// run without it.
+func WithDescribedTypes() RowsOption {
+	return func(c *rowsConfig) {
+		c.describeTypes = true
+	}
+}
+
+// WithIPStrings makes IPv4 and IPv6 columns scan as the text form of their addresses instead of a netip.Addr.
+// It applies to the Native driver type, and to the Parquet driver types with WithDescribedTypes.
+func WithIPStrings() RowsOption {
+	return func(c *rowsConfig) {
+		c.ipStrings = true
+	}
+}
+
+// withColumnTypes sets the ClickHouse types of the columns of the result, by name.
+func withColumnTypes(types map[string]string) RowsOption {
+	return func(c *rowsConfig) {
+		c.columnTypes = types
+	}
+}
+
+// withDescriber sets how to describe the query of Parquet rows whose result may hold big integers, see
+// describeBigInts.
+func withDescriber(describe func() (map[string]string, error)) RowsOption {
+	return func(c *rowsConfig) {
+		c.describer = describe
+	}
+}
+
+// rowsOptions returns the options of the rows of the query, with the types of its columns when they are described.
+// With WithDateTime64Strings alone, only the types of the DateTime64 and big integer columns are kept. Otherwise,
+// the rows of the Parquet driver types describe the query themselves when their result may hold big integers.
+func (c *conn) rowsOptions(query string) []RowsOption {
+	cfg := newRowsConfig(c.rowsOpts)
+	if !c.driverType.describable() {
+		return c.rowsOpts
+	}
+	opts := c.rowsOpts[:len(c.rowsOpts):len(c.rowsOpts)]
+	if !cfg.describeTypes && !cfg.timeStrings {
+		if c.driverType == ARROW || cfg.bigIntBytes {
+			return c.rowsOpts
+		}
+		return append(opts, withDescriber(func() (map[string]string, error) {
+			return c.describe(query)
+		}))
+	}
+	types, err := c.describe(query)
+	if err != nil {
+		// the query fails on its own if it is invalid, and may just not be describable, e.g. SHOW TABLES
+		return c.rowsOpts
+	}
+	if !cfg.describeTypes {
+		for name, typ := range types {
+			if _, ok := pqconv.DateTime64Precision(typ); !ok && !isBigIntType(typ) {
+				delete(types, name)
+			}
+		}
+	}
+	return append(opts, withColumnTypes(types))
+}
+
+// describeBigInts learns the types of the big integer columns of Parquet rows, which Parquet holds as fixed length
+// byte arrays without recording their type, like the values of FixedString or IPv6 columns. The query is only
+// described when the fields hold such arrays of 16 or 32 bytes whose type isn't known yet, and at most once.
+func (c *rowsConfig) describeBigInts(fields []parquet.Field) {
+	if c.describer == nil {
+		return
+	}
+	for _, f := range fields {
+		if _, ok := c.columnTypes[f.Name()]; ok || !mayBeBigInt(f) {
+			continue
+		}
+		describe := c.describer
+		c.describer = nil
+		types, err := describe()
+		if err != nil {
+			return
+		}
+		columnTypes := make(map[string]string, len(c.columnTypes)+len(types))
+		for name, typ := range types {
+			if isBigIntType(typ) {
+				columnTypes[name] = typ
+			}
+		}
+		for name, typ := range c.columnTypes {
+			columnTypes[name] = typ
+		}
+		c.columnTypes = columnTypes
+		return
+	}
+}
+
+// mayBeBigInt reports whether a field holds arrays of 16 or 32 bytes without a logical type, as the values of
+// Int128, UInt128, Int256 and UInt256 columns are.
+func mayBeBigInt(f parquet.Field) bool {
+	t := f.Type()
+	return f.Leaf() && t.Kind() == parquet.FixedLenByteArray && (t.Length() == 16 || t.Length() == 32) && t.LogicalType() == nil
+}
+
+// isBigIntType reports whether typ is Int128, UInt128, Int256 or UInt256, possibly Nullable.
+func isBigIntType(typ string) bool {
+	switch baseType(typ) {
+	case "Int128", "UInt128", "Int256", "UInt256":
+		return true
+	}
+	return false
+}
+
+// describable reports whether WithDescribedTypes applies to the driver type: Native and text results record the
+// ClickHouse types of their columns.
+func (d DriverType) describable() bool {
+	return d == PARQUET || d == PARQUET_STREAMING || d == ARROW
+}
+
+// describe returns the ClickHouse types of the columns of the query, by name.
+func (c *conn) describe(query string) (map[string]string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	result, err := c.QueryFun("DESCRIBE ("+query+")", "TabSeparated", c.udfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Free()
+	types := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(result.String()))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid DESCRIBE row %q", scanner.Text())
+		}
+		types[unescapeTSV(fields[0])] = unescapeTSV(fields[1])
+	}
+	return types, scanner.Err()
+}
+
+// typeConverters returns the converters decoding the columns whose described type isn't recorded by Parquet:
+// IPv4, IPv6, JSON and big integer columns. Converters set with WithColumnConverter take precedence.
+func (c *rowsConfig) typeConverters() map[string]func(parquet.Value) (any, error) {
+	if c.columnTypes == nil {
+		return c.converters
+	}
+	converters := make(map[string]func(parquet.Value) (any, error), len(c.converters))
+	for name, typ := range c.columnTypes {
+		switch baseType(typ) {
+		case "IPv4":
+			converters[name] = ipConverter(false, c.ipStrings)
+		case "IPv6":
+			converters[name] = ipConverter(true, c.ipStrings)
+		case "Int128", "Int256":
+			if !c.bigIntBytes {
+				converters[name] = BigIntConverter(true)
+			}
+		case "UInt128", "UInt256":
+			if !c.bigIntBytes {
+				converters[name] = BigIntConverter(false)
+			}
+		default:
+			if isJSONType(typ) {
+				converters[name] = jsonConverter(c.jsonMode())
+			}
+		}
+	}
+	for name, fn := range c.converters {
+		converters[name] = fn
+	}
+	return converters
+}
+
+// scanType returns the scan type of a column of Parquet rows.
+func (c *rowsConfig) scanType(field parquet.Field) reflect.Type {
+	if t, ok := c.describedScanType(field.Name()); ok {
+		return t
+	}
+	if c.timeStrings && c.timestampPrecision(field) > 0 {
+		if field.Optional() {
+			return reflect.TypeOf((*string)(nil))
+		}
+		return reflect.TypeOf("")
+	}
+	if mode := c.jsonMode(); mode != jsonval.String && pqconv.IsJSON(field) && c.converters[field.Name()] == nil {
+		return jsonval.Type(mode)
+	}
+	decoder := c.decoder(false)
+	return decoder.ScanType(field)
+}
+
+// columnMetadata returns the metadata of the columns of Parquet rows, with their described types.
+func (c *rowsConfig) columnMetadata(fields []parquet.Field) []pqconv.ColumnMeta {
+	columns := pqconv.Columns(fields)
+	pqconv.SetTypes(columns, c.columnTypes)
+	for i, f := range fields {
+		columns[i].ScanType = c.scanType(f)
+	}
+	return columns
+}
+
+// describedScanType returns the scan type of the named column when it is a described IPv4, IPv6, JSON or big integer
+// column.
+func (c *rowsConfig) describedScanType(name string) (reflect.Type, bool) {
+	typ, ok := c.columnTypes[name]
+	if !ok || c.converters[name] != nil {
+		return nil, false
+	}
+	if isJSONType(typ) {
+		return jsonval.Type(c.jsonMode()), true
+	}
+	if base := baseType(typ); base != "IPv4" && base != "IPv6" && !isBigIntType(typ) {
+		return nil, false
+	}
+	return native.ScanType(typ, native.Options{IPStrings: c.ipStrings, BigIntBytes: c.bigIntBytes}), true
+}
+
+// baseType strips the LowCardinality and Nullable wrappers of a ClickHouse type.
+func baseType(typ string) string {
+	typ = native.UnwrapLowCardinality(typ)
+	if strings.HasPrefix(typ, "Nullable(") && strings.HasSuffix(typ, ")") {
+		return typ[len("Nullable(") : len(typ)-1]
+	}
+	return typ
+}
+
+// isJSONType reports whether typ is a JSON type, e.g. JSON(max_dynamic_paths = 8), or the legacy Object('json').
+func isJSONType(typ string) bool {
+	typ = baseType(typ)
+	return typ == "JSON" || strings.HasPrefix(typ, "JSON(") || strings.HasPrefix(typ, "Object(")
+}
+
+// jsonConverter returns the converter decoding the values of a JSON column held as strings.
+func jsonConverter(mode string) func(parquet.Value) (any, error) {
+	return func(v parquet.Value) (any, error) {
+		if v.IsNull() {
+			return nil, nil
+		}
+		return jsonval.Decode(v.ByteArray(), mode)
+	}
+}
+
+// ipConverter returns the converter decoding the values of an IPv4 or an IPv6 column.
+func ipConverter(v6, asStrings bool) func(parquet.Value) (any, error) {
+	return func(v parquet.Value) (any, error) {
+		if v.IsNull() {
+			return nil, nil
+		}
+		var addr netip.Addr
+		if v6 {
+			b := v.ByteArray()
+			if len(b) != 16 {
+				return nil, fmt.Errorf("invalid IPv6 value of %d bytes", len(b))
+			}
+			addr = netip.AddrFrom16([16]byte(b))
+		} else {
+			addr = native.IPv4(v.Uint32())
+		}
+		if asStrings {
+			return addr.String(), nil
+		}
+		return addr, nil
+	}
+}
@@ -0,0 +1,295 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestTypeConverters(t *testing.T) {
+	custom := func(parquet.Value) (any, error) { return "custom", nil }
+	cfg := newRowsConfig([]RowsOption{
+		withColumnTypes(map[string]string{"v4": "IPv4", "v6": "Nullable(IPv6)", "other": "IPv4", "n": "UInt32"}),
+		WithColumnConverter("other", custom),
+	})
+	converters := cfg.typeConverters()
+	if len(converters) != 3 || converters["n"] != nil {
+		t.Fatalf("expected converters for the IP columns only, got %v", converters)
+	}
+	for name, tc := range map[string]struct {
+		value    parquet.Value
+		expected any
+	}{
+		"v4":    {parquet.ValueOf(uint32(0xc0a80001)), netip.MustParseAddr("192.168.0.1")},
+		"v6":    {parquet.ValueOf(netip.MustParseAddr("2001:db8::1").AsSlice()), netip.MustParseAddr("2001:db8::1")},
+		"other": {parquet.ValueOf(uint32(1)), "custom"},
+	} {
+		got, err := converters[name](tc.value)
+		if err != nil {
+			t.Fatalf("%s: convert fail, err: %s", name, err)
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %v, got %#v", name, tc.expected, got)
+		}
+	}
+	if got, _ := converters["v6"](parquet.NullValue()); got != nil {
+		t.Errorf("expected nil for NULL, got %#v", got)
+	}
+	if st, ok := cfg.describedScanType("v6"); !ok || st != reflect.TypeOf((*netip.Addr)(nil)) {
+		t.Errorf("expected *netip.Addr scan type, got %v", st)
+	}
+	if got := cfg.databaseTypeName("v4", "INT(32,false)"); got != "IPv4" {
+		t.Errorf("expected the described type, got %s", got)
+	}
+}
+
+func TestJSONConverters(t *testing.T) {
+	value := parquet.ValueOf(`{"a":1}`)
+	for mode, expected := range map[JSONMode]any{
+		JSONAsMap:    map[string]any{"a": int64(1)},
+		JSONAsRaw:    json.RawMessage(`{"a":1}`),
+		JSONAsString: `{"a":1}`,
+	} {
+		cfg := newRowsConfig([]RowsOption{
+			withColumnTypes(map[string]string{"j": "JSON", "o": "Nullable(Object('json'))", "s": "String"}),
+			WithJSONAs(mode),
+		})
+		converters := cfg.typeConverters()
+		if len(converters) != 2 || converters["s"] != nil {
+			t.Fatalf("%s: expected converters for the JSON columns only, got %v", mode, converters)
+		}
+		got, err := converters["j"](value)
+		if err != nil {
+			t.Fatalf("%s: convert fail, err: %s", mode, err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %#v, got %#v", mode, expected, got)
+		}
+		if st, ok := cfg.describedScanType("o"); !ok || st != reflect.TypeOf(expected) {
+			t.Errorf("%s: expected %T scan type, got %v", mode, expected, st)
+		}
+	}
+	if _, err := NewConnect(map[string]string{jsonAsKey: "yaml"}); err == nil {
+		t.Errorf("expected an error for an invalid %s", jsonAsKey)
+	}
+}
+
+func TestDbWithJSON(t *testing.T) {
+	const query = `SELECT '{"a":1,"b":"x"}'::JSON AS j`
+	db, err := sql.Open("chdb", "driverType=PARQUET;describeTypes=true")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+	var m map[string]any
+	if err := db.QueryRow(query).Scan(&m); err != nil {
+		t.Fatalf("scan fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(m, map[string]any{"a": int64(1), "b": "x"}) {
+		t.Errorf("unexpected map %v", m)
+	}
+
+	raw, err := sql.Open("chdb", "driverType=PARQUET;describeTypes=true;jsonAs=raw")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer raw.Close()
+	var msg json.RawMessage
+	if err := raw.QueryRow(query).Scan(&msg); err != nil {
+		t.Fatalf("scan fail, err: %s", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(msg, &decoded); err != nil || decoded["b"] != "x" {
+		t.Errorf("unexpected raw message %s, err: %v", msg, err)
+	}
+}
+
+func TestDbWithIPs(t *testing.T) {
+	const query = "SELECT toIPv4('192.168.0.1') AS v4, toIPv6('2001:db8::1') AS v6, toUInt32(7) AS n"
+	for _, dsn := range []string{"driverType=NATIVE", "driverType=PARQUET;describeTypes=true"} {
+		db, err := sql.Open("chdb", dsn)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s: query fail, err: %s", dsn, err)
+		}
+		types, _ := rows.ColumnTypes()
+		if types[0].DatabaseTypeName() != "IPv4" || types[1].ScanType() != reflect.TypeOf(netip.Addr{}) {
+			t.Errorf("%s: unexpected column types %s, %s", dsn, types[0].DatabaseTypeName(), types[1].ScanType())
+		}
+		var (
+			v4, v6 netip.Addr
+			n      uint32
+		)
+		if !rows.Next() {
+			t.Fatalf("%s: expected a row, err: %v", dsn, rows.Err())
+		}
+		if err := rows.Scan(&v4, &v6, &n); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", dsn, err)
+		}
+		rows.Close()
+		db.Close()
+		if v4 != netip.MustParseAddr("192.168.0.1") || v6 != netip.MustParseAddr("2001:db8::1") || n != 7 {
+			t.Errorf("%s: unexpected values %v, %v, %d", dsn, v4, v6, n)
+		}
+	}
+}
+
+func TestDescribedColumnMetadata(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{"lc": parquet.String(), "v4": parquet.Uint(32)})
+	cfg := newRowsConfig([]RowsOption{withColumnTypes(map[string]string{"lc": "LowCardinality(String)", "v4": "IPv4"})})
+	cols := cfg.columnMetadata(schema.Fields())
+	if cols[0].Type != "LowCardinality(String)" || cols[0].ScanType != reflect.TypeOf("") {
+		t.Errorf("unexpected LowCardinality metadata %+v", cols[0])
+	}
+	if cols[1].Type != "IPv4" || cols[1].ScanType != reflect.TypeOf(netip.Addr{}) {
+		t.Errorf("unexpected IPv4 metadata %+v", cols[1])
+	}
+	if got := cfg.databaseTypeName("lc", "STRING"); got != "LowCardinality(String)" {
+		t.Errorf("expected the described type, got %s", got)
+	}
+	cfg.unwrapLowCardinality = true
+	if got := cfg.databaseTypeName("lc", "STRING"); got != "String" {
+		t.Errorf("expected the unwrapped type, got %s", got)
+	}
+}
+
+func TestDescribedLowCardinality(t *testing.T) {
+	type row struct {
+		LC  string  `parquet:"lc"`
+		NLC *string `parquet:"nlc,optional"`
+	}
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []row{{LC: "a"}}); err != nil {
+		t.Fatalf("write parquet fail, err: %s", err)
+	}
+	types := withColumnTypes(map[string]string{"lc": "LowCardinality(String)", "nlc": "LowCardinality(Nullable(String))"})
+	for _, tc := range []struct {
+		opts     []RowsOption
+		expected []string
+	}{
+		{[]RowsOption{types}, []string{"LowCardinality(String)", "LowCardinality(Nullable(String))"}},
+		{[]RowsOption{WithUnwrapLowCardinality(), types}, []string{"String", "Nullable(String)"}},
+	} {
+		rows, err := PARQUET.PrepareRows(&chunkResult{buf: buf.Bytes()}, buf.Bytes(), defaultBufferSize, false, tc.opts...)
+		if err != nil {
+			t.Fatalf("prepare rows fail, err: %s", err)
+		}
+		for i, expected := range tc.expected {
+			if got := rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(i); got != expected {
+				t.Errorf("column %d: expected type %s, got %s", i, expected, got)
+			}
+		}
+		rows.Close()
+	}
+}
+
+func TestDbWithDescribedTypes(t *testing.T) {
+	const query = "SELECT toInt16(1) AS i, toLowCardinality('a') AS lc, toDate('2024-01-02') AS d"
+	for _, dsn := range []string{"driverType=PARQUET;describeTypes=true", "driverType=ARROW;describeTypes=true"} {
+		db, err := sql.Open("chdb", dsn)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s: query fail, err: %s", dsn, err)
+		}
+		types, _ := rows.ColumnTypes()
+		for i, expected := range []string{"Int16", "LowCardinality(String)", "Date"} {
+			if got := types[i].DatabaseTypeName(); got != expected {
+				t.Errorf("%s: column %s: expected type %s, got %s", dsn, types[i].Name(), expected, got)
+			}
+		}
+		rows.Close()
+		db.Close()
+	}
+
+	for _, dsn := range []string{"driverType=PARQUET;describeTypes=true;unwrapLowCardinality=true", "driverType=ARROW;describeTypes=true;unwrapLowCardinality=true"} {
+		db, err := sql.Open("chdb", dsn)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query("SELECT toLowCardinality('a') AS lc, toLowCardinality(toNullable('b')) AS nlc")
+		if err != nil {
+			t.Fatalf("%s: query fail, err: %s", dsn, err)
+		}
+		types, _ := rows.ColumnTypes()
+		for i, expected := range []string{"String", "Nullable(String)"} {
+			if got := types[i].DatabaseTypeName(); got != expected {
+				t.Errorf("%s: column %s: expected the unwrapped type %s, got %s", dsn, types[i].Name(), expected, got)
+			}
+		}
+		rows.Close()
+		db.Close()
+	}
+}
+
+func TestDescribeBigInts(t *testing.T) {
+	type row struct {
+		I    [16]byte `parquet:"i"`
+		Hash [32]byte `parquet:"hash"`
+		Name string   `parquet:"name"`
+	}
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []row{{I: [16]byte{42}, Hash: [32]byte{0: 1, 31: 0x80}}}); err != nil {
+		t.Fatalf("write parquet fail, err: %s", err)
+	}
+	described := 0
+	describer := withDescriber(func() (map[string]string, error) {
+		described++
+		return map[string]string{"i": "Int128", "hash": "Nullable(UInt256)", "name": "String"}, nil
+	})
+	rows, err := PARQUET.PrepareRows(&chunkResult{buf: buf.Bytes()}, buf.Bytes(), defaultBufferSize, false, describer)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	pr := rows.(*parquetRows)
+	if described != 1 || !reflect.DeepEqual(pr.columnTypes, map[string]string{"i": "Int128", "hash": "Nullable(UInt256)"}) {
+		t.Errorf("expected the big integer types to be described once, got %v after %d calls", pr.columnTypes, described)
+	}
+	pr.describeBigInts(pr.schemaFields)
+	if described != 1 {
+		t.Errorf("expected the query to be described once, got %d calls", described)
+	}
+	values := make([]driver.Value, 3)
+	if err := rows.Next(values); err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	hash := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(0x80), 248), big.NewInt(1))
+	if i, ok := values[0].(*big.Int); !ok || i.Int64() != 42 {
+		t.Errorf("expected 42, got %#v", values[0])
+	}
+	if h, ok := values[1].(*big.Int); !ok || h.Cmp(hash) != 0 {
+		t.Errorf("expected %s, got %#v", hash, values[1])
+	}
+	if st := pr.ColumnTypeScanType(0); st != reflect.TypeOf((*big.Int)(nil)) {
+		t.Errorf("expected *big.Int scan type, got %v", st)
+	}
+
+	// without big integer candidates, the query isn't described
+	var plain bytes.Buffer
+	if err := parquet.Write(&plain, []struct {
+		Name string `parquet:"name"`
+	}{{"a"}}); err != nil {
+		t.Fatalf("write parquet fail, err: %s", err)
+	}
+	rows, err = PARQUET.PrepareRows(&chunkResult{buf: plain.Bytes()}, plain.Bytes(), defaultBufferSize, false, describer)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	rows.Close()
+	if described != 1 {
+		t.Errorf("expected no description without big integer candidates, got %d calls", described)
+	}
+}
@@ -1,27 +1,56 @@
 package chdbdriver
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
-	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/chdb-io/chdb-go/chdb"
 	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
 	"github.com/huandu/go-sqlbuilder"
-	"github.com/parquet-go/parquet-go"
 )
 
 type DriverType int
 
+// ErrTruncatedChunk is returned when a result chunk is not a complete Parquet file,
+// e.g. because the native layer returned a partial buffer.
+var ErrTruncatedChunk = pqconv.ErrTruncatedChunk
+
+// checkChunk validates a Parquet chunk before it is read. Empty chunks carry no rows and are left to the rows implementations.
+func checkChunk(buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	return pqconv.CheckChunk(buf)
+}
+
 const (
+	// ARROW requests ArrowStream results, which are faster to decode than Parquet and keep the precision
+	// of Decimal columns, decoded as strings. Only projection, unsigned widening, LowCardinality
+	// unwrapping and described types apply to its rows.
 	ARROW DriverType = iota
 	PARQUET
 	PARQUET_STREAMING
+	// NATIVE requests Native results, whose column blocks are decoded directly. It is the default driver type.
+	// Column converters, deduplication, prefetching and NextPage only apply to the Parquet driver types.
+	NATIVE
+	// CSV requests CSVWithNamesAndTypes results and TSV requests TSVWithNamesAndTypes results, which are parsed
+	// as they are read. Their values are returned as text, and only projection and LowCardinality unwrapping
+	// apply to their rows.
+	CSV
+	TSV
+	// JSON requests JSONCompactEachRowWithNamesAndTypes results, which are decoded as they are read. It suits
+	// results with nested or JSON columns. Values are returned as decoded by encoding/json, with integer numbers
+	// as int64, and only projection and LowCardinality unwrapping apply to its rows.
+	JSON
 	INVALID
 )
 
@@ -31,7 +60,24 @@ const (
 	driverTypeKey            = "driverType"
 	useUnsafeStringReaderKey = "useUnsafeStringReader"
 	driverBufferSizeKey      = "bufferSize"
+	unwrapLowCardinalityKey  = "unwrapLowCardinality"
+	widenUnsignedKey         = "widenUnsigned"
+	prefetchDepthKey         = "prefetchDepth"
+	uuidStringsKey           = "uuidStrings"
+	decimalStringsKey        = "decimalStrings"
+	timeZoneKey              = "tz"
+	bigIntBytesKey           = "bigIntBytes"
+	enumAsStringKey          = "enumAsString"
+	ipStringsKey             = "ipStrings"
+	describeTypesKey         = "describeTypes"
+	jsonAsKey                = "jsonAs"
+	defaultFormatKey         = "defaultFormat"
 	defaultBufferSize        = 512
+
+	// settingsPrefix prefixes the keys of ClickHouse settings, e.g. settings.max_threads=4. As settings apply to a
+	// whole session, the connector opens its own session for them, closed with the sql.DB, which fails while another
+	// session is open.
+	settingsPrefix = "settings."
 )
 
 func (d DriverType) String() string {
@@ -40,43 +86,134 @@ func (d DriverType) String() string {
 		return "Arrow"
 	case PARQUET:
 		return "Parquet"
+	case NATIVE:
+		return "Native"
+	case CSV:
+		return "CSV"
+	case TSV:
+		return "TSV"
+	case JSON:
+		return "JSON"
 	case INVALID:
 		return "Invalid"
 	}
 	return ""
 }
 
-func (d DriverType) PrepareRows(result chdbpurego.ChdbResult, buf []byte, bufSize int, useUnsafe bool) (driver.Rows, error) {
+func (d DriverType) PrepareRows(result chdbpurego.ChdbResult, buf []byte, bufSize int, useUnsafe bool, opts ...RowsOption) (driver.Rows, error) {
+	rows, err := d.prepareRows(result, buf, bufSize, useUnsafe, opts)
+	if err != nil {
+		return nil, err
+	}
+	setStats(rows, &queryStats{stats: chdb.ResultStats(result)})
+	return rows, nil
+}
+
+func (d DriverType) prepareRows(result chdbpurego.ChdbResult, buf []byte, bufSize int, useUnsafe bool, opts []RowsOption) (driver.Rows, error) {
 	switch d {
 	case PARQUET:
-		reader := parquet.NewGenericReader[any](bytes.NewReader(buf))
-		return &parquetRows{
-			localResult: result, reader: reader,
-			bufferSize: bufSize, needNewBuffer: true,
+		if err := checkChunk(buf); err != nil {
+			return nil, err
+		}
+		rows := &parquetRows{
+			localResult: result,
+			bufferSize:  bufSize, needNewBuffer: true,
 			useUnsafeStringReader: useUnsafe,
-			schemaFields:          reader.Schema().Fields(),
-		}, nil
+			rowsConfig:            newRowsConfig(opts),
+		}
+		var err error
+		if rows.reader, rows.fileFields, rows.schemaFields, rows.projection, err = rows.openReader(buf); err != nil {
+			return nil, err
+		}
+		rows.describeBigInts(rows.schemaFields)
+		rows.columns = func() []chdb.ColumnMeta {
+			return rows.columnMetadata(rows.schemaFields)
+		}
+		return rows, nil
 
+	case ARROW:
+		return newArrowRows(result, nil, useUnsafe, opts)
+	case NATIVE:
+		return newNativeRows(result, nil, useUnsafe, opts)
+	case CSV, TSV, JSON:
+		return newTextRows(d, result, nil, opts)
 	}
 	return nil, fmt.Errorf("unsupported driver type")
 }
 
-func (d DriverType) PrepareStreamingRows(result chdbpurego.ChdbStreamResult, bufSize int, useUnsafe bool) (driver.Rows, error) {
+func (d DriverType) PrepareStreamingRows(result chdbpurego.ChdbStreamResult, bufSize int, useUnsafe bool, opts ...RowsOption) (driver.Rows, error) {
+	stats := &queryStats{start: time.Now()}
+	rows, err := d.prepareStreamingRows(&statsStream{ChdbStreamResult: result, stats: stats}, bufSize, useUnsafe, opts)
+	if err != nil {
+		return nil, err
+	}
+	setStats(rows, stats)
+	return rows, nil
+}
+
+func (d DriverType) prepareStreamingRows(result chdbpurego.ChdbStreamResult, bufSize int, useUnsafe bool, opts []RowsOption) (driver.Rows, error) {
 	switch d {
 	case PARQUET_STREAMING:
 		nextRes := result.GetNext()
 		if nextRes == nil {
 			return nil, fmt.Errorf("result is nil")
 		}
+		if err := checkChunk(nextRes.Buf()); err != nil {
+			return nil, err
+		}
 
-		reader := parquet.NewGenericReader[any](bytes.NewReader(nextRes.Buf()))
-		return &parquetStreamingRows{
-			stream: result, curChunk: nextRes, reader: reader,
+		rows := &parquetStreamingRows{
+			stream: result, curChunk: nextRes,
 			bufferSize: bufSize, needNewBuffer: true,
 			useUnsafeStringReader: useUnsafe,
-			schemaFields:          reader.Schema().Fields(),
-		}, nil
+			rowsConfig:            newRowsConfig(opts),
+		}
+		prefetch := rows.prefetchDepth > 0 && nextRes.RowsRead() > 0
+		if prefetch {
+			// fetching the next chunks frees the first one, which is still read
+			nextRes = ownChunk(nextRes)
+			rows.curChunk = nextRes
+		}
+		var err error
+		if rows.reader, rows.fileFields, rows.schemaFields, rows.projection, err = rows.openReader(nextRes.Buf()); err != nil {
+			return nil, err
+		}
+		rows.describeBigInts(rows.schemaFields)
+		rows.columns = func() []chdb.ColumnMeta {
+			return rows.columnMetadata(rows.schemaFields)
+		}
+		if prefetch {
+			rows.prefetch = newPrefetcher(result, rows.prefetchDepth)
+		}
+		return rows, nil
 
+	case ARROW:
+		nextRes := result.GetNext()
+		if nextRes == nil {
+			return nil, fmt.Errorf("result is nil")
+		}
+		if err := nextRes.Error(); err != nil {
+			return nil, err
+		}
+		return newArrowRows(nextRes, result, useUnsafe, opts)
+	case NATIVE:
+		nextRes := result.GetNext()
+		if nextRes == nil {
+			return nil, fmt.Errorf("result is nil")
+		}
+		if err := nextRes.Error(); err != nil {
+			return nil, err
+		}
+		return newNativeRows(nextRes, result, useUnsafe, opts)
+	case CSV, TSV, JSON:
+		nextRes := result.GetNext()
+		if nextRes == nil {
+			return nil, fmt.Errorf("result is nil")
+		}
+		if err := nextRes.Error(); err != nil {
+			return nil, err
+		}
+		return newTextRows(d, nextRes, result, opts)
 	}
 	return nil, fmt.Errorf("unsupported driver type")
 }
@@ -91,6 +228,16 @@ func (d DriverType) SupportStreaming() bool {
 
 func (d DriverType) GetFormat() string {
 	switch d {
+	case ARROW:
+		return "ArrowStream"
+	case NATIVE:
+		return "Native"
+	case CSV:
+		return "CSVWithNamesAndTypes"
+	case TSV:
+		return "TSVWithNamesAndTypes"
+	case JSON:
+		return "JSONCompactEachRowWithNamesAndTypes"
 	case PARQUET:
 		return "Parquet"
 	case PARQUET_STREAMING:
@@ -102,8 +249,16 @@ func (d DriverType) GetFormat() string {
 
 func parseDriverType(s string) DriverType {
 	switch strings.ToUpper(s) {
-	// case "ARROW":
-	// 	return ARROW
+	case "ARROW":
+		return ARROW
+	case "NATIVE":
+		return NATIVE
+	case "CSV":
+		return CSV
+	case "TSV":
+		return TSV
+	case "JSON":
+		return JSON
 	case "PARQUET":
 		return PARQUET
 	case "PARQUET_STREAMING":
@@ -153,8 +308,31 @@ func (r *singleRow) Err() error {
 }
 
 type execResult struct {
-	localRes chdbpurego.ChdbResult
-	err      error
+	err   error
+	stats chdb.ExecResult
+}
+
+// StatsResult is implemented by the results of the driver Exec and ExecContext methods, which can be reached
+// through sql.Conn.Raw. It exposes the statistics chDB reports for the statement.
+type StatsResult interface {
+	Stats() chdb.ExecResult
+}
+
+// RowsColumnTypeElementNames is implemented by the rows of every driver type, which can be reached through
+// sql.Conn.Raw. ColumnTypeElementNames returns the names of the elements of a Tuple or Nested column, or of the
+// tuples of an array of them, in the order of the values of the []any they are decoded into. Unnamed elements are
+// named after their position from 1. It returns nil for other columns.
+type RowsColumnTypeElementNames interface {
+	driver.Rows
+	ColumnTypeElementNames(index int) []string
+}
+
+// RowsColumnTypeEnumValues is implemented by the rows of every driver type, which can be reached through
+// sql.Conn.Raw. ColumnTypeEnumValues returns the values of an Enum8 or Enum16 column by name. It returns nil for
+// other columns, and for the Parquet and Arrow driver types, whose results don't record the values of enums.
+type RowsColumnTypeEnumValues interface {
+	driver.Rows
+	ColumnTypeEnumValues(index int) map[string]int16
 }
 
 func (e *execResult) LastInsertId() (int64, error) {
@@ -169,7 +347,12 @@ func (e *execResult) RowsAffected() (int64, error) {
 		return 0, e.err
 	}
 	// chdb return the number of rows inserted/updated/deleted trough rows_read
-	return int64(e.localRes.RowsRead()), nil
+	return int64(e.stats.RowsWritten), nil
+}
+
+// Stats implements StatsResult.
+func (e *execResult) Stats() chdb.ExecResult {
+	return e.stats
 }
 
 type queryHandle func(string, ...string) (chdbpurego.ChdbResult, error)
@@ -183,6 +366,8 @@ type connector struct {
 	isStreaming bool
 	useUnsafe   bool
 	session     *chdb.Session
+	rowsOpts    []RowsOption
+	ownsSession bool // whether session was opened for the connector, and is closed with it
 }
 
 // Connect returns a connection to a database.
@@ -194,11 +379,21 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 		udfPath: c.udfPath, session: c.session,
 		driverType: c.driverType, bufferSize: c.bufferSize,
 		useUnsafe: c.useUnsafe, isStreaming: c.isStreaming,
+		rowsOpts: c.rowsOpts,
 	}
 	cc.SetupQueryFun()
 	return cc, nil
 }
 
+// Close closes the session of the connector when it was opened for the settings of the connection string.
+// sql.DB.Close calls it.
+func (c *connector) Close() error {
+	if c.ownsSession {
+		c.session.Close()
+	}
+	return nil
+}
+
 // Driver returns the underying Driver of the connector,
 // compatibility with the Driver method on sql.DB
 func (c *connector) Driver() driver.Driver { return Driver{} }
@@ -219,11 +414,71 @@ func parseConnectStr(str string) (ret map[string]string, err error) {
 
 	return
 }
+
+// settingOptions returns the session options of the settings.<name>=<value> options, sorted by name.
+func settingOptions(opts map[string]string) ([]chdb.Option, error) {
+	var names []string
+	for key := range opts {
+		if name, ok := strings.CutPrefix(key, settingsPrefix); ok {
+			if err := sqlfmt.ValidateSettingName(name); err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	settings := make([]chdb.Option, len(names))
+	for i, name := range names {
+		settings[i] = chdb.WithSetting(name, opts[settingsPrefix+name])
+	}
+	return settings, nil
+}
+
+// withTimeZoneSetting returns opts with the session_timezone setting of the tz option, so that the queries of the
+// connection also interpret the times without a time zone in it, e.g. toDateTime('2024-01-02 03:04:05'). Like any
+// setting, it makes the connector open its own session. A session_timezone setting given explicitly takes precedence.
+func withTimeZoneSetting(opts map[string]string, tz string) map[string]string {
+	key := settingsPrefix + "session_timezone"
+	if _, ok := opts[key]; ok {
+		return opts
+	}
+	withTZ := make(map[string]string, len(opts)+1)
+	for k, v := range opts {
+		withTZ[k] = v
+	}
+	withTZ[key] = tz
+	return withTZ
+}
+
 func NewConnect(opts map[string]string) (ret *connector, err error) {
 	ret = &connector{}
+	// the default format only applies to the queries run on the session directly, as the driver always provides one.
+	// Like the session path, it is ignored when the global session is already open, as it is then reused as is.
+	var sessionOpts []chdb.Option
+	if format, ok := opts[defaultFormatKey]; ok {
+		sessionOpts = append(sessionOpts, chdb.WithDefaultFormat(format))
+	}
+	var loc *time.Location
+	if tz, ok := opts[timeZoneKey]; ok {
+		if loc, err = native.Location(tz); err != nil {
+			return nil, err
+		}
+		opts = withTimeZoneSetting(opts, tz)
+	}
+	settings, err := settingOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 	sessionPath, ok := opts[sessionOptionKey]
-	if ok {
-		ret.session, err = chdb.NewSession(sessionPath)
+	if len(settings) > 0 {
+		// settings apply to the whole session, so the connector opens its own instead of sharing the global one
+		ret.session, err = chdb.NewIsolatedSession(sessionPath, append(sessionOpts, settings...)...)
+		if err != nil {
+			return nil, fmt.Errorf("open session for the settings: %w", err)
+		}
+		ret.ownsSession = true
+	} else if ok {
+		ret.session, err = chdb.OpenSession(sessionPath, sessionOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -232,7 +487,7 @@ func NewConnect(opts map[string]string) (ret *connector, err error) {
 	if ok {
 		ret.driverType = parseDriverType(driverType)
 	} else {
-		ret.driverType = PARQUET //default to parquet
+		ret.driverType = NATIVE //default to native
 	}
 	bufferSize, ok := opts[driverBufferSizeKey]
 	if ok {
@@ -252,13 +507,90 @@ func NewConnect(opts map[string]string) (ret *connector, err error) {
 		}
 	}
 
+	unwrapLowCardinality, ok := opts[unwrapLowCardinalityKey]
+	if ok {
+		if strings.ToLower(unwrapLowCardinality) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithUnwrapLowCardinality())
+		}
+	}
+
+	widenUnsigned, ok := opts[widenUnsignedKey]
+	if ok {
+		if strings.ToLower(widenUnsigned) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithWidenUnsigned())
+		}
+	}
+
+	uuidStrings, ok := opts[uuidStringsKey]
+	if ok {
+		if strings.ToLower(uuidStrings) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithUUIDStrings())
+		}
+	}
+
+	decimalStrings, ok := opts[decimalStringsKey]
+	if ok {
+		if strings.ToLower(decimalStrings) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithDecimalStrings())
+		}
+	}
+
+	ipStrings, ok := opts[ipStringsKey]
+	if ok {
+		if strings.ToLower(ipStrings) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithIPStrings())
+		}
+	}
+
+	describeTypes, ok := opts[describeTypesKey]
+	if ok {
+		if strings.ToLower(describeTypes) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithDescribedTypes())
+		}
+	}
+
+	if jsonAs, ok := opts[jsonAsKey]; ok {
+		switch mode := JSONMode(strings.ToLower(jsonAs)); mode {
+		case JSONAsMap, JSONAsRaw, JSONAsString:
+			ret.rowsOpts = append(ret.rowsOpts, WithJSONAs(mode))
+		default:
+			return nil, fmt.Errorf("invalid %s %q, expected map, raw or string", jsonAsKey, jsonAs)
+		}
+	}
+
+	enumAsString, ok := opts[enumAsStringKey]
+	if ok {
+		if strings.ToLower(enumAsString) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithEnumStrings())
+		}
+	}
+
+	bigIntBytes, ok := opts[bigIntBytesKey]
+	if ok {
+		if strings.ToLower(bigIntBytes) == "true" {
+			ret.rowsOpts = append(ret.rowsOpts, WithBigIntBytes())
+		}
+	}
+
+	if loc != nil {
+		ret.rowsOpts = append(ret.rowsOpts, WithLocation(loc))
+	}
+
+	prefetchDepth, ok := opts[prefetchDepthKey]
+	if ok {
+		// invalid depths leave prefetching disabled, like invalid buffer sizes fall back to the default
+		if depth, err := strconv.Atoi(prefetchDepth); err == nil && depth > 0 {
+			ret.rowsOpts = append(ret.rowsOpts, WithPrefetchDepth(depth))
+		}
+	}
+
 	udfPath, ok := opts[udfPathOptionKey]
 	if ok {
 		ret.udfPath = udfPath
 	}
 	if ret.session == nil {
 
-		ret.session, err = chdb.NewSession()
+		ret.session, err = chdb.OpenSession("", sessionOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -267,6 +599,21 @@ func NewConnect(opts map[string]string) (ret *connector, err error) {
 	return
 }
 
+// NewConnector returns a connector for the given connection string, whose rows are configured with opts.
+// Use it with sql.OpenDB to pass options which can't be expressed in the connection string.
+func NewConnector(name string, opts ...RowsOption) (driver.Connector, error) {
+	parsed, err := parseConnectStr(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewConnect(parsed)
+	if err != nil {
+		return nil, err
+	}
+	c.rowsOpts = append(c.rowsOpts, opts...)
+	return c, nil
+}
+
 type Driver struct{}
 
 // Open returns a new connection to the database.
@@ -294,6 +641,7 @@ type conn struct {
 	useUnsafe   bool
 	isStreaming bool
 	session     *chdb.Session
+	rowsOpts    []RowsOption
 
 	QueryFun  queryHandle
 	streamFun queryStream
@@ -315,20 +663,20 @@ func (c *conn) Close() error {
 	return nil
 }
 
+// Format returns the output format chDB produces for the queries of the connection, e.g. Parquet. It can be reached
+// through sql.Conn.Raw.
+func (c *conn) Format() string {
+	return c.driverType.GetFormat()
+}
+
 func (c *conn) SetupQueryFun() {
-	if c.isStreaming {
-		c.streamFun = chdb.QueryStream
-	} else {
-		c.QueryFun = chdb.Query
-	}
+	// the stream function is set up for non-streaming drivers too, to run the queries of cancellable contexts
+	c.streamFun = chdb.QueryStream
+	c.QueryFun = chdb.Query
 
 	if c.session != nil {
-		if c.isStreaming {
-			c.streamFun = c.session.QueryStream
-		} else {
-			c.QueryFun = c.session.Query
-		}
-
+		c.streamFun = c.session.QueryStream
+		c.QueryFun = c.session.Query
 	}
 
 }
@@ -350,21 +698,19 @@ func (c *conn) ExecContext(ctx context.Context, query string, args []driver.Name
 	if err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ctx.Done() != nil {
+		return c.execCancellable(ctx, compiledQuery)
+	}
 
 	result, err := c.QueryFun(compiledQuery, c.driverType.String(), c.udfPath)
 	if err != nil {
 		return nil, err
 	}
-	res := &execResult{
-		err:      nil,
-		localRes: result,
-	}
-	runtime.SetFinalizer(res, func(r *execResult) {
-		if r.localRes != nil {
-			r.localRes.Free()
-		}
-	})
-	return res, nil
+	defer result.Free()
+	return &execResult{stats: chdb.NewExecResult(result)}, nil
 }
 
 func (c *conn) QueryRowContext(ctx context.Context, query string, values []driver.Value) *singleRow {
@@ -381,7 +727,14 @@ func (c *conn) QueryRowContext(ctx context.Context, query string, values []drive
 	}
 }
 
+// compileArguments binds the arguments to the query: named arguments to its {name:Type} placeholders,
+// positional arguments to its ? placeholders.
 func (c *conn) compileArguments(query string, args []driver.NamedValue) (string, error) {
+	for _, arg := range args {
+		if arg.Name != "" {
+			return bindNamed(query, args)
+		}
+	}
 	var compiledQuery string
 	if len(args) > 0 {
 		compiledArgs := make([]interface{}, len(args))
@@ -404,23 +757,25 @@ func (c *conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 	if err != nil {
 		return nil, err
 	}
-	if c.isStreaming {
-		result, err := c.streamFun(compiledQuery, c.driverType.GetFormat(), c.udfPath)
-		if err != nil {
-			return nil, err
-		}
-		return c.driverType.PrepareStreamingRows(result, c.bufferSize, c.useUnsafe)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// only streamed queries can be interrupted, so the queries of cancellable contexts are always streamed
+	if c.isStreaming || ctx.Done() != nil {
+		return c.queryStreaming(ctx, compiledQuery)
 	}
+	opts := c.rowsOptions(compiledQuery)
 	result, err := c.QueryFun(compiledQuery, c.driverType.GetFormat(), c.udfPath)
 	if err != nil {
 		return nil, err
 	}
 
 	buf := result.Buf()
-	if len(buf) == 0 {
+	// Native and text results without rows may be empty
+	if len(buf) == 0 && c.driverType != NATIVE && c.driverType != CSV && c.driverType != TSV && c.driverType != JSON {
 		return nil, fmt.Errorf("result is nil")
 	}
-	return c.driverType.PrepareRows(result, buf, c.bufferSize, c.useUnsafe)
+	return c.driverType.PrepareRows(result, buf, c.bufferSize, c.useUnsafe, opts...)
 
 }
 
@@ -432,10 +787,7 @@ func (c *conn) Prepare(query string) (driver.Stmt, error) {
 	return c.PrepareContext(context.Background(), query)
 }
 
+// PrepareContext returns a prepared statement, whose arguments are bound like those of QueryContext.
 func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	return nil, fmt.Errorf("does not support prepare statement")
+	return &stmt{conn: c, query: query}, nil
 }
-
-// todo: func(c *conn) Prepare(query string)
-// todo: func(c *conn) PrepareContext(ctx context.Context, query string)
-// todo: prepared statment
@@ -1,12 +1,17 @@
 package chdbdriver
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
 )
 
 var (
@@ -22,6 +27,20 @@ func globalSetup() error {
 	return nil
 }
 
+// withoutSharedSession closes the shared session for the duration of the test, for the tests opening their own,
+// and opens a new one afterwards.
+func withoutSharedSession(t *testing.T) {
+	session.Close()
+	t.Cleanup(func() {
+		chdb.CloseGlobalSession()
+		sess, err := chdb.NewSession()
+		if err != nil {
+			t.Fatalf("reopen the shared session fail, err: %s", err)
+		}
+		session = sess
+	})
+}
+
 func globalTeardown() {
 	session.Cleanup()
 	session.Close()
@@ -144,6 +163,95 @@ func TestDbWithOpt(t *testing.T) {
 	}
 }
 
+func TestSettingOptions(t *testing.T) {
+	for _, tc := range []struct {
+		dsn      string
+		settings int
+		err      bool
+	}{
+		{"", 0, false},
+		{"driverType=parquet", 0, false},
+		{"settings.max_threads=4;settings.max_memory_usage=2G", 2, false},
+		{"settings.=1", 0, true},
+		{"settings.max threads=1", 0, true},
+	} {
+		opts, err := parseConnectStr(tc.dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		settings, err := settingOptions(opts)
+		if (err != nil) != tc.err || len(settings) != tc.settings {
+			t.Errorf("%s: expected %d settings, got %d, err: %v", tc.dsn, tc.settings, len(settings), err)
+		}
+	}
+	opts := map[string]string{timeZoneKey: "Asia/Tokyo"}
+	if got := withTimeZoneSetting(opts, "Asia/Tokyo"); got[settingsPrefix+"session_timezone"] != "Asia/Tokyo" || len(opts) != 1 {
+		t.Errorf("expected the session_timezone setting of tz without changing the options, got %v and %v", got, opts)
+	}
+	opts[settingsPrefix+"session_timezone"] = "UTC"
+	if got := withTimeZoneSetting(opts, "Asia/Tokyo"); got[settingsPrefix+"session_timezone"] != "UTC" {
+		t.Errorf("expected the explicit session_timezone setting to take precedence, got %v", got)
+	}
+}
+
+func TestDbWithSettings(t *testing.T) {
+	if _, err := sql.Open("chdb", "settings.max_threads=3"); !errors.Is(err, chdb.ErrConnectionInUse) {
+		t.Errorf("expected ErrConnectionInUse while the shared session is open, got %v", err)
+	}
+	withoutSharedSession(t)
+
+	db, err := sql.Open("chdb", "settings.max_threads=3;settings.max_memory_usage=2G")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	var value string
+	if err := db.QueryRow("SELECT getSetting('max_threads')").Scan(&value); err != nil {
+		t.Fatalf("query fail, err: %s", err)
+	}
+	if value != "3" {
+		t.Errorf("expected max_threads 3, got %s", value)
+	}
+	db.Close()
+
+	// the settings don't outlive the sql.DB
+	sess, err := chdb.NewSession()
+	if err != nil {
+		t.Fatalf("new session fail, err: %s", err)
+	}
+	defer sess.Close()
+	ret, err := sess.Query("SELECT getSetting('max_threads')")
+	if err != nil {
+		t.Fatalf("query fail, err: %s", err)
+	}
+	if ret.String() == "3\n" {
+		t.Errorf("expected the settings of the closed sql.DB not to apply to other sessions")
+	}
+	if _, err := sql.Open("chdb", "settings.max-threads=3"); err == nil {
+		t.Errorf("expected an error for an invalid setting name")
+	}
+}
+
+func TestDbWithDefaultFormat(t *testing.T) {
+	withoutSharedSession(t)
+
+	db, err := sql.Open("chdb", "defaultFormat=JSONEachRow")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+	var value int
+	if err := db.QueryRow("SELECT 42").Scan(&value); err != nil || value != 42 {
+		t.Fatalf("expected the driver format to be used, got %d, err: %v", value, err)
+	}
+	sess, err := chdb.NewSession()
+	if err != nil {
+		t.Fatalf("new session fail, err: %s", err)
+	}
+	if sess.DefaultFormat() != "JSONEachRow" {
+		t.Errorf("expected default format JSONEachRow, got %s", sess.DefaultFormat())
+	}
+}
+
 func TestDbWithSession(t *testing.T) {
 
 	session.Query(
@@ -349,10 +457,32 @@ func TestExec(t *testing.T) {
 		fmt.Printf("tblName: %v\n", tblName)
 	}
 
-	_, err = db.Exec("INSERT INTO TestExec VALUES (1), (2), (3);")
+	res, err := db.Exec("INSERT INTO TestExec VALUES (1), (2), (3);")
 	if err != nil {
 		t.Fatalf("exec failed, err: %s", err)
 	}
+	if n, err := res.RowsAffected(); err != nil || n != 3 {
+		t.Errorf("expected 3 rows affected, got %d, err: %v", n, err)
+	}
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("get conn fail, err: %s", err)
+	}
+	defer cn.Close()
+	err = cn.Raw(func(driverConn any) error {
+		res, err := driverConn.(driver.ExecerContext).ExecContext(context.Background(), "INSERT INTO TestExec VALUES (4), (5)", nil)
+		if err != nil {
+			return err
+		}
+		if stats := res.(StatsResult).Stats(); stats.RowsWritten != 2 {
+			t.Errorf("expected 2 rows written, got %d", stats.RowsWritten)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("raw exec failed, err: %s", err)
+	}
 	rows := db.QueryRow("select * from TestExec;")
 
 	var bar = 0
@@ -370,3 +500,169 @@ func TestExec(t *testing.T) {
 	}
 
 }
+
+// chunkResult is a fake result serving a fixed buffer.
+type chunkResult struct {
+	buf []byte
+}
+
+func (r *chunkResult) Buf() []byte       { return r.buf }
+func (r *chunkResult) String() string    { return string(r.buf) }
+func (r *chunkResult) Len() int          { return len(r.buf) }
+func (r *chunkResult) Elapsed() float64  { return 0 }
+func (r *chunkResult) RowsRead() uint64  { return 1 }
+func (r *chunkResult) BytesRead() uint64 { return uint64(len(r.buf)) }
+func (r *chunkResult) Error() error      { return nil }
+func (r *chunkResult) Free()             {}
+
+// chunkStream is a fake stream serving the given chunks.
+type chunkStream struct {
+	chunks []*chunkResult
+}
+
+func (s *chunkStream) GetNext() chdbpurego.ChdbResult {
+	if len(s.chunks) == 0 {
+		return nil
+	}
+	next := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return next
+}
+func (s *chunkStream) Error() error { return nil }
+func (s *chunkStream) Cancel()      {}
+func (s *chunkStream) Free()        {}
+
+func TestTruncatedChunk(t *testing.T) {
+	result, err := session.Query("SELECT number, toString(number) FROM numbers(10)", "Parquet")
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	buf := result.Buf()
+	truncated := buf[:len(buf)-10]
+
+	if _, err := PARQUET.PrepareRows(&chunkResult{buf: truncated}, truncated, defaultBufferSize, false); !errors.Is(err, ErrTruncatedChunk) {
+		t.Errorf("PARQUET: expected ErrTruncatedChunk, got %v", err)
+	}
+	stream := &chunkStream{chunks: []*chunkResult{{buf: truncated}}}
+	if _, err := PARQUET_STREAMING.PrepareStreamingRows(stream, defaultBufferSize, false); !errors.Is(err, ErrTruncatedChunk) {
+		t.Errorf("PARQUET_STREAMING: expected ErrTruncatedChunk, got %v", err)
+	}
+
+	// a truncated chunk in the middle of the stream is reported by Next
+	stream = &chunkStream{chunks: []*chunkResult{{buf: buf}, {buf: truncated}}}
+	rows, err := PARQUET_STREAMING.PrepareStreamingRows(stream, defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	values := make([]driver.Value, 2)
+	for {
+		err = rows.Next(values)
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrTruncatedChunk) {
+		t.Errorf("expected ErrTruncatedChunk reading the second chunk, got %v", err)
+	}
+}
+
+func TestDbWithArrays(t *testing.T) {
+	for _, driverType := range []string{"NATIVE", "PARQUET", "PARQUET_STREAMING", "ARROW"} {
+		db, err := sql.Open("chdb", "driverType="+driverType)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var (
+			ids    []uint64
+			names  []*string
+			nested any
+		)
+		err = db.QueryRow("SELECT [1, 2, 3]::Array(UInt64), ['a', NULL]::Array(Nullable(String)), [[1], [2, 3]]::Array(Array(UInt8))").
+			Scan(&ids, &names, &nested)
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if !reflect.DeepEqual(ids, []uint64{1, 2, 3}) {
+			t.Errorf("%s: unexpected Array(UInt64) %v", driverType, ids)
+		}
+		if len(names) != 2 || names[0] == nil || *names[0] != "a" || names[1] != nil {
+			t.Errorf("%s: unexpected Array(Nullable(String)) %v", driverType, names)
+		}
+		if !reflect.DeepEqual(nested, []any{[]uint8{1}, []uint8{2, 3}}) {
+			t.Errorf("%s: unexpected Array(Array(UInt8)) %#v", driverType, nested)
+		}
+	}
+}
+
+func TestDbWithMaps(t *testing.T) {
+	for _, driverType := range []string{"NATIVE", "PARQUET", "PARQUET_STREAMING", "ARROW"} {
+		db, err := sql.Open("chdb", "driverType="+driverType)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var (
+			counts map[string]uint32
+			nested any
+		)
+		err = db.QueryRow("SELECT map('a', 1, 'b', 2)::Map(String, UInt32), map(1, ['x'])::Map(UInt8, Array(String))").
+			Scan(&counts, &nested)
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if !reflect.DeepEqual(counts, map[string]uint32{"a": 1, "b": 2}) {
+			t.Errorf("%s: unexpected Map(String, UInt32) %v", driverType, counts)
+		}
+		if !reflect.DeepEqual(nested, map[uint8][]string{1: {"x"}}) {
+			t.Errorf("%s: unexpected Map(UInt8, Array(String)) %#v", driverType, nested)
+		}
+	}
+}
+
+func TestDbWithTuples(t *testing.T) {
+	query := "SELECT (1, 'a')::Tuple(id UInt8, name String), [(2, 'b')]::Array(Tuple(id UInt8, name String))"
+	for _, driverType := range []string{"NATIVE", "PARQUET", "PARQUET_STREAMING", "ARROW", "JSON"} {
+		db, err := sql.Open("chdb", "driverType="+driverType)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		cn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("get conn fail, err: %s", err)
+		}
+		err = cn.Raw(func(driverConn any) error {
+			rows, err := driverConn.(driver.QueryerContext).QueryContext(context.Background(), query, nil)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for i := 0; i < 2; i++ {
+				if names := rows.(RowsColumnTypeElementNames).ColumnTypeElementNames(i); !reflect.DeepEqual(names, []string{"id", "name"}) {
+					t.Errorf("%s: column %d: unexpected element names %q", driverType, i, names)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: raw query fail, err: %s", driverType, err)
+		}
+		cn.Close()
+
+		var tuple, nested any
+		err = db.QueryRow(query).Scan(&tuple, &nested)
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if driverType == "JSON" {
+			continue
+		}
+		if !reflect.DeepEqual(tuple, []any{uint8(1), "a"}) {
+			t.Errorf("%s: unexpected Tuple %#v", driverType, tuple)
+		}
+		if !reflect.DeepEqual(nested, []any{[]any{uint8(2), "b"}}) {
+			t.Errorf("%s: unexpected Array(Tuple) %#v", driverType, nested)
+		}
+	}
+}
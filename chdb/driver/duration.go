@@ -0,0 +1,80 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// WithDurationColumns makes the given columns scan as time.Duration. Each column is mapped to the unit of its values,
+// e.g. time.Second for a column of seconds. Interval columns such as IntervalSecond are exported as plain integers,
+// so they need to be listed as well. Numeric values are multiplied by the unit, NULL values are left as is.
+func WithDurationColumns(units map[string]time.Duration) RowsOption {
+	return func(c *rowsConfig) {
+		if c.durationUnits == nil {
+			c.durationUnits = make(map[string]time.Duration, len(units))
+		}
+		for column, unit := range units {
+			c.durationUnits[column] = unit
+		}
+	}
+}
+
+// convertDurations converts the values of the duration columns of a decoded row.
+func (c *rowsConfig) convertDurations(fields []parquet.Field, dest []driver.Value) error {
+	if len(c.durationUnits) == 0 {
+		return nil
+	}
+	for i, f := range fields {
+		if err := c.convertDuration(f.Name(), &dest[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertDuration converts the value of the named column when it is a duration column.
+func (c *rowsConfig) convertDuration(column string, v *driver.Value) error {
+	unit, ok := c.durationUnits[column]
+	if !ok || *v == nil {
+		return nil
+	}
+	d, err := toDuration(*v, unit)
+	if err != nil {
+		return fmt.Errorf("column %s: %w", column, err)
+	}
+	*v = d
+	return nil
+}
+
+func toDuration(v any, unit time.Duration) (time.Duration, error) {
+	switch n := v.(type) {
+	case int8:
+		return time.Duration(n) * unit, nil
+	case int16:
+		return time.Duration(n) * unit, nil
+	case int32:
+		return time.Duration(n) * unit, nil
+	case int64:
+		return time.Duration(n) * unit, nil
+	case uint8:
+		return time.Duration(n) * unit, nil
+	case uint16:
+		return time.Duration(n) * unit, nil
+	case uint32:
+		return time.Duration(n) * unit, nil
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows time.Duration", n)
+		}
+		return time.Duration(n) * unit, nil
+	case float32:
+		return time.Duration(float64(n) * float64(unit)), nil
+	case float64:
+		return time.Duration(n * float64(unit)), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to time.Duration", v)
+}
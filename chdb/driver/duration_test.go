@@ -0,0 +1,62 @@
+package chdbdriver
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDbWithDurationColumns(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING", "NATIVE"} {
+		connector, err := NewConnector(fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType),
+			WithDurationColumns(map[string]time.Duration{"elapsed": time.Second, "ttl": time.Millisecond}))
+		if err != nil {
+			t.Fatalf("create connector fail, err: %s", err)
+		}
+		db := sql.OpenDB(connector)
+		var (
+			elapsed time.Duration
+			ttl     *time.Duration
+			missing *time.Duration
+		)
+		row := db.QueryRow("SELECT toUInt32(90) AS elapsed, toNullable(toInt64(1500)) AS ttl, CAST(NULL AS Nullable(Int64)) AS missing")
+		if err := row.Scan(&elapsed, &ttl, &missing); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if elapsed != 90*time.Second {
+			t.Errorf("%s: expected 90s, got %s", driverType, elapsed)
+		}
+		if ttl == nil || *ttl != 1500*time.Millisecond {
+			t.Errorf("%s: expected 1.5s, got %v", driverType, ttl)
+		}
+		if missing != nil {
+			t.Errorf("%s: expected nil duration, got %v", driverType, *missing)
+		}
+		db.Close()
+	}
+}
+
+func TestToDuration(t *testing.T) {
+	tests := []struct {
+		value    any
+		unit     time.Duration
+		expected time.Duration
+	}{
+		{int32(3), time.Second, 3 * time.Second},
+		{uint64(2), time.Minute, 2 * time.Minute},
+		{float64(1.5), time.Second, 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got, err := toDuration(tt.value, tt.unit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Errorf("toDuration(%v, %s) = %s, expected %s", tt.value, tt.unit, got, tt.expected)
+		}
+	}
+	if _, err := toDuration("1s", time.Second); err == nil {
+		t.Errorf("expected an error converting a string")
+	}
+}
@@ -0,0 +1,207 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+)
+
+// nativeRows reads Native results, decoding their column blocks directly. The result is either a single buffer,
+// or the chunks of a stream, each of them made of complete blocks.
+type nativeRows struct {
+	streamRows
+	result     chdbpurego.ChdbResult       // current chunk
+	stream     chdbpurego.ChdbStreamResult // nil for buffered results
+	reader     *native.Reader
+	block      *native.Block
+	blockRow   int
+	names      []string       // columns reported by the rows
+	types      []string       // ClickHouse types of the reported columns
+	projection []int          // indexes of the reported columns in the blocks, nil when not projected
+	opts       native.Options // decoding options
+	rowsConfig
+}
+
+func newNativeRows(result chdbpurego.ChdbResult, stream chdbpurego.ChdbStreamResult, useUnsafe bool, opts []RowsOption) (*nativeRows, error) {
+	rows := &nativeRows{result: result, stream: stream, rowsConfig: newRowsConfig(opts)}
+	rows.columns = func() []chdb.ColumnMeta {
+		return typedColumns(rows.names, rows.types, rows.columnScanType)
+	}
+	rows.opts = native.Options{UnsafeStrings: useUnsafe && !rows.resultCopy, WidenUnsigned: rows.widenUnsigned, UUIDStrings: rows.uuidStrings, DecimalStrings: rows.decimalStrings, IPStrings: rows.ipStrings, EnumNames: rows.enumStrings, BigIntBytes: rows.bigIntBytes, JSONAs: rows.jsonMode(), Location: rows.location}
+	rows.reader = native.NewReader(result.Buf(), rows.opts)
+	// the columns are known from the first block, which is read upfront
+	for rows.names == nil {
+		err := rows.nextBlock()
+		if err == io.EOF {
+			err = rows.nextChunk()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// nextBlock reads the next block of the current chunk, and sets the reported columns from the first one.
+func (r *nativeRows) nextBlock() error {
+	block, err := r.reader.Next()
+	if err != nil {
+		return err
+	}
+	if r.names == nil {
+		if err := r.setColumns(block); err != nil {
+			return err
+		}
+	}
+	r.block, r.blockRow = block, 0
+	return nil
+}
+
+func (r *nativeRows) setColumns(block *native.Block) error {
+	names, types := block.Names, block.Types
+	var projection []int
+	if r.rowsConfig.projection != nil {
+		projection = make([]int, len(r.rowsConfig.projection))
+		names, types = make([]string, len(projection)), make([]string, len(projection))
+		for i, name := range r.rowsConfig.projection {
+			projection[i] = -1
+			for j, n := range block.Names {
+				if n == name {
+					projection[i] = j
+					break
+				}
+			}
+			if projection[i] < 0 {
+				return fmt.Errorf("projected column %q not found in the result", name)
+			}
+			names[i], types[i] = name, block.Types[projection[i]]
+		}
+	}
+	r.names, r.types, r.projection = names, types, projection
+	return nil
+}
+
+func (r *nativeRows) Columns() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Close releases the result, see streamRows.close.
+func (r *nativeRows) Close() error {
+	return r.close(func() {
+		r.block = nil
+		r.reader = nil
+		if r.stream != nil {
+			r.stream.Free()
+			r.stream = nil
+		} else {
+			r.result.Free()
+		}
+		r.result = nil
+	})
+}
+
+func (r *nativeRows) Next(dest []driver.Value) error {
+	return r.read(func() error {
+		return r.next(dest)
+	})
+}
+
+func (r *nativeRows) next(dest []driver.Value) error {
+	for r.block == nil || r.blockRow == r.block.Rows {
+		err := r.nextBlock()
+		if err == io.EOF {
+			err = r.nextChunk()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i := range r.names {
+		column := i
+		if r.projection != nil {
+			column = r.projection[i]
+		}
+		dest[i] = r.block.Columns[column][r.blockRow]
+		if err := r.convertDuration(r.names[i], &dest[i]); err != nil {
+			return err
+		}
+		if t, ok := dest[i].(time.Time); ok && r.timeStrings {
+			if p, _, ok := native.PrecisionScale(r.types[i]); ok && strings.Contains(r.types[i], "DateTime64") {
+				dest[i] = formatDateTime64(t, int(p))
+			}
+		}
+	}
+	r.blockRow++
+	return nil
+}
+
+// nextChunk moves to the next chunk of the stream, returning io.EOF once it is exhausted.
+func (r *nativeRows) nextChunk() error {
+	if r.stream == nil || r.result == nil {
+		return io.EOF
+	}
+	r.block = nil
+	// free the previous chunk
+	r.result.Free()
+	r.result = r.stream.GetNext()
+	if r.result == nil {
+		return io.EOF
+	}
+	if r.result.Error() != nil {
+		return fmt.Errorf("error in chunk: %s", r.result.Error())
+	}
+	if r.result.RowsRead() == 0 {
+		return io.EOF
+	}
+	r.reader = native.NewReader(r.result.Buf(), r.opts)
+	return nil
+}
+
+func (r *nativeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if r.unwrapLowCardinality {
+		return native.UnwrapLowCardinality(r.types[index])
+	}
+	return r.types[index]
+}
+
+func (r *nativeRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return native.Nullable(r.types[index]), true
+}
+
+func (r *nativeRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	return native.PrecisionScale(r.types[index])
+}
+
+func (r *nativeRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.columnScanType(index)
+}
+
+// columnScanType returns the scan type of a column: string for the DateTime64 columns formatted with
+// WithDateTime64Strings, or *string when they are Nullable.
+func (r *nativeRows) columnScanType(index int) reflect.Type {
+	if _, _, ok := native.PrecisionScale(r.types[index]); ok && r.timeStrings && strings.Contains(r.types[index], "DateTime64") {
+		if native.Nullable(r.types[index]) {
+			return reflect.TypeOf((*string)(nil))
+		}
+		return reflect.TypeOf("")
+	}
+	return native.ScanType(r.types[index], r.opts)
+}
+
+func (r *nativeRows) ColumnTypeElementNames(index int) []string {
+	return native.ElementNames(r.types[index])
+}
+
+func (r *nativeRows) ColumnTypeEnumValues(index int) map[string]int16 {
+	return native.EnumValues(r.types[index])
+}
@@ -0,0 +1,250 @@
+package chdbdriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"math/big"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDbWithNative(t *testing.T) {
+	// Native is the default driver type
+	db, err := sql.Open("chdb", "")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT number AS id, toString(number) AS name, if(number = 1, NULL, number) AS maybe,
+		[toNullable('a'), NULL] AS tags, toDecimal64(number, 2) AS price, toDate('2024-01-02') AS day,
+		toLowCardinality('lc') AS lc, (number, 'x') AS pair
+		FROM numbers(3)`)
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatalf("get column types fail, err: %s", err)
+	}
+	for i, expected := range []string{"UInt64", "String", "Nullable(UInt64)", "Array(Nullable(String))", "Decimal(18, 2)", "Date", "LowCardinality(String)", "Tuple(UInt64, String)"} {
+		if got := types[i].DatabaseTypeName(); got != expected {
+			t.Errorf("column %s: expected type %s, got %s", types[i].Name(), expected, got)
+		}
+	}
+	a := "a"
+	var count uint64
+	for rows.Next() {
+		var (
+			id    uint64
+			name  string
+			maybe *uint64
+			tags  []*string
+			price *big.Rat
+			day   time.Time
+			lc    string
+			pair  []any
+		)
+		if err := rows.Scan(&id, &name, &maybe, &tags, &price, &day, &lc, &pair); err != nil {
+			t.Fatalf("scan fail, err: %s", err)
+		}
+		if id != count || name != strconv.FormatUint(count, 10) {
+			t.Errorf("row %d: unexpected id %d and name %s", count, id, name)
+		}
+		if (count == 1) != (maybe == nil) {
+			t.Errorf("row %d: unexpected nullable value %v", count, maybe)
+		}
+		if !reflect.DeepEqual(tags, []*string{&a, nil}) {
+			t.Errorf("row %d: unexpected tags %v", count, tags)
+		}
+		if price.Cmp(new(big.Rat).SetUint64(count)) != 0 || !day.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) || lc != "lc" {
+			t.Errorf("row %d: unexpected price %s, day %s or lc %s", count, price, day, lc)
+		}
+		if !reflect.DeepEqual(pair, []any{count, "x"}) {
+			t.Errorf("row %d: unexpected pair %v", count, pair)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows fail, err: %s", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 rows, got %d", count)
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT count() FROM numbers(10) WHERE number > 100").Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected a count of 0, got %d, err: %v", n, err)
+	}
+	empty, err := db.Query("SELECT number FROM numbers(10) WHERE number > 100")
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	if empty.Next() {
+		t.Errorf("expected no rows")
+	}
+	empty.Close()
+}
+
+func TestDbWithUUID(t *testing.T) {
+	const id = "01234567-89ab-cdef-0123-456789abcdef"
+	for _, dsn := range []string{"driverType=NATIVE", "driverType=PARQUET"} {
+		db, err := sql.Open("chdb", dsn)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var v any
+		if err := db.QueryRow("SELECT toUUID('" + id + "')").Scan(&v); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", dsn, err)
+		}
+		if v != uuid.MustParse(id) {
+			t.Errorf("%s: expected a uuid.UUID, got %#v", dsn, v)
+		}
+		db.Close()
+
+		db, err = sql.Open("chdb", dsn+";uuidStrings=true")
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var u uuid.UUID
+		if err := db.QueryRow("SELECT toUUID('" + id + "')").Scan(&u); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", dsn, err)
+		}
+		if u.String() != id {
+			t.Errorf("%s: expected %s, got %s", dsn, id, u)
+		}
+		db.Close()
+	}
+}
+
+func TestDbWithBigInts(t *testing.T) {
+	const query = "SELECT toInt128(-170141183460469231731687303715884105728) AS i, " +
+		"toUInt256('115792089237316195423570985008687907853269984665640564039457584007913129639935') AS u, toUInt128(42) AS small"
+	minInt128 := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+	maxUInt256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	db, err := sql.Open("chdb", "driverType=NATIVE")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("query fail, err: %s", err)
+	}
+	types, _ := rows.ColumnTypes()
+	if types[0].DatabaseTypeName() != "Int128" || types[1].ScanType() != reflect.TypeOf((*big.Int)(nil)) {
+		t.Errorf("unexpected column types %s, %s", types[0].DatabaseTypeName(), types[1].ScanType())
+	}
+	var (
+		i, u  *big.Int
+		small int64
+	)
+	if !rows.Next() {
+		t.Fatalf("expected a row, err: %v", rows.Err())
+	}
+	// values which fit also scan into integers
+	if err := rows.Scan(&i, &u, &small); err != nil {
+		t.Fatalf("scan fail, err: %s", err)
+	}
+	rows.Close()
+	db.Close()
+	if i.Cmp(minInt128) != 0 || u.Cmp(maxUInt256) != 0 || small != 42 {
+		t.Errorf("expected %s, %s and 42, got %s, %s and %d", minInt128, maxUInt256, i, u, small)
+	}
+
+	// Parquet results don't record the big integer types, so the query is described to decode them
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", "driverType="+driverType)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var pi, pu, psmall *big.Int
+		err = db.QueryRow(query).Scan(&pi, &pu, &psmall)
+		db.Close()
+		if err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if pi.Cmp(minInt128) != 0 || pu.Cmp(maxUInt256) != 0 || psmall.Int64() != 42 {
+			t.Errorf("%s: expected %s, %s and 42, got %s, %s and %s", driverType, minInt128, maxUInt256, pi, pu, psmall)
+		}
+	}
+
+	db, err = sql.Open("chdb", "driverType=PARQUET;bigIntBytes=true")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+	var ri, ru, raw []byte
+	if err := db.QueryRow(query).Scan(&ri, &ru, &raw); err != nil {
+		t.Fatalf("parquet: scan fail, err: %s", err)
+	}
+	if len(raw) != 16 || raw[0] != 42 {
+		t.Errorf("parquet: expected the 16 raw bytes of 42, got %v", raw)
+	}
+}
+
+func TestDbWithEnums(t *testing.T) {
+	const query = "SELECT CAST('b', 'Enum8(\\'a\\' = 1, \\'b\\' = 2)') AS e"
+	for _, tc := range []struct {
+		dsn      string
+		expected any
+	}{
+		{"driverType=NATIVE", int8(2)},
+		{"driverType=NATIVE;enumAsString=true", "b"},
+	} {
+		db, err := sql.Open("chdb", tc.dsn)
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var v any
+		if err := db.QueryRow(query).Scan(&v); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", tc.dsn, err)
+		}
+		if v != tc.expected {
+			t.Errorf("%s: expected %#v, got %#v", tc.dsn, tc.expected, v)
+		}
+
+		cn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("get conn fail, err: %s", err)
+		}
+		err = cn.Raw(func(driverConn any) error {
+			rows, err := driverConn.(driver.QueryerContext).QueryContext(context.Background(), query, nil)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			if values := rows.(RowsColumnTypeEnumValues).ColumnTypeEnumValues(0); !reflect.DeepEqual(values, map[string]int16{"a": 1, "b": 2}) {
+				t.Errorf("%s: unexpected enum values %v", tc.dsn, values)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: raw query fail, err: %s", tc.dsn, err)
+		}
+		cn.Close()
+		db.Close()
+	}
+}
+
+func TestDbWithNativeJSON(t *testing.T) {
+	withoutSharedSession(t)
+	db, err := sql.Open("chdb", "driverType=NATIVE;settings.output_format_native_write_json_as_string=1")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+	var m map[string]any
+	if err := db.QueryRow(`SELECT '{"a":1,"b":"x"}'::JSON AS j`).Scan(&m); err != nil {
+		t.Fatalf("scan fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(m, map[string]any{"a": int64(1), "b": "x"}) {
+		t.Errorf("unexpected map %v", m)
+	}
+}
@@ -0,0 +1,208 @@
+package chdbdriver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// RowsOption configures the rows returned by the driver.
+type RowsOption func(*rowsConfig)
+
+// rowsConfig holds the settings shared by all the rows implementations.
+type rowsConfig struct {
+	unwrapLowCardinality bool
+	widenUnsigned        bool
+	durationUnits        map[string]time.Duration
+	prefetchDepth        int
+	converters           map[string]func(parquet.Value) (any, error)
+	resultCopy           bool
+	timeStrings          bool
+	uuidStrings          bool
+	decimalStrings       bool
+	enumStrings          bool
+	ipStrings            bool
+	jsonAs               JSONMode
+	describeTypes        bool
+	columnTypes          map[string]string                 // ClickHouse types of the columns by name, when described
+	describer            func() (map[string]string, error) // describes the query for describeBigInts, nil once done
+	bigIntBytes          bool
+	location             *time.Location
+	projection           []string
+
+	dedupColumns     []string
+	dedupLimit       int
+	dedupPassthrough bool
+}
+
+func newRowsConfig(opts []RowsOption) rowsConfig {
+	var cfg rowsConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithUnwrapLowCardinality makes ColumnTypeDatabaseTypeName report LowCardinality(T) columns as T.
+// Scanning is not affected: LowCardinality(T) columns are always decoded as T.
+func WithUnwrapLowCardinality() RowsOption {
+	return func(c *rowsConfig) {
+		c.unwrapLowCardinality = true
+	}
+}
+
+// WithWidenUnsigned makes unsigned integer columns scan as the next larger signed type,
+// e.g. UInt32 as int64, for code which doesn't handle unsigned types.
+// UInt64 values are returned as int64 when they fit, and as uint64 otherwise.
+func WithWidenUnsigned() RowsOption {
+	return func(c *rowsConfig) {
+		c.widenUnsigned = true
+	}
+}
+
+// WithUUIDStrings makes UUID columns scan as their canonical string instead of uuid.UUID values. uuid.UUID values
+// suit *any destinations, but not *uuid.UUID or string ones: its Scan method only accepts strings and bytes.
+// It applies to the Parquet and Native driver types, and to the Arrow ones when the types are described.
+func WithUUIDStrings() RowsOption {
+	return func(c *rowsConfig) {
+		c.uuidStrings = true
+	}
+}
+
+// WithDecimalStrings makes Decimal columns scan as the text of their exact value, e.g. "-12.34", instead of *big.Rat
+// values, and report string as their scan type. *big.Rat values only scan into *big.Rat and *any destinations, while
+// the strings also scan into float and string ones. It applies to the Parquet, Native and Arrow driver types.
+func WithDecimalStrings() RowsOption {
+	return func(c *rowsConfig) {
+		c.decimalStrings = true
+	}
+}
+
+// WithEnumStrings makes Enum8 and Enum16 columns scan as the names of their values instead of their int8 and int16
+// values. It applies to the Native driver type: Parquet and Arrow results hold enums as plain integers, and CSV, TSV
+// and JSON results as their names.
+func WithEnumStrings() RowsOption {
+	return func(c *rowsConfig) {
+		c.enumStrings = true
+	}
+}
+
+// JSONMode selects how the values of JSON columns are decoded, see WithJSONAs.
+type JSONMode string
+
+const (
+	// JSONAsMap decodes JSON objects as a map[string]any, with integer numbers as int64 and other numbers as
+	// float64, like the JSON driver type. It is the default.
+	JSONAsMap JSONMode = jsonval.Map
+	// JSONAsRaw decodes JSON values as a json.RawMessage.
+	JSONAsRaw JSONMode = jsonval.Raw
+	// JSONAsString decodes JSON values as their text.
+	JSONAsString JSONMode = "string"
+)
+
+// WithJSONAs selects how the values of JSON columns are decoded. It applies to the Parquet driver types, to the
+// columns of the JSON logical type and, with WithDescribedTypes, to the described JSON columns, and to the JSON and
+// Object columns of the Native driver type. Native results only hold them as text with the
+// output_format_native_write_json_as_string setting, e.g. settings.output_format_native_write_json_as_string=1 in
+// the connection string.
+func WithJSONAs(mode JSONMode) RowsOption {
+	return func(c *rowsConfig) {
+		c.jsonAs = mode
+	}
+}
+
+// jsonMode returns the jsonval mode of the JSON columns.
+func (c *rowsConfig) jsonMode() string {
+	switch c.jsonAs {
+	case JSONAsRaw:
+		return jsonval.Raw
+	case JSONAsString:
+		return jsonval.String
+	}
+	return jsonval.Map
+}
+
+// WithBigIntBytes makes Int128, UInt128, Int256 and UInt256 columns scan as their raw little endian bytes instead of
+// a *big.Int, whose values scan into *big.Int destinations, and into integer destinations when they fit. It applies to
+// the Native driver type and to the Parquet driver types, whose results don't record the types of such columns:
+// without it, the query is described to learn them when the result holds arrays of 16 or 32 bytes, see
+// WithDescribedTypes, and only the top-level columns are decoded as *big.Int.
+func WithBigIntBytes() RowsOption {
+	return func(c *rowsConfig) {
+		c.bigIntBytes = true
+	}
+}
+
+// BigIntConverter returns a column converter decoding the values of an Int128 or Int256 column when signed, or of
+// a UInt128 or UInt256 column otherwise, into *big.Int values. Parquet results hold such columns as fixed length
+// byte arrays, without recording their type, which the Parquet driver types describe to decode them, unless
+// WithBigIntBytes is set; it applies the conversion to a column explicitly, e.g. with
+// WithColumnConverter("hash", BigIntConverter(false)) for a UInt256 column.
+func BigIntConverter(signed bool) func(parquet.Value) (any, error) {
+	return func(v parquet.Value) (any, error) {
+		if v.IsNull() {
+			return nil, nil
+		}
+		if v.Kind() != parquet.FixedLenByteArray {
+			return nil, fmt.Errorf("could not convert %s value to a big integer", v.Kind())
+		}
+		return native.BigInt(v.ByteArray(), signed), nil
+	}
+}
+
+// WithLocation sets the time zone of the DateTime and DateTime64 values of columns declared without one, which are
+// returned in UTC by default. Values of columns declared with a time zone, e.g. DateTime64(3, 'Asia/Tokyo'), are
+// returned in it with the Native and Arrow driver types. Parquet results don't record the time zones of their
+// columns, so it applies to all their timestamps. It doesn't change how the queries interpret times, unlike the tz
+// option of the connection string, which also sets the session_timezone setting of the connection.
+func WithLocation(loc *time.Location) RowsOption {
+	return func(c *rowsConfig) {
+		c.location = loc
+	}
+}
+
+// WithColumnConverter decodes the values of the named column with fn instead of the built-in decoding,
+// e.g. to unpack a bit field into a struct. fn is called with every value of the column, NULL values included,
+// and only applies to columns that are not arrays, tuples or maps. Like the other options taking parquet.Value
+// values, it only applies to the Parquet driver types.
+func WithColumnConverter(column string, fn func(parquet.Value) (any, error)) RowsOption {
+	return func(c *rowsConfig) {
+		if c.converters == nil {
+			c.converters = map[string]func(parquet.Value) (any, error){}
+		}
+		c.converters[column] = fn
+	}
+}
+
+// WithResultCopy guarantees that every returned value is a Go owned copy, which remains valid once the rows are closed,
+// even if the connection was opened with useUnsafeStringReader. Values are copied by default, this option only
+// matters to override the unsafe string reader.
+func WithResultCopy() RowsOption {
+	return func(c *rowsConfig) {
+		c.resultCopy = true
+	}
+}
+
+func (c *rowsConfig) decoder(unsafeStrings bool) pqconv.Decoder {
+	return pqconv.Decoder{UnsafeStrings: unsafeStrings && !c.resultCopy, WidenUnsigned: c.widenUnsigned, UUIDStrings: c.uuidStrings, DecimalStrings: c.decimalStrings, JSONAs: c.jsonMode(), Location: c.location, Converters: c.typeConverters()}
+}
+
+// databaseTypeName returns the database type name of the named column of a Parquet result: its described type when
+// known, and its Parquet type otherwise.
+func (c *rowsConfig) databaseTypeName(name, typeName string) string {
+	if described, ok := c.columnTypes[name]; ok {
+		typeName = described
+	}
+	// ClickHouse Bool columns are stored as parquet BOOLEAN
+	if typeName == "BOOLEAN" {
+		return "Bool"
+	}
+	if c.unwrapLowCardinality {
+		return pqconv.UnwrapLowCardinality(typeName)
+	}
+	return typeName
+}
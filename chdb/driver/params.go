@@ -0,0 +1,137 @@
+package chdbdriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+// placeholderPattern matches a ClickHouse query parameter placeholder, e.g. {id:UInt64}.
+var placeholderPattern = regexp.MustCompile(`^\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*:\s*([^{}]+?)\s*\}`)
+
+// bindNamed replaces the {name:Type} placeholders of the query with the values of the named arguments,
+// cast to the placeholder type. Identifier placeholders, e.g. {table:Identifier}, are replaced with the
+// quoted identifier. Placeholders inside string literals, quoted identifiers and comments are left as is.
+func bindNamed(query string, args []driver.NamedValue) (string, error) {
+	values := make(map[string]any, len(args))
+	for _, arg := range args {
+		if arg.Name == "" {
+			return "", fmt.Errorf("positional arguments can't be mixed with named arguments")
+		}
+		values[arg.Name] = arg.Value
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end := sqlfmt.QuotedEnd(query, i)
+			out.WriteString(query[i:end])
+			i = end
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			out.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			out.WriteString(query[i : i+end+4])
+			i += end + 4
+		case c == '{':
+			m := placeholderPattern.FindStringSubmatch(query[i:])
+			if m == nil {
+				out.WriteByte(c)
+				i++
+				continue
+			}
+			value, ok := values[m[1]]
+			if !ok {
+				return "", fmt.Errorf("missing value for query parameter %q", m[1])
+			}
+			bound, err := bindValue(m[2], value)
+			if err != nil {
+				return "", fmt.Errorf("query parameter %q: %w", m[1], err)
+			}
+			out.WriteString(bound)
+			i += len(m[0])
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String(), nil
+}
+
+// bindValue renders the value of a placeholder of the given ClickHouse type.
+func bindValue(chType string, value any) (string, error) {
+	if chType == "Identifier" {
+		name, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("expected a string for an Identifier, got %T", value)
+		}
+		return sqlfmt.QuoteIdentifier(name), nil
+	}
+	lit, err := sqlfmt.Literal(reflect.ValueOf(value))
+	if err != nil {
+		return "", err
+	}
+	return "CAST(" + lit + " AS " + chType + ")", nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, accepting the slices, arrays and maps bound to query
+// parameters as they are. Other values, driver.Valuer ones included, get the default conversion of database/sql.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+	switch reflect.ValueOf(nv.Value).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// stmt is a prepared statement. Queries are only compiled when run, since chDB has no server side statements.
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1, as the placeholders are only checked when the statement is run.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, prepareValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, prepareValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(nv)
+}
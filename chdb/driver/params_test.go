@@ -0,0 +1,122 @@
+package chdbdriver
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestBindNamed(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		query    string
+		args     []driver.NamedValue
+		expected string
+	}{
+		{
+			"SELECT * FROM t WHERE id = {id:UInt64}",
+			[]driver.NamedValue{{Name: "id", Value: 5}},
+			"SELECT * FROM t WHERE id = CAST(5 AS UInt64)",
+		},
+		{
+			"SELECT {s: String}, {s:Nullable(String)}",
+			[]driver.NamedValue{{Name: "s", Value: "it's \\ here"}},
+			`SELECT CAST('it\'s \\ here' AS String), CAST('it\'s \\ here' AS Nullable(String))`,
+		},
+		{
+			"SELECT {ts:DateTime64(3)}, {ids:Array(UInt16)}, {none:Nullable(UInt8)}",
+			[]driver.NamedValue{{Name: "ts", Value: ts}, {Name: "ids", Value: []uint16{1, 2}}, {Name: "none", Value: nil}},
+			"SELECT CAST(toDateTime64('2024-01-02 03:04:05.000000000', 9, 'UTC') AS DateTime64(3)), CAST([1, 2] AS Array(UInt16)), CAST(NULL AS Nullable(UInt8))",
+		},
+		{
+			"SELECT * FROM {table:Identifier}",
+			[]driver.NamedValue{{Name: "table", Value: "my`table"}},
+			"SELECT * FROM `my\\`table`",
+		},
+		{
+			"SELECT '{id:UInt64}', `{id:UInt64}`, {id:UInt64} -- {id:UInt64}\n/* {id:UInt64} */ , {x}",
+			[]driver.NamedValue{{Name: "id", Value: 1}},
+			"SELECT '{id:UInt64}', `{id:UInt64}`, CAST(1 AS UInt64) -- {id:UInt64}\n/* {id:UInt64} */ , {x}",
+		},
+	}
+	for _, tt := range tests {
+		got, err := bindNamed(tt.query, tt.args)
+		if err != nil {
+			t.Errorf("bindNamed(%q) fail, err: %s", tt.query, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("bindNamed(%q): expected %q, got %q", tt.query, tt.expected, got)
+		}
+	}
+
+	if _, err := bindNamed("SELECT {id:UInt64}", []driver.NamedValue{{Name: "other", Value: 1}}); err == nil {
+		t.Errorf("expected an error for a missing parameter")
+	}
+	if _, err := bindNamed("SELECT {id:UInt64}", []driver.NamedValue{{Name: "id", Value: 1}, {Ordinal: 2, Value: 2}}); err == nil {
+		t.Errorf("expected an error when mixing named and positional arguments")
+	}
+	if _, err := bindNamed("SELECT * FROM {t:Identifier}", []driver.NamedValue{{Name: "t", Value: 1}}); err == nil {
+		t.Errorf("expected an error for a non string identifier")
+	}
+}
+
+func TestDbWithNamedArgs(t *testing.T) {
+	db, err := sql.Open("chdb", "")
+	if err != nil {
+		t.Fatalf("open db fail, err: %s", err)
+	}
+	defer db.Close()
+
+	var (
+		id   uint64
+		name string
+		n    uint64
+	)
+	row := db.QueryRow("SELECT {id:UInt64}, {name:String}, length({tags:Array(String)})",
+		sql.Named("id", 5), sql.Named("name", "o'brien"), sql.Named("tags", []string{"a", "b"}))
+	if err := row.Scan(&id, &name, &n); err != nil {
+		t.Fatalf("scan fail, err: %s", err)
+	}
+	if id != 5 || name != "o'brien" || n != 2 {
+		t.Errorf("expected 5, o'brien and 2, got %d, %s and %d", id, name, n)
+	}
+
+	stmt, err := db.Prepare("SELECT number FROM numbers(10) WHERE number = {n:UInt64}")
+	if err != nil {
+		t.Fatalf("prepare fail, err: %s", err)
+	}
+	defer stmt.Close()
+	for _, want := range []uint64{3, 7} {
+		var got uint64
+		if err := stmt.QueryRow(sql.Named("n", want)).Scan(&got); err != nil {
+			t.Fatalf("scan fail, err: %s", err)
+		}
+		if got != want {
+			t.Errorf("expected %d, got %d", want, got)
+		}
+	}
+}
+
+func TestCheckNamedValue(t *testing.T) {
+	c := &conn{}
+	for _, tc := range []struct {
+		value any
+		err   error
+	}{
+		{[]int{1, 2}, nil},
+		{[2]string{"a", "b"}, nil},
+		{map[string]int{"a": 1}, nil},
+		{uuid.Nil, driver.ErrSkip},
+		{sql.NullString{String: "x", Valid: true}, driver.ErrSkip},
+		{int32(1), driver.ErrSkip},
+		{"x", driver.ErrSkip},
+	} {
+		if err := c.CheckNamedValue(&driver.NamedValue{Value: tc.value}); err != tc.err {
+			t.Errorf("%T: expected %v, got %v", tc.value, tc.err, err)
+		}
+	}
+}
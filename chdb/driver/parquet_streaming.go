@@ -1,30 +1,38 @@
 package chdbdriver
 
 import (
-	"bytes"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"io"
-	"time"
-
 	"reflect"
 
 	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
 	"github.com/parquet-go/parquet-go"
 )
 
+// ErrStreamClosed is returned when reading streaming rows after they were closed, e.g. by another goroutine.
+var ErrStreamClosed = errors.New("stream is closed")
+
 type parquetStreamingRows struct {
+	streamRows
 	stream                chdbpurego.ChdbStreamResult // result from clickhouse
 	curChunk              chdbpurego.ChdbResult       // current chunk
 	reader                *parquet.GenericReader[any] // parquet reader
 	curRecord             parquet.Row
 	buffer                []parquet.Row   // record buffer
-	schemaFields          []parquet.Field // schema fields
+	schemaFields          []parquet.Field // fields reported by the rows
+	fileFields            []parquet.Field // fields of the current chunk
+	projection            []int           // indexes of schemaFields in fileFields, nil when not projected
 	bufferSize            int             // amount of records to preload into buffer
 	bufferIndex           int64           // index in the current buffer
 	curRow                int64           // row counter
 	needNewBuffer         bool
 	useUnsafeStringReader bool
+	dedup                 *deduper
+	prefetch              *prefetcher
+	rowsConfig
 }
 
 func (r *parquetStreamingRows) Columns() (out []string) {
@@ -35,20 +43,25 @@ func (r *parquetStreamingRows) Columns() (out []string) {
 	return
 }
 
+// Close releases the stream, see streamRows.close.
 func (r *parquetStreamingRows) Close() error {
-	if r.curRecord != nil {
+	return r.close(func() {
 		r.curRecord = nil
-	}
-	// ignore reader close
-	_ = r.reader.Close()
-	r.reader = nil
-	r.stream.Free()
-	r.curChunk = nil
-	r.stream = nil
-	r.schemaFields = nil
-
-	r.buffer = nil
-	return nil
+		// ignore reader close
+		_ = r.reader.Close()
+		r.reader = nil
+		if r.prefetch != nil {
+			r.prefetch.close()
+			r.prefetch = nil
+		}
+		r.stream.Free()
+		r.curChunk = nil
+		r.stream = nil
+		r.schemaFields = nil
+		r.fileFields = nil
+		r.dedup = nil
+		r.buffer = nil
+	})
 }
 
 func (r *parquetStreamingRows) readNextChunkFromBuf() error {
@@ -77,7 +90,11 @@ func (r *parquetStreamingRows) readNextChunkFromStream() error {
 	}
 	// free the previous chunk
 	r.curChunk.Free()
-	r.curChunk = r.stream.GetNext()
+	if r.prefetch != nil {
+		r.curChunk = r.prefetch.next()
+	} else {
+		r.curChunk = r.stream.GetNext()
+	}
 	if r.curChunk == nil {
 		return io.EOF
 	}
@@ -87,12 +104,50 @@ func (r *parquetStreamingRows) readNextChunkFromStream() error {
 	if r.curChunk.RowsRead() == 0 {
 		return io.EOF
 	}
-	r.reader = parquet.NewGenericReader[any](bytes.NewReader(r.curChunk.Buf()))
-	r.schemaFields = r.reader.Schema().Fields()
+	if err := checkChunk(r.curChunk.Buf()); err != nil {
+		return err
+	}
+	reader, fileFields, schemaFields, projection, err := r.openReader(r.curChunk.Buf())
+	if err != nil {
+		return err
+	}
+	r.reader, r.fileFields, r.schemaFields, r.projection = reader, fileFields, schemaFields, projection
 	return nil
 }
 
 func (r *parquetStreamingRows) Next(dest []driver.Value) error {
+	return r.read(func() error {
+		return r.nextDistinct(dest)
+	})
+}
+
+// nextDistinct reads the next row, skipping the duplicates when deduplication is enabled.
+func (r *parquetStreamingRows) nextDistinct(dest []driver.Value) error {
+	if len(r.dedupColumns) == 0 {
+		return r.next(dest)
+	}
+	if r.dedup == nil {
+		d, err := newDeduper(&r.rowsConfig, r.Columns())
+		if err != nil {
+			return err
+		}
+		r.dedup = d
+	}
+	for {
+		if err := r.next(dest); err != nil {
+			return err
+		}
+		dup, err := r.dedup.duplicate(dest)
+		if err != nil {
+			return err
+		}
+		if !dup {
+			return nil
+		}
+	}
+}
+
+func (r *parquetStreamingRows) next(dest []driver.Value) error {
 	if r.curRow == 0 && r.curChunk.RowsRead() == 0 {
 		return io.EOF //here we can simply return early since we don't need to issue a read to the file
 	}
@@ -113,72 +168,18 @@ func (r *parquetStreamingRows) Next(dest []driver.Value) error {
 	if len(r.curRecord) == 0 {
 		return fmt.Errorf("empty row")
 	}
-	var scanError error
-	r.curRecord.Range(func(columnIndex int, columnValues []parquet.Value) bool {
-		if len(columnValues) != 1 {
-			return false
-		}
-		curVal := columnValues[0]
-		if curVal.IsNull() {
-			dest[columnIndex] = nil
-			return true
-		}
-		switch r.ColumnTypeDatabaseTypeName(columnIndex) {
-		case "STRING":
-			// we check if the user has initialized the connection with the unsafeStringReader parameter, and in that case we use `getStringFromBytes` method.
-			// otherwise, we fallback to the traditional way and we allocate a new string
-			if r.useUnsafeStringReader {
-				dest[columnIndex] = getStringFromBytes(curVal)
-			} else {
-				dest[columnIndex] = string(curVal.ByteArray())
-			}
-
-		case "INT8", "INT(8,true)":
-			dest[columnIndex] = int8(curVal.Int32()) //check if this is correct
-		case "INT16", "INT(16,true)":
-			dest[columnIndex] = int16(curVal.Int32())
-		case "INT64", "INT(64,true)":
-			dest[columnIndex] = curVal.Int64()
-		case "INT(64,false)":
-			dest[columnIndex] = curVal.Uint64()
-		case "INT(32,false)":
-			dest[columnIndex] = curVal.Uint32()
-		case "INT(8,false)":
-			dest[columnIndex] = uint8(curVal.Uint32()) //check if this is correct
-		case "INT(16,false)":
-			dest[columnIndex] = uint16(curVal.Uint32())
-		case "INT32", "INT(32,true)":
-			dest[columnIndex] = curVal.Int32()
-		case "FLOAT32":
-			dest[columnIndex] = curVal.Float()
-		case "DOUBLE":
-			dest[columnIndex] = curVal.Double()
-		case "BOOLEAN":
-			dest[columnIndex] = curVal.Boolean()
-		case "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY":
-			dest[columnIndex] = curVal.ByteArray()
-		case "TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)", "TIME(isAdjustedToUTC=true,unit=MILLIS)":
-			dest[columnIndex] = time.UnixMilli(curVal.Int64()).UTC()
-		case "TIMESTAMP(isAdjustedToUTC=true,unit=MICROS)", "TIME(isAdjustedToUTC=true,unit=MICROS)":
-			dest[columnIndex] = time.UnixMicro(curVal.Int64()).UTC()
-		case "TIMESTAMP(isAdjustedToUTC=true,unit=NANOS)", "TIME(isAdjustedToUTC=true,unit=NANOS)":
-			dest[columnIndex] = time.Unix(0, curVal.Int64()).UTC()
-		case "TIMESTAMP(isAdjustedToUTC=false,unit=MILLIS)", "TIME(isAdjustedToUTC=false,unit=MILLIS)":
-			dest[columnIndex] = time.UnixMilli(curVal.Int64())
-		case "TIMESTAMP(isAdjustedToUTC=false,unit=MICROS)", "TIME(isAdjustedToUTC=false,unit=MICROS)":
-			dest[columnIndex] = time.UnixMicro(curVal.Int64())
-		case "TIMESTAMP(isAdjustedToUTC=false,unit=NANOS)", "TIME(isAdjustedToUTC=false,unit=NANOS)":
-			dest[columnIndex] = time.Unix(0, curVal.Int64())
-		default:
-			scanError = fmt.Errorf("could not cast to type: %s", r.ColumnTypeDatabaseTypeName(columnIndex))
-			return false
-
-		}
-		return true
-	})
-	if scanError != nil {
-		return scanError
+	decoder := r.decoder(r.useUnsafeStringReader)
+	decoder.Projection = r.projection
+	// scanning relies on the parquet schema rather than on the reported database type names
+	if err := decoder.Row(r.fileFields, r.curRecord, func(columnIndex int, v any) {
+		dest[columnIndex] = v
+	}); err != nil {
+		return err
+	}
+	if err := r.convertDurations(r.schemaFields, dest); err != nil {
+		return err
 	}
+	r.formatTimes(r.schemaFields, dest)
 	r.curRow++
 	r.bufferIndex++
 	r.needNewBuffer = r.bufferIndex == int64(len(r.buffer)) // if we achieved the buffer size, we need a new one
@@ -186,7 +187,7 @@ func (r *parquetStreamingRows) Next(dest []driver.Value) error {
 }
 
 func (r *parquetStreamingRows) ColumnTypeDatabaseTypeName(index int) string {
-	return r.schemaFields[index].Type().String()
+	return r.databaseTypeName(r.schemaFields[index].Name(), r.schemaFields[index].Type().String())
 }
 
 func (r *parquetStreamingRows) ColumnTypeNullable(index int) (nullable, ok bool) {
@@ -194,23 +195,70 @@ func (r *parquetStreamingRows) ColumnTypeNullable(index int) (nullable, ok bool)
 }
 
 func (r *parquetStreamingRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
-	return 0, 0, false
+	return r.precisionScale(r.schemaFields[index])
 }
 
 func (r *parquetStreamingRows) ColumnTypeScanType(index int) reflect.Type {
-	switch r.schemaFields[index].Type().Kind() {
-	case parquet.Boolean:
-		return reflect.TypeOf(false)
-	case parquet.Int32:
-		return reflect.TypeOf(int32(0))
-	case parquet.Int64:
-		return reflect.TypeOf(int64(0))
-	case parquet.Float:
-		return reflect.TypeOf(float32(0))
-	case parquet.Double:
-		return reflect.TypeOf(float64(0))
-	case parquet.ByteArray, parquet.FixedLenByteArray:
-		return reflect.TypeOf("")
-	}
+	return r.scanType(r.schemaFields[index])
+}
+
+func (r *parquetStreamingRows) ColumnTypeElementNames(index int) []string {
+	return pqconv.ElementNames(r.schemaFields[index])
+}
+
+// ColumnTypeEnumValues returns nil: Parquet results hold enums as plain integers.
+func (r *parquetStreamingRows) ColumnTypeEnumValues(index int) map[string]int16 {
 	return nil
 }
+
+// ScanBatch scans up to len(dests) rows at once. Every dests[i] holds the destinations of a row, one pointer per column,
+// following the same conversion rules as sql.Rows.Scan.
+// It returns the number of rows scanned; io.EOF is returned once the stream is exhausted, possibly along with n > 0.
+func (r *parquetStreamingRows) ScanBatch(dests [][]any) (n int, err error) {
+	values := make([]driver.Value, len(r.schemaFields))
+	for n < len(dests) {
+		if err := r.Next(values); err != nil {
+			return n, err
+		}
+		row := dests[n]
+		if len(row) != len(values) {
+			return n, fmt.Errorf("expected %d destination arguments in ScanBatch, not %d", len(values), len(row))
+		}
+		for i, v := range values {
+			if err := pqconv.Assign(row[i], v); err != nil {
+				return n, fmt.Errorf("converting column %d: %w", i, err)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// NextPage returns the next size rows, regardless of the boundaries of the chunks the stream is made of.
+// The last page may hold less rows; once the stream is exhausted, NextPage returns io.EOF.
+// With the unsafe string reader, the strings of a page are only valid until the next call.
+func (r *parquetStreamingRows) NextPage(size int) ([][]any, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid page size %d", size)
+	}
+	page := make([][]any, 0, size)
+	values := make([]driver.Value, len(r.schemaFields))
+	for len(page) < size {
+		err := r.Next(values)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make([]any, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		page = append(page, row)
+	}
+	if len(page) == 0 {
+		return nil, io.EOF
+	}
+	return page, nil
+}
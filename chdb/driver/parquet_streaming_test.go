@@ -1,9 +1,21 @@
 package chdbdriver
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"runtime"
 	"testing"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
 )
 
 func TestDbWithParquetStreaming(t *testing.T) {
@@ -138,3 +150,511 @@ func TestDBWithParquetStreamingConnection(t *testing.T) {
 		count++
 	}
 }
+
+func TestParquetStreamingColumnMetadata(t *testing.T) {
+	cn, err := Driver{}.Open(fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), "PARQUET_STREAMING"))
+	if err != nil {
+		t.Fatalf("open conn fail, err: %s", err)
+	}
+	defer cn.Close()
+	rows, err := cn.(*conn).QueryContext(context.Background(),
+		"SELECT toUInt32(1) AS id, 'abc' AS name, toNullable(toInt64(2)) AS score", nil)
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	defer rows.Close()
+	meta := rows.(*parquetStreamingRows).ColumnMetadata()
+	if len(meta) != 3 {
+		t.Fatalf("expected 3 columns, got %d", len(meta))
+	}
+	expected := []chdb.ColumnMeta{
+		{Name: "id", Type: "UInt32", ScanType: reflect.TypeOf(int32(0))},
+		{Name: "name", Type: "String", ScanType: reflect.TypeOf("")},
+		{Name: "score", Type: "Nullable(Int64)", Nullable: true, ScanType: reflect.TypeOf((*int64)(nil))},
+	}
+	for i, exp := range expected {
+		got := meta[i]
+		if got.Name != exp.Name || got.Type != exp.Type || got.Nullable != exp.Nullable || got.ScanType != exp.ScanType {
+			t.Errorf("column %d: expected %+v, got %+v", i, exp, got)
+		}
+		if got.ParquetType == "" {
+			t.Errorf("column %d: expected parquet type to be set", i)
+		}
+	}
+}
+
+func TestDbWithLowCardinality(t *testing.T) {
+	session.Query(`CREATE TABLE IF NOT EXISTS TestDbWithLowCardinality
+		(id UInt32, tag LowCardinality(String), label LowCardinality(Nullable(String)))
+		ENGINE = MergeTree() ORDER BY id;`)
+	session.Query("TRUNCATE TABLE TestDbWithLowCardinality;")
+	session.Query("INSERT INTO TestDbWithLowCardinality VALUES (1, 'a', 'x'), (2, 'b', NULL), (3, 'a', 'x'), (4, 'b', NULL);")
+
+	expected := []struct {
+		tag   string
+		label sql.NullString
+	}{
+		{"a", sql.NullString{String: "x", Valid: true}},
+		{"b", sql.NullString{}},
+		{"a", sql.NullString{String: "x", Valid: true}},
+		{"b", sql.NullString{}},
+	}
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s;unwrapLowCardinality=true", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query("SELECT tag, label FROM TestDbWithLowCardinality ORDER BY id;")
+		if err != nil {
+			t.Fatalf("run Query fail, err: %s", err)
+		}
+		count := 0
+		for rows.Next() {
+			var (
+				tag   string
+				label sql.NullString
+			)
+			if err := rows.Scan(&tag, &label); err != nil {
+				t.Fatalf("%s: scan fail, err: %s", driverType, err)
+			}
+			if tag != expected[count].tag || label != expected[count].label {
+				t.Errorf("%s: row %d expected %v %v, got %v %v", driverType, count, expected[count].tag, expected[count].label, tag, label)
+			}
+			count++
+		}
+		rows.Close()
+		if count != len(expected) {
+			t.Errorf("%s: expected %d rows, got %d", driverType, len(expected), count)
+		}
+	}
+}
+
+func TestDbWithWidenUnsigned(t *testing.T) {
+	query := "SELECT toUInt32(4000000000) AS a, toUInt64(18446744073709551615) AS b, toUInt64(5) AS c"
+	tests := []struct {
+		options  string
+		expected []any
+	}{
+		{"", []any{uint32(4000000000), uint64(18446744073709551615), uint64(5)}},
+		{";widenUnsigned=true", []any{int64(4000000000), uint64(18446744073709551615), int64(5)}},
+	}
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		for _, tt := range tests {
+			db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s%s", session.ConnStr(), driverType, tt.options))
+			if err != nil {
+				t.Fatalf("open db fail, err: %s", err)
+			}
+			got := make([]any, 3)
+			if err := db.QueryRow(query).Scan(&got[0], &got[1], &got[2]); err != nil {
+				t.Fatalf("%s%s: scan fail, err: %s", driverType, tt.options, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("%s%s: expected %#v, got %#v", driverType, tt.options, tt.expected, got)
+			}
+			var a, c int64
+			var b uint64
+			if err := db.QueryRow(query).Scan(&a, &b, &c); err != nil {
+				t.Fatalf("%s%s: scan into fixed types fail, err: %s", driverType, tt.options, err)
+			}
+			db.Close()
+		}
+	}
+}
+
+func TestDbWithBool(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query(`SELECT true AS flag, toNullable(false) AS maybe, CAST(NULL AS Nullable(Bool)) AS missing,
+			[true, false]::Array(Bool) AS flags, [true, NULL]::Array(Nullable(Bool)) AS maybes`)
+		if err != nil {
+			t.Fatalf("run Query fail, err: %s", err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("get column types fail, err: %s", err)
+		}
+		for _, i := range []int{0, 1, 2} {
+			if types[i].DatabaseTypeName() != "Bool" {
+				t.Errorf("%s: column %s expected database type Bool, got %s", driverType, types[i].Name(), types[i].DatabaseTypeName())
+			}
+		}
+		if !rows.Next() {
+			t.Fatalf("%s: expected a row", driverType)
+		}
+		var (
+			flag    bool
+			maybe   sql.NullBool
+			missing *bool
+			flags   []bool
+			maybes  []*bool
+		)
+		if err := rows.Scan(&flag, &maybe, &missing, &flags, &maybes); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		rows.Close()
+		if !flag || maybe != (sql.NullBool{Bool: false, Valid: true}) || missing != nil {
+			t.Errorf("%s: unexpected scalar values %v %v %v", driverType, flag, maybe, missing)
+		}
+		if !reflect.DeepEqual(flags, []bool{true, false}) {
+			t.Errorf("%s: expected [true false], got %v", driverType, flags)
+		}
+		if len(maybes) != 2 || maybes[0] == nil || !*maybes[0] || maybes[1] != nil {
+			t.Errorf("%s: expected [true <nil>], got %v", driverType, maybes)
+		}
+	}
+}
+
+func TestDbWithNullableElementArrays(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query(`SELECT arr, flags FROM (
+			SELECT 1 AS id, [1, NULL, 3]::Array(Nullable(Int64)) AS arr, [true]::Array(Bool) AS flags
+			UNION ALL SELECT 2, []::Array(Nullable(Int64)), []::Array(Bool)
+			UNION ALL SELECT 3, [NULL, NULL]::Array(Nullable(Int64)), [false, true]::Array(Bool)
+		) ORDER BY id`)
+		if err != nil {
+			t.Fatalf("run Query fail, err: %s", err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("get column types fail, err: %s", err)
+		}
+		if types[0].ScanType() != reflect.TypeOf([]*int64{}) || types[1].ScanType() != reflect.TypeOf([]bool{}) {
+			t.Errorf("%s: expected []*int64 and []bool scan types, got %v and %v", driverType, types[0].ScanType(), types[1].ScanType())
+		}
+		var (
+			arrs  [][]*int64
+			flags [][]bool
+		)
+		for rows.Next() {
+			var (
+				arr  []*int64
+				flag []bool
+			)
+			if err := rows.Scan(&arr, &flag); err != nil {
+				t.Fatalf("%s: scan fail, err: %s", driverType, err)
+			}
+			arrs = append(arrs, arr)
+			flags = append(flags, flag)
+		}
+		rows.Close()
+		db.Close()
+		if len(arrs) != 3 {
+			t.Fatalf("%s: expected 3 rows, got %d", driverType, len(arrs))
+		}
+		if len(arrs[0]) != 3 || *arrs[0][0] != 1 || arrs[0][1] != nil || *arrs[0][2] != 3 {
+			t.Errorf("%s: expected [1 <nil> 3], got %v", driverType, arrs[0])
+		}
+		// an empty array is not NULL, and neither is an array of NULL elements
+		if arrs[1] == nil || len(arrs[1]) != 0 {
+			t.Errorf("%s: expected an empty non nil array, got %#v", driverType, arrs[1])
+		}
+		if len(arrs[2]) != 2 || arrs[2][0] != nil || arrs[2][1] != nil {
+			t.Errorf("%s: expected [<nil> <nil>], got %v", driverType, arrs[2])
+		}
+		if !reflect.DeepEqual(flags, [][]bool{{true}, {}, {false, true}}) {
+			t.Errorf("%s: expected [[true] [] [false true]], got %v", driverType, flags)
+		}
+	}
+}
+
+func TestDbWithColumnConverter(t *testing.T) {
+	type permissions struct{ Read, Write bool }
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		connector, err := NewConnector(fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType),
+			WithColumnConverter("flags", func(v parquet.Value) (any, error) {
+				bits := v.Uint32()
+				return permissions{Read: bits&1 != 0, Write: bits&2 != 0}, nil
+			}))
+		if err != nil {
+			t.Fatalf("create connector fail, err: %s", err)
+		}
+		db := sql.OpenDB(connector)
+		var (
+			flags permissions
+			other uint32
+			name  string
+		)
+		row := db.QueryRow("SELECT toUInt8(2) AS flags, toUInt32(2) AS other, 'x' AS name")
+		if err := row.Scan(&flags, &other, &name); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if flags != (permissions{Write: true}) {
+			t.Errorf("%s: expected the converter to be used, got %+v", driverType, flags)
+		}
+		if other != 2 || name != "x" {
+			t.Errorf("%s: expected other columns to decode normally, got %d %s", driverType, other, name)
+		}
+		db.Close()
+	}
+}
+
+func TestDbWithResultCopy(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		connector, err := NewConnector(fmt.Sprintf("session=%s;driverType=%s;useUnsafeStringReader=true;bufferSize=10", session.ConnStr(), driverType),
+			WithResultCopy())
+		if err != nil {
+			t.Fatalf("create connector fail, err: %s", err)
+		}
+		db := sql.OpenDB(connector)
+		rows, err := db.Query("SELECT concat('value-', toString(number)) AS s FROM numbers(1000)")
+		if err != nil {
+			t.Fatalf("run Query fail, err: %s", err)
+		}
+		var retained []any
+		for rows.Next() {
+			var v any
+			if err := rows.Scan(&v); err != nil {
+				t.Fatalf("%s: scan fail, err: %s", driverType, err)
+			}
+			retained = append(retained, v)
+		}
+		rows.Close()
+		db.Close()
+
+		// put pressure on the allocator, so that released buffers are reused
+		for i := 0; i < 10; i++ {
+			garbage := make([][]byte, 1000)
+			for j := range garbage {
+				garbage[j] = bytes.Repeat([]byte{'#'}, 64)
+			}
+			runtime.GC()
+		}
+		for i, v := range retained {
+			if expected := fmt.Sprintf("value-%d", i); fmt.Sprint(v) != expected {
+				t.Fatalf("%s: retained value %d changed after close, expected %s, got %v", driverType, i, expected, v)
+			}
+		}
+	}
+}
+
+func TestDbNullableScanType(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		rows, err := db.Query("SELECT toInt64(1) AS plain, toNullable(toInt64(2)) AS maybe")
+		if err != nil {
+			t.Fatalf("run Query fail, err: %s", err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("get column types fail, err: %s", err)
+		}
+		expected := []struct {
+			scanType reflect.Type
+			nullable bool
+		}{
+			{reflect.TypeOf(int64(0)), false},
+			{reflect.TypeOf((*int64)(nil)), true},
+		}
+		for i, exp := range expected {
+			nullable, ok := types[i].Nullable()
+			if !ok || nullable != exp.nullable || types[i].ScanType() != exp.scanType {
+				t.Errorf("%s: column %s expected scan type %v nullable %v, got %v %v", driverType, types[i].Name(), exp.scanType, exp.nullable, types[i].ScanType(), nullable)
+			}
+		}
+		rows.Close()
+	}
+}
+
+func TestDbWithGeoTypes(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		var (
+			point   [2]float64
+			polygon [][][2]float64
+		)
+		row := db.QueryRow("SELECT (1.5, 2.5)::Point AS p, [[(0, 0), (10, 0), (10, 10), (0, 0)]]::Polygon AS poly")
+		if err := row.Scan(&point, &polygon); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if point != [2]float64{1.5, 2.5} {
+			t.Errorf("%s: expected point (1.5, 2.5), got %v", driverType, point)
+		}
+		expected := [][][2]float64{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}}
+		if !reflect.DeepEqual(polygon, expected) {
+			t.Errorf("%s: expected polygon %v, got %v", driverType, expected, polygon)
+		}
+	}
+}
+
+const scanBatchQuery = "SELECT number AS id, toString(number) AS name FROM numbers(10000) SETTINGS max_block_size = 1000"
+
+func openStreamingRows(tb testing.TB, query string) *parquetStreamingRows {
+	tb.Helper()
+	cn, err := Driver{}.Open(fmt.Sprintf("session=%s;driverType=%s", session.ConnStr(), "PARQUET_STREAMING"))
+	if err != nil {
+		tb.Fatalf("open conn fail, err: %s", err)
+	}
+	rows, err := cn.(*conn).QueryContext(context.Background(), query, nil)
+	if err != nil {
+		tb.Fatalf("run Query fail, err: %s", err)
+	}
+	return rows.(*parquetStreamingRows)
+}
+
+func TestParquetStreamingScanBatch(t *testing.T) {
+	single := openStreamingRows(t, scanBatchQuery)
+	defer single.Close()
+	var expected [][2]any
+	values := make([]driver.Value, 2)
+	for single.Next(values) == nil {
+		expected = append(expected, [2]any{values[0], values[1]})
+	}
+
+	batch := openStreamingRows(t, scanBatchQuery)
+	defer batch.Close()
+	var (
+		got   [][2]any
+		ids   = make([]uint64, 64)
+		names = make([]string, 64)
+		dests = make([][]any, 64)
+	)
+	for i := range dests {
+		dests[i] = []any{&ids[i], &names[i]}
+	}
+	for {
+		n, err := batch.ScanBatch(dests)
+		for i := 0; i < n; i++ {
+			got = append(got, [2]any{ids[i], names[i]})
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ScanBatch fail, err: %s", err)
+		}
+	}
+	if len(got) != 10000 || !reflect.DeepEqual(got, expected) {
+		t.Fatalf("batch scanning returned %d rows not matching the %d rows of single row scanning", len(got), len(expected))
+	}
+}
+
+func BenchmarkParquetStreamingScanSingle(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		rows := openStreamingRows(b, scanBatchQuery)
+		var (
+			id   uint64
+			name string
+		)
+		values := make([]driver.Value, 2)
+		for rows.Next(values) == nil {
+			if err := pqconv.Assign(&id, values[0]); err != nil {
+				b.Fatal(err)
+			}
+			if err := pqconv.Assign(&name, values[1]); err != nil {
+				b.Fatal(err)
+			}
+		}
+		rows.Close()
+	}
+}
+
+func BenchmarkParquetStreamingScanBatch(b *testing.B) {
+	ids := make([]uint64, 256)
+	names := make([]string, 256)
+	dests := make([][]any, 256)
+	for i := range dests {
+		dests[i] = []any{&ids[i], &names[i]}
+	}
+	for i := 0; i < b.N; i++ {
+		rows := openStreamingRows(b, scanBatchQuery)
+		for {
+			if _, err := rows.ScanBatch(dests); err != nil {
+				break
+			}
+		}
+		rows.Close()
+	}
+}
+
+func TestParquetStreamingNextPage(t *testing.T) {
+	// 100 rows per native chunk, pages of 70 rows straddle the chunk boundaries
+	rows := openStreamingRows(t, "SELECT number FROM numbers(1000) SETTINGS max_block_size = 100")
+	defer rows.Close()
+	var (
+		sizes []int
+		next  uint64
+	)
+	for {
+		page, err := rows.NextPage(70)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPage fail, err: %s", err)
+		}
+		sizes = append(sizes, len(page))
+		for _, row := range page {
+			if row[0] != next {
+				t.Fatalf("expected row %d, got %v", next, row[0])
+			}
+			next++
+		}
+	}
+	if next != 1000 {
+		t.Errorf("expected 1000 rows, got %d", next)
+	}
+	expected := []int{70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 20}
+	if !reflect.DeepEqual(sizes, expected) {
+		t.Errorf("expected page sizes %v, got %v", expected, sizes)
+	}
+}
+
+// endlessStream is a fake stream serving the same chunk forever.
+type endlessStream struct {
+	chunk []byte
+}
+
+func (s *endlessStream) GetNext() chdbpurego.ChdbResult { return &chunkResult{buf: s.chunk} }
+func (s *endlessStream) Error() error                   { return nil }
+func (s *endlessStream) Cancel()                        {}
+func (s *endlessStream) Free()                          {}
+
+func TestParquetStreamingConcurrentClose(t *testing.T) {
+	result, err := session.Query("SELECT number, toString(number) FROM numbers(10)", "Parquet")
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	for _, depth := range []int{0, 2} {
+		rows, err := PARQUET_STREAMING.PrepareStreamingRows(&endlessStream{chunk: result.Buf()}, 3, false, WithPrefetchDepth(depth))
+		if err != nil {
+			t.Fatalf("prepare rows fail, err: %s", err)
+		}
+		started := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			values := make([]driver.Value, 2)
+			for i := 0; ; i++ {
+				if err := rows.Next(values); err != nil {
+					done <- err
+					return
+				}
+				if i == 0 {
+					close(started)
+				}
+			}
+		}()
+		<-started
+		if err := rows.Close(); err != nil {
+			t.Fatalf("depth %d: close fail, err: %s", depth, err)
+		}
+		if err := <-done; !errors.Is(err, ErrStreamClosed) {
+			t.Errorf("depth %d: expected ErrStreamClosed, got %v", depth, err)
+		}
+		if err := rows.Close(); err != nil {
+			t.Errorf("depth %d: expected a second close to be a no-op, got %s", depth, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package chdbdriver
 import (
 	"database/sql"
 	"fmt"
+	"math/big"
 	"testing"
 )
 
@@ -138,3 +139,49 @@ func TestDBWithParquetConnection(t *testing.T) {
 		count++
 	}
 }
+
+func TestDbWithParquetDecimal(t *testing.T) {
+	for _, driverType := range []string{"PARQUET", "PARQUET_STREAMING"} {
+		db, err := sql.Open("chdb", fmt.Sprintf("driverType=%s", driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		defer db.Close()
+
+		rows, err := db.Query(`SELECT toDecimal32(-12.34, 2) AS d32, toDecimal64(123456.789, 3) AS d64,
+			toDecimal128('-1.2345678901234567890123', 22) AS d128, toDecimal256('3.5', 40) AS d256`)
+		if err != nil {
+			t.Fatalf("%s: run Query fail, err: %s", driverType, err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("%s: get column types fail, err: %s", driverType, err)
+		}
+		for i, expected := range [][2]int64{{9, 2}, {18, 3}, {38, 22}, {76, 40}} {
+			if p, s, ok := types[i].DecimalSize(); !ok || p != expected[0] || s != expected[1] {
+				t.Errorf("%s: column %s: expected precision %d and scale %d, got %d, %d, %t", driverType, types[i].Name(), expected[0], expected[1], p, s, ok)
+			}
+		}
+		var d32, d64, d128, d256 *big.Rat
+		if !rows.Next() {
+			t.Fatalf("%s: expected a row, err: %v", driverType, rows.Err())
+		}
+		if err := rows.Scan(&d32, &d64, &d128, &d256); err != nil {
+			t.Fatalf("%s: scan fail, err: %s", driverType, err)
+		}
+		if d32.FloatString(2) != "-12.34" || d64.FloatString(3) != "123456.789" || d128.FloatString(22) != "-1.2345678901234567890123" || d256.FloatString(1) != "3.5" {
+			t.Errorf("%s: unexpected decimals %s, %s, %s, %s", driverType, d32, d64, d128, d256)
+		}
+		rows.Close()
+
+		db, err = sql.Open("chdb", fmt.Sprintf("driverType=%s;decimalStrings=true", driverType))
+		if err != nil {
+			t.Fatalf("open db fail, err: %s", err)
+		}
+		defer db.Close()
+		var s string
+		if err := db.QueryRow("SELECT toDecimal32(-12.34, 2)").Scan(&s); err != nil || s != "-12.34" {
+			t.Errorf("%s: expected -12.34, got %q, err: %v", driverType, s, err)
+		}
+	}
+}
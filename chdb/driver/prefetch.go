@@ -0,0 +1,112 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// WithPrefetchDepth makes streaming rows load the next chunks of the stream in the background while the current one
+// is scanned. At most depth chunks are queued: when the consumer is slower than the stream, the background loading
+// blocks until queued chunks are consumed, so that memory stays bounded. Prefetching is disabled when depth is 0.
+// It only applies to the Parquet driver types.
+func WithPrefetchDepth(depth int) RowsOption {
+	return func(c *rowsConfig) {
+		c.prefetchDepth = depth
+	}
+}
+
+// prefetcher loads the chunks of a stream in a background goroutine into a bounded queue. The stream frees its
+// current chunk on the next call to GetNext, so the queued chunks are copied into Go memory, see ownedChunk.
+type prefetcher struct {
+	chunks chan chdbpurego.ChdbResult
+	done   chan struct{}
+}
+
+func newPrefetcher(stream chdbpurego.ChdbStreamResult, depth int) *prefetcher {
+	p := &prefetcher{
+		chunks: make(chan chdbpurego.ChdbResult, depth),
+		done:   make(chan struct{}),
+	}
+	go p.run(stream)
+	return p
+}
+
+func (p *prefetcher) run(stream chdbpurego.ChdbStreamResult) {
+	defer close(p.chunks)
+	for {
+		next := stream.GetNext()
+		if next == nil {
+			return
+		}
+		chunk := ownChunk(next)
+		select {
+		case p.chunks <- chunk:
+		case <-p.done:
+			chunk.Free()
+			return
+		}
+		// the consumer stops at the first failed or empty chunk, so there is nothing left to load
+		if chunk.Error() != nil || chunk.RowsRead() == 0 {
+			return
+		}
+	}
+}
+
+// next returns the next chunk of the stream, or nil once the stream is exhausted.
+func (p *prefetcher) next() chdbpurego.ChdbResult {
+	chunk, ok := <-p.chunks
+	if !ok {
+		return nil
+	}
+	return chunk
+}
+
+// close stops the background loading and frees the queued chunks. It waits for the loading goroutine
+// to exit, so that the stream can be freed afterwards.
+func (p *prefetcher) close() {
+	close(p.done)
+	for chunk := range p.chunks {
+		chunk.Free()
+	}
+}
+
+// ownedChunk is a chunk of a stream copied into Go memory, so that it outlives the next call to GetNext, which frees
+// the chunks of the native streams.
+type ownedChunk struct {
+	buf       []byte
+	err       error
+	elapsed   float64
+	rowsRead  uint64
+	bytesRead uint64
+}
+
+// ownChunk copies chunk, with its error and its statistics, and frees it.
+func ownChunk(chunk chdbpurego.ChdbResult) *ownedChunk {
+	defer chunk.Free()
+	return &ownedChunk{
+		buf:       bytes.Clone(chunk.Buf()),
+		err:       chunk.Error(),
+		elapsed:   chunk.Elapsed(),
+		rowsRead:  chunk.RowsRead(),
+		bytesRead: chunk.BytesRead(),
+	}
+}
+
+func (c *ownedChunk) Buf() []byte       { return c.buf }
+func (c *ownedChunk) String() string    { return string(c.buf) }
+func (c *ownedChunk) Len() int          { return len(c.buf) }
+func (c *ownedChunk) Elapsed() float64  { return c.elapsed }
+func (c *ownedChunk) RowsRead() uint64  { return c.rowsRead }
+func (c *ownedChunk) BytesRead() uint64 { return c.bytesRead }
+func (c *ownedChunk) Error() error      { return c.err }
+func (c *ownedChunk) Free()             {}
+
+func (c *ownedChunk) Stats() chdbpurego.QueryStats {
+	return chdbpurego.QueryStats{
+		Elapsed:   time.Duration(c.elapsed * float64(time.Second)),
+		RowsRead:  c.rowsRead,
+		BytesRead: c.bytesRead,
+	}
+}
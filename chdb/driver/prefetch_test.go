@@ -0,0 +1,180 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/parquet-go/parquet-go"
+)
+
+// countingStream is a fake stream producing parquet chunks on demand, tracking how many are alive at once.
+type countingStream struct {
+	mu        sync.Mutex
+	remaining int
+	chunk     []byte
+	live      int
+	maxLive   int
+}
+
+type countedChunk struct {
+	chunkResult
+	stream *countingStream
+	rows   uint64
+}
+
+func (c *countedChunk) RowsRead() uint64 { return c.rows }
+
+func (c *countedChunk) Free() {
+	c.stream.mu.Lock()
+	defer c.stream.mu.Unlock()
+	c.stream.live--
+}
+
+func (s *countingStream) GetNext() chdbpurego.ChdbResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live++
+	if s.live > s.maxLive {
+		s.maxLive = s.live
+	}
+	if s.remaining == 0 {
+		return &countedChunk{stream: s}
+	}
+	s.remaining--
+	return &countedChunk{chunkResult: chunkResult{buf: s.chunk}, stream: s, rows: 10}
+}
+func (s *countingStream) Error() error { return nil }
+func (s *countingStream) Cancel()      {}
+func (s *countingStream) Free()        {}
+
+func TestPrefetchBoundedWithSlowConsumer(t *testing.T) {
+	type row struct {
+		ID int64 `parquet:"id"`
+	}
+	var buf bytes.Buffer
+	rowsPerChunk := make([]row, 10)
+	for i := range rowsPerChunk {
+		rowsPerChunk[i].ID = int64(i)
+	}
+	if err := parquet.Write(&buf, rowsPerChunk); err != nil {
+		t.Fatal(err)
+	}
+
+	const depth, chunks = 2, 50
+	stream := &countingStream{remaining: chunks, chunk: buf.Bytes()}
+	rows, err := PARQUET_STREAMING.PrepareStreamingRows(stream, 5, false, WithPrefetchDepth(depth))
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+
+	values := make([]driver.Value, 1)
+	count := 0
+	for {
+		err := rows.Next(values)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next fail, err: %s", err)
+		}
+		count++
+		// slow consumer: the stream is much faster, and has to wait for the queue to drain
+		time.Sleep(100 * time.Microsecond)
+	}
+	if count != chunks*10 {
+		t.Errorf("expected %d rows, got %d", chunks*10, count)
+	}
+	// the chunk being read, the queued ones and the one waiting to be queued
+	if stream.maxLive > depth+2 {
+		t.Errorf("expected at most %d chunks alive at once, got %d", depth+2, stream.maxLive)
+	}
+}
+
+// freeingStream is a fake stream which frees its current chunk on the next call to GetNext, like the native
+// streams do.
+type freeingStream struct {
+	chunks  [][]byte
+	current *freedChunk
+}
+
+// freedChunk is a chunk of a freeingStream, whose buffer is overwritten and whose rows are dropped once freed.
+type freedChunk struct {
+	chunkResult
+	freed bool
+}
+
+func (c *freedChunk) RowsRead() uint64 {
+	if c.freed {
+		return 0
+	}
+	return 1
+}
+
+func (c *freedChunk) Free() {
+	if !c.freed {
+		c.freed = true
+		for i := range c.buf {
+			c.buf[i] = 0
+		}
+		c.buf = nil
+	}
+}
+
+func (s *freeingStream) GetNext() chdbpurego.ChdbResult {
+	if s.current != nil {
+		s.current.Free()
+	}
+	if len(s.chunks) == 0 {
+		s.current = nil
+		return nil
+	}
+	s.current = &freedChunk{chunkResult: chunkResult{buf: bytes.Clone(s.chunks[0])}}
+	s.chunks = s.chunks[1:]
+	return s.current
+}
+func (s *freeingStream) Error() error { return nil }
+func (s *freeingStream) Cancel()      {}
+func (s *freeingStream) Free()        {}
+
+func TestPrefetchFreedChunks(t *testing.T) {
+	type row struct {
+		ID int64 `parquet:"id"`
+	}
+	var chunks [][]byte
+	for c := 0; c < 5; c++ {
+		var buf bytes.Buffer
+		rows := make([]row, 10)
+		for i := range rows {
+			rows[i].ID = int64(c*10 + i)
+		}
+		if err := parquet.Write(&buf, rows); err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, buf.Bytes())
+	}
+	rows, err := PARQUET_STREAMING.PrepareStreamingRows(&freeingStream{chunks: chunks}, 3, false, WithPrefetchDepth(3))
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	// let the prefetcher fetch ahead, freeing the chunks it already returned
+	time.Sleep(10 * time.Millisecond)
+	values := make([]driver.Value, 1)
+	for i := int64(0); i < 50; i++ {
+		if err := rows.Next(values); err != nil {
+			t.Fatalf("row %d: Next fail, err: %s", i, err)
+		}
+		if values[0] != i {
+			t.Fatalf("expected row %d, got %v", i, values[0])
+		}
+	}
+	if err := rows.Next(values); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
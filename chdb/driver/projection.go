@@ -0,0 +1,47 @@
+package chdbdriver
+
+import (
+	"bytes"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// WithProjection restricts the rows to the given columns of the result, in the given order, so that the values of
+// the other columns are never decoded, and with the Parquet driver types, their pages never read. It is meant for wide results when the query itself can't be narrowed;
+// preparing the rows fails when a column is not part of the result.
+func WithProjection(columns []string) RowsOption {
+	return func(c *rowsConfig) {
+		c.projection = append([]string(nil), columns...)
+	}
+}
+
+// openReader opens the Parquet reader of a chunk. It returns the fields the reader reads, the fields reported by the
+// rows and the decoder projection of the ones into the others. Without WithProjection, the reader reads all the
+// fields, which are all reported, and the projection is nil. With it, the reader is given a schema holding only the
+// projected fields, so that the pages of the other columns are never read.
+func (c *rowsConfig) openReader(buf []byte) (reader *parquet.GenericReader[any], fileFields, schemaFields []parquet.Field, projection []int, err error) {
+	reader = parquet.NewGenericReader[any](bytes.NewReader(buf))
+	if c.projection == nil {
+		fields := reader.Schema().Fields()
+		return reader, fields, fields, nil, nil
+	}
+	projected, _, err := pqconv.Project(reader.Schema().Fields(), c.projection)
+	name := reader.Schema().Name()
+	reader.Close()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	group := parquet.Group{}
+	for _, f := range projected {
+		group[f.Name()] = f
+	}
+	// the fields of a group are sorted by name, the decoder projection puts them back in the requested order
+	reader = parquet.NewGenericReader[any](bytes.NewReader(buf), parquet.NewSchema(name, group))
+	fileFields = reader.Schema().Fields()
+	if schemaFields, projection, err = pqconv.Project(fileFields, c.projection); err != nil {
+		reader.Close()
+		return nil, nil, nil, nil, err
+	}
+	return reader, fileFields, schemaFields, projection, nil
+}
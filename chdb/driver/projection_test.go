@@ -0,0 +1,120 @@
+package chdbdriver
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestProjection(t *testing.T) {
+	result, err := session.Query("SELECT number AS id, toString(number) AS name, number * 2 AS twice FROM numbers(3)", "Parquet")
+	if err != nil {
+		t.Fatalf("run Query fail, err: %s", err)
+	}
+	prepare := map[string]func(opts ...RowsOption) (driver.Rows, error){
+		"PARQUET": func(opts ...RowsOption) (driver.Rows, error) {
+			return PARQUET.PrepareRows(&chunkResult{buf: result.Buf()}, result.Buf(), defaultBufferSize, false, opts...)
+		},
+		"PARQUET_STREAMING": func(opts ...RowsOption) (driver.Rows, error) {
+			stream := &chunkStream{chunks: []*chunkResult{{buf: result.Buf()}, {buf: result.Buf()}}}
+			return PARQUET_STREAMING.PrepareStreamingRows(stream, defaultBufferSize, false, opts...)
+		},
+	}
+	for driverType, open := range prepare {
+		rows, err := open(WithProjection([]string{"twice", "id"}))
+		if err != nil {
+			t.Fatalf("%s: prepare rows fail, err: %s", driverType, err)
+		}
+		if !reflect.DeepEqual(rows.Columns(), []string{"twice", "id"}) {
+			t.Errorf("%s: expected the projected columns, got %v", driverType, rows.Columns())
+		}
+		values := make([]driver.Value, 2)
+		var got [][]driver.Value
+		for {
+			if err := rows.Next(values); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: Next fail, err: %s", driverType, err)
+			}
+			got = append(got, []driver.Value{values[0], values[1]})
+		}
+		rows.Close()
+		if len(got) < 3 || !reflect.DeepEqual(got[:3], [][]driver.Value{{uint64(0), uint64(0)}, {uint64(2), uint64(1)}, {uint64(4), uint64(2)}}) {
+			t.Errorf("%s: unexpected projected rows %v", driverType, got)
+		}
+
+		if _, err := open(WithProjection([]string{"id", "missing"})); err == nil {
+			t.Errorf("%s: expected an error for a missing column", driverType)
+		}
+	}
+}
+
+func BenchmarkProjection(b *testing.B) {
+	columns := make([]string, 50)
+	for i := range columns {
+		columns[i] = fmt.Sprintf("repeat(toString(number), 10) AS c%d", i)
+	}
+	result, err := session.Query("SELECT number AS id, "+strings.Join(columns, ", ")+" FROM numbers(10000)", "Parquet")
+	if err != nil {
+		b.Fatalf("run Query fail, err: %s", err)
+	}
+	buf := result.Buf()
+	for _, bm := range []struct {
+		name string
+		opts []RowsOption
+	}{
+		{"All", nil},
+		{"Projected", []RowsOption{WithProjection([]string{"id", "c0"})}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rows, err := PARQUET.PrepareRows(&chunkResult{buf: buf}, buf, defaultBufferSize, false, bm.opts...)
+				if err != nil {
+					b.Fatalf("prepare rows fail, err: %s", err)
+				}
+				values := make([]driver.Value, len(rows.Columns()))
+				for rows.Next(values) == nil {
+				}
+				rows.Close()
+			}
+		})
+	}
+}
+
+func TestProjectionSchema(t *testing.T) {
+	type row struct {
+		ID    uint64 `parquet:"id"`
+		Name  string `parquet:"name"`
+		Twice uint64 `parquet:"twice"`
+	}
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []row{{0, "a", 0}, {1, "b", 2}}); err != nil {
+		t.Fatalf("write parquet fail, err: %s", err)
+	}
+	rows, err := PARQUET.PrepareRows(&chunkResult{buf: buf.Bytes()}, buf.Bytes(), defaultBufferSize, false, WithProjection([]string{"twice", "id"}))
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	var read []string
+	for _, f := range rows.(*parquetRows).reader.Schema().Fields() {
+		read = append(read, f.Name())
+	}
+	if !reflect.DeepEqual(read, []string{"id", "twice"}) {
+		t.Errorf("expected the reader to only read the projected columns, got %v", read)
+	}
+	values := make([]driver.Value, 2)
+	var got [][]driver.Value
+	for rows.Next(values) == nil {
+		got = append(got, []driver.Value{values[0], values[1]})
+	}
+	if !reflect.DeepEqual(got, [][]driver.Value{{uint64(0), uint64(0)}, {uint64(2), uint64(1)}}) {
+		t.Errorf("unexpected projected rows %v", got)
+	}
+}
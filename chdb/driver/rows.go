@@ -0,0 +1,98 @@
+package chdbdriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+)
+
+// streamRows is the base embedded in the rows of every driver type, which read their result, a single buffer or the
+// chunks of a stream, as Next is called. It holds the statistics and the context of the query, and serializes Close
+// with the reads of Next.
+type streamRows struct {
+	*queryStats // statistics of the query, see RowsStats
+	// mu serializes Close with the reads of Next, so that a concurrent Close doesn't release the stream under it.
+	mu         sync.Mutex
+	closed     bool
+	ctx        context.Context          // context of the query, nil when it can't be cancelled
+	stopCancel func() bool              // stops interrupting the stream when ctx is done
+	columns    func() []chdb.ColumnMeta // metadata of the reported columns, see ColumnMetadata
+}
+
+// streamBase returns the base of the rows, through which the statistics and the context of the query are set.
+func (s *streamRows) streamBase() *streamRows {
+	return s
+}
+
+// read reads the next row with next. It fails with ErrStreamClosed once the rows are closed, and with the error of
+// the context of the query once it is done.
+func (s *streamRows) read(next func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return s.ctx.Err()
+	}
+	if err := next(); err != nil {
+		// a cancelled stream looks exhausted, report why it ended
+		if s.ctx != nil && s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// close releases the result with release the first time it is called, after the query stopped being interrupted
+// by its context. It is safe to call it more than once, and concurrently with read, which then returns
+// ErrStreamClosed.
+func (s *streamRows) close(release func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.stopCancel != nil {
+		s.stopCancel()
+	}
+	release()
+	return nil
+}
+
+// ColumnMetadata returns the metadata of all the result columns.
+// It is available before the first call to Next.
+func (s *streamRows) ColumnMetadata() []chdb.ColumnMeta {
+	if s.columns == nil {
+		return nil
+	}
+	return s.columns()
+}
+
+// typedColumns returns the metadata of columns whose ClickHouse types are recorded by the result, as Native and
+// text results do. ParquetType is left empty.
+func typedColumns(names, types []string, scanType func(index int) reflect.Type) []chdb.ColumnMeta {
+	out := make([]chdb.ColumnMeta, len(names))
+	for i, name := range names {
+		out[i] = chdb.ColumnMeta{
+			Name:         name,
+			Type:         types[i],
+			Nullable:     native.Nullable(types[i]),
+			ScanType:     scanType(i),
+			ElementNames: native.ElementNames(types[i]),
+		}
+	}
+	return out
+}
+
+// streamBaser is implemented by the rows embedding streamRows.
+type streamBaser interface {
+	driver.Rows
+	streamBase() *streamRows
+}
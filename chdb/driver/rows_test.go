@@ -0,0 +1,57 @@
+package chdbdriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStreamRows(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped, released := 0, 0
+	s := &streamRows{ctx: ctx, stopCancel: func() bool { stopped++; return true }}
+	if err := s.read(func() error { return nil }); err != nil {
+		t.Errorf("expected a row, got %v", err)
+	}
+	// a cancelled stream looks exhausted
+	if err := s.read(func() error { cancel(); return io.EOF }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if err := s.read(func() error { t.Fatal("unexpected read of a cancelled query"); return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.close(func() { released++ }); err != nil {
+			t.Errorf("close fail, err: %s", err)
+		}
+	}
+	if stopped != 1 || released != 1 {
+		t.Errorf("expected the rows to be released once, got %d stops and %d releases", stopped, released)
+	}
+	if err := s.read(func() error { return nil }); err != ErrStreamClosed {
+		t.Errorf("expected ErrStreamClosed, got %v", err)
+	}
+}
+
+func TestTextRowsColumnMetadata(t *testing.T) {
+	data := "id\tname\nUInt64\tNullable(String)\n1\t\\N\n"
+	rows, err := TSV.PrepareRows(&chunkResult{buf: []byte(data)}, []byte(data), defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	meta := rows.(*textRows).ColumnMetadata()
+	if len(meta) != 2 || meta[0].Name != "id" || meta[0].Type != "UInt64" || meta[0].Nullable || meta[0].ScanType != reflect.TypeOf("") {
+		t.Errorf("unexpected metadata of id %+v", meta)
+	}
+	if len(meta) == 2 && (meta[1].Type != "Nullable(String)" || !meta[1].Nullable || meta[1].ScanType != reflect.TypeOf((*string)(nil))) {
+		t.Errorf("unexpected metadata of name %+v", meta[1])
+	}
+	values := make([]driver.Value, 2)
+	if err := rows.Next(values); err != nil || values[0] != "1" || values[1] != nil {
+		t.Errorf("expected 1 and NULL, got %v, err: %v", values, err)
+	}
+}
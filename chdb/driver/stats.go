@@ -0,0 +1,74 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// RowsStats is implemented by the rows of every driver type. database/sql doesn't expose the driver.Rows of its
+// sql.Rows, so they are reached by running the query on the driver connection within sql.Conn.Raw:
+//
+//	err := conn.Raw(func(dc any) error {
+//		rows, err := dc.(driver.QueryerContext).QueryContext(ctx, query, nil)
+//		if err != nil {
+//			return err
+//		}
+//		defer rows.Close()
+//		// read the rows with Next, then
+//		stats := rows.(chdbdriver.RowsStats).Stats()
+//		...
+//	})
+//
+// Stats returns the statistics chDB reports for the query: the elapsed time and the rows and bytes read. The
+// statistics of streamed results accumulate as their chunks are read, and are complete once Next returns io.EOF.
+type RowsStats interface {
+	driver.Rows
+	Stats() chdb.QueryStats
+}
+
+// queryStats holds the statistics of the result of a query. It is embedded in streamRows to implement RowsStats.
+// It is safe for concurrent use, since prefetched chunks are counted from another goroutine.
+type queryStats struct {
+	mu    sync.Mutex
+	stats chdb.QueryStats
+	start time.Time // start of a streamed query
+}
+
+// Stats implements RowsStats.
+func (q *queryStats) Stats() chdb.QueryStats {
+	if q == nil {
+		return chdb.QueryStats{}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
+
+// statsStream accumulates the statistics of the chunks of a stream as they are fetched.
+type statsStream struct {
+	chdbpurego.ChdbStreamResult
+	stats *queryStats
+}
+
+func (s *statsStream) GetNext() chdbpurego.ChdbResult {
+	chunk := s.ChdbStreamResult.GetNext()
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+	if chunk != nil {
+		s.stats.stats.RowsRead += chunk.RowsRead()
+		s.stats.stats.BytesRead += chunk.BytesRead()
+	}
+	s.stats.stats.Elapsed = time.Since(s.stats.start)
+	return chunk
+}
+
+// setStats attaches the statistics of the query to rows.
+func setStats(rows driver.Rows, stats *queryStats) {
+	if r, ok := rows.(streamBaser); ok {
+		r.streamBase().queryStats = stats
+	}
+}
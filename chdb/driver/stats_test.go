@@ -0,0 +1,38 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"io"
+	"testing"
+)
+
+func TestRowsStats(t *testing.T) {
+	data := "id\nUInt64\n1\n2\n3\n"
+	rows, err := TSV.PrepareRows(&chunkResult{buf: []byte(data)}, []byte(data), defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	if stats := rows.(RowsStats).Stats(); stats.RowsRead != 1 || stats.BytesRead != uint64(len(data)) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// the statistics of a stream grow as its chunks are read
+	stream := splitChunks(data, 4)
+	chunks := uint64(len(stream.chunks))
+	rows, err = TSV.PrepareStreamingRows(stream, defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	defer rows.Close()
+	values := make([]driver.Value, 1)
+	for {
+		if err := rows.Next(values); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("next fail, err: %s", err)
+		}
+	}
+	if stats := rows.(RowsStats).Stats(); stats.RowsRead != chunks || stats.BytesRead != uint64(len(data)) {
+		t.Errorf("expected %d rows and %d bytes read, got %+v", chunks, len(data), stats)
+	}
+}
@@ -0,0 +1,378 @@
+package chdbdriver
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/chdb-io/chdb-go/chdb/internal/native"
+)
+
+// textNull is how CSV and TSV results write NULL values.
+const textNull = `\N`
+
+// chunkReader reads the buffers of a result and of the following chunks of its stream as a single byte stream,
+// so that a record may span several chunks.
+type chunkReader struct {
+	result chdbpurego.ChdbResult       // current chunk
+	stream chdbpurego.ChdbStreamResult // nil for buffered results
+	off    int
+	done   bool // set once the last chunk was read
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for !c.done && c.off == c.result.Len() {
+		if err := c.nextChunk(); err != nil {
+			c.done = true
+			return 0, err
+		}
+	}
+	if c.done {
+		return 0, io.EOF
+	}
+	n := copy(p, c.result.Buf()[c.off:])
+	c.off += n
+	return n, nil
+}
+
+// nextChunk moves to the next chunk of the stream, returning io.EOF once it is exhausted.
+func (c *chunkReader) nextChunk() error {
+	if c.stream == nil {
+		return io.EOF
+	}
+	// free the previous chunk
+	c.result.Free()
+	c.result, c.off = c.stream.GetNext(), 0
+	if c.result == nil {
+		return io.EOF
+	}
+	if err := c.result.Error(); err != nil {
+		return fmt.Errorf("error in chunk: %s", err)
+	}
+	if c.result.Len() == 0 {
+		return io.EOF
+	}
+	return nil
+}
+
+// free releases the current chunk and the stream.
+func (c *chunkReader) free() {
+	if c.stream != nil {
+		c.stream.Free()
+		c.stream = nil
+	} else if c.result != nil {
+		c.result.Free()
+	}
+	c.result = nil
+}
+
+// recordReader reads the fields of the next record, returning io.EOF once the input is exhausted.
+// NULL fields are returned as nil.
+type recordReader func() ([]any, error)
+
+// readLine reads a line without its line break, returning io.EOF once the input is exhausted.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// csvRecords reads CSV records. Quoted fields may contain separators, doubled quotes and line breaks, and span
+// several chunks. Unquoted \N fields are NULL values, while quoted ones are strings.
+func csvRecords(r io.Reader) recordReader {
+	reader := bufio.NewReader(r)
+	return func() ([]any, error) {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			record []any
+			field  strings.Builder
+		)
+		for i := 0; ; i++ {
+			if i < len(line) && line[i] == '"' {
+				for i++; ; {
+					j := strings.IndexByte(line[i:], '"')
+					if j < 0 {
+						// the line break belongs to the field
+						field.WriteString(line[i:])
+						field.WriteByte('\n')
+						if line, err = readLine(reader); err != nil {
+							return nil, fmt.Errorf("unterminated quoted field: %w", io.ErrUnexpectedEOF)
+						}
+						i = 0
+						continue
+					}
+					field.WriteString(line[i : i+j])
+					i += j + 1
+					if i == len(line) || line[i] != '"' {
+						break
+					}
+					field.WriteByte('"')
+					i++
+				}
+				record = append(record, field.String())
+				field.Reset()
+			} else {
+				j := strings.IndexByte(line[i:], ',')
+				if j < 0 {
+					j = len(line) - i
+				}
+				if raw := line[i : i+j]; raw == textNull {
+					record = append(record, nil)
+				} else {
+					record = append(record, raw)
+				}
+				i += j
+			}
+			if i >= len(line) {
+				return record, nil
+			}
+			if line[i] != ',' {
+				return nil, fmt.Errorf("unexpected %q after a quoted field", line[i])
+			}
+		}
+	}
+}
+
+// tsvRecords reads TSV records, whose special characters are escaped with backslashes.
+func tsvRecords(r io.Reader) recordReader {
+	reader := bufio.NewReader(r)
+	return func() ([]any, error) {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		fields := strings.Split(line, "\t")
+		record := make([]any, len(fields))
+		for i, field := range fields {
+			// \N is a NULL value, while the escaped \\N is the \N string
+			if field != textNull {
+				record[i] = unescapeTSV(field)
+			}
+		}
+		return record, nil
+	}
+}
+
+// jsonRecords reads JSONCompactEachRow records, one JSON array per row.
+func jsonRecords(r io.Reader) recordReader {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return func() ([]any, error) {
+		var record []any
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+		for i, v := range record {
+			record[i] = jsonval.Normalize(v)
+		}
+		return record, nil
+	}
+}
+
+// headerStrings converts a header record to strings.
+func headerStrings(record []any) ([]string, error) {
+	out := make([]string, len(record))
+	for i, v := range record {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid header value %v", v)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// unescapeTSV unescapes a TSV field.
+func unescapeTSV(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// textRows reads CSVWithNamesAndTypes, TSVWithNamesAndTypes and JSONCompactEachRowWithNamesAndTypes results,
+// either a single buffer or the chunks of a stream, parsed as they are read so that memory stays bounded.
+// CSV and TSV values are returned as their text, and NULL values of Nullable columns as nil.
+type textRows struct {
+	streamRows
+	chunks     *chunkReader
+	records    recordReader
+	textNulls  bool     // whether NULL values are written as \N
+	names      []string // columns reported by the rows
+	types      []string // ClickHouse types of the reported columns
+	nullable   []bool   // whether the reported columns are Nullable
+	projection []int    // indexes of the reported columns in the records, nil when not projected
+	rowsConfig
+}
+
+func newTextRows(d DriverType, result chdbpurego.ChdbResult, stream chdbpurego.ChdbStreamResult, opts []RowsOption) (*textRows, error) {
+	rows := &textRows{chunks: &chunkReader{result: result, stream: stream}, rowsConfig: newRowsConfig(opts)}
+	rows.columns = func() []chdb.ColumnMeta {
+		return typedColumns(rows.names, rows.types, rows.ColumnTypeScanType)
+	}
+	switch d {
+	case TSV:
+		rows.records, rows.textNulls = tsvRecords(rows.chunks), true
+	case JSON:
+		rows.records = jsonRecords(rows.chunks)
+	default:
+		rows.records, rows.textNulls = csvRecords(rows.chunks), true
+	}
+	// the header holds the names of the columns, then their types. Empty results may have no header at all.
+	header, err := rows.records()
+	if err == io.EOF {
+		return rows, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names, err := headerStrings(header)
+	if err != nil {
+		return nil, err
+	}
+	if header, err = rows.records(); err != nil {
+		return nil, fmt.Errorf("read the column types: %w", err)
+	}
+	types, err := headerStrings(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(types) != len(names) {
+		return nil, fmt.Errorf("invalid header of %d column names and %d types", len(names), len(types))
+	}
+	if err := rows.setColumns(names, types); err != nil {
+		return nil, err
+	}
+	rows.nullable = make([]bool, len(rows.types))
+	for i, t := range rows.types {
+		rows.nullable[i] = native.Nullable(t)
+	}
+	return rows, nil
+}
+
+func (r *textRows) setColumns(names, types []string) error {
+	if r.rowsConfig.projection == nil {
+		r.names, r.types = names, types
+		return nil
+	}
+	r.projection = make([]int, len(r.rowsConfig.projection))
+	r.names, r.types = make([]string, len(r.projection)), make([]string, len(r.projection))
+	for i, name := range r.rowsConfig.projection {
+		r.projection[i] = -1
+		for j, n := range names {
+			if n == name {
+				r.projection[i] = j
+				break
+			}
+		}
+		if r.projection[i] < 0 {
+			return fmt.Errorf("projected column %q not found in the result", name)
+		}
+		r.names[i], r.types[i] = name, types[r.projection[i]]
+	}
+	return nil
+}
+
+func (r *textRows) Columns() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Close releases the result, see streamRows.close.
+func (r *textRows) Close() error {
+	return r.close(r.chunks.free)
+}
+
+func (r *textRows) Next(dest []driver.Value) error {
+	return r.read(func() error {
+		return r.next(dest)
+	})
+}
+
+func (r *textRows) next(dest []driver.Value) error {
+	if r.names == nil {
+		return io.EOF
+	}
+	record, err := r.records()
+	if err != nil {
+		return err
+	}
+	for i := range r.names {
+		column := i
+		if r.projection != nil {
+			column = r.projection[i]
+		}
+		if column >= len(record) {
+			return fmt.Errorf("record of %d fields, expected %d", len(record), len(r.names))
+		}
+		dest[i] = record[column]
+	}
+	return nil
+}
+
+func (r *textRows) ColumnTypeDatabaseTypeName(index int) string {
+	if r.unwrapLowCardinality {
+		return native.UnwrapLowCardinality(r.types[index])
+	}
+	return r.types[index]
+}
+
+func (r *textRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.nullable[index], true
+}
+
+func (r *textRows) ColumnTypeScanType(index int) reflect.Type {
+	if !r.textNulls {
+		return reflect.TypeOf((*any)(nil)).Elem()
+	}
+	if r.nullable[index] {
+		return reflect.TypeOf((*string)(nil))
+	}
+	return reflect.TypeOf("")
+}
+
+func (r *textRows) ColumnTypeElementNames(index int) []string {
+	return native.ElementNames(r.types[index])
+}
+
+func (r *textRows) ColumnTypeEnumValues(index int) map[string]int16 {
+	return native.EnumValues(r.types[index])
+}
@@ -0,0 +1,137 @@
+package chdbdriver
+
+import (
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// splitChunks splits data into chunks of at most size bytes, served by a fake stream.
+func splitChunks(data string, size int) *chunkStream {
+	stream := &chunkStream{}
+	for len(data) > 0 {
+		n := min(size, len(data))
+		stream.chunks = append(stream.chunks, &chunkResult{buf: []byte(data[:n])})
+		data = data[n:]
+	}
+	return stream
+}
+
+func readTextRows(t *testing.T, rows driver.Rows) [][]driver.Value {
+	t.Helper()
+	defer rows.Close()
+	var got [][]driver.Value
+	for {
+		values := make([]driver.Value, len(rows.Columns()))
+		if err := rows.Next(values); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("next fail, err: %s", err)
+		}
+		got = append(got, values)
+	}
+	return got
+}
+
+func TestTextStreamingRows(t *testing.T) {
+	for _, tc := range []struct {
+		driverType DriverType
+		data       string
+	}{
+		{CSV, "\"id\",\"name\",\"note\"\n\"UInt64\",\"String\",\"Nullable(String)\"\n" +
+			"1,\"a, \"\"quoted\"\"\nmultiline value\",\\N\n2,\"b\",\"x\"\n"},
+		{TSV, "id\tname\tnote\nUInt64\tString\tNullable(String)\n" +
+			"1\ta, \"quoted\"\\nmultiline value\t\\N\n2\tb\tx\n"},
+	} {
+		// tiny chunks make fields span chunk boundaries
+		for _, size := range []int{1, 7, len(tc.data)} {
+			rows, err := tc.driverType.PrepareStreamingRows(splitChunks(tc.data, size), defaultBufferSize, false)
+			if err != nil {
+				t.Fatalf("%s: prepare rows fail, err: %s", tc.driverType, err)
+			}
+			if !reflect.DeepEqual(rows.Columns(), []string{"id", "name", "note"}) {
+				t.Fatalf("%s: unexpected columns %v", tc.driverType, rows.Columns())
+			}
+			if got := rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(2); got != "Nullable(String)" {
+				t.Errorf("%s: expected Nullable(String), got %s", tc.driverType, got)
+			}
+			expected := [][]driver.Value{
+				{"1", "a, \"quoted\"\nmultiline value", nil},
+				{"2", "b", "x"},
+			}
+			if got := readTextRows(t, rows); !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s, chunks of %d bytes: expected %q, got %q", tc.driverType, size, expected, got)
+			}
+		}
+	}
+}
+
+func TestTextRowsNulls(t *testing.T) {
+	for _, tc := range []struct {
+		driverType DriverType
+		data       string
+	}{
+		{CSV, "\"a\",\"b\"\n\"Nullable(String)\",\"String\"\n\\N,\"\\N\"\n\"\\N\",\"\"\n"},
+		{TSV, "a\tb\nNullable(String)\tString\n\\N\t\\\\N\n\\\\N\t\n"},
+	} {
+		rows, err := tc.driverType.PrepareRows(&chunkResult{buf: []byte(tc.data)}, []byte(tc.data), defaultBufferSize, false)
+		if err != nil {
+			t.Fatalf("%s: prepare rows fail, err: %s", tc.driverType, err)
+		}
+		expected := [][]driver.Value{{nil, `\N`}, {`\N`, ""}}
+		if got := readTextRows(t, rows); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %q, got %q", tc.driverType, expected, got)
+		}
+	}
+}
+
+func TestJSONStreamingRows(t *testing.T) {
+	data := `["id","tags","meta","score"]` + "\n" +
+		`["UInt64","Array(String)","Map(String, Nullable(Int32))","Nullable(Float64)"]` + "\n" +
+		`["18446744073709551615",["a","b\"c"],{"x":1,"y":null},1.5]` + "\n" +
+		`["2",[],{},null]` + "\n"
+	for _, size := range []int{3, len(data)} {
+		rows, err := JSON.PrepareStreamingRows(splitChunks(data, size), defaultBufferSize, false)
+		if err != nil {
+			t.Fatalf("prepare rows fail, err: %s", err)
+		}
+		if got := rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(1); got != "Array(String)" {
+			t.Errorf("expected Array(String), got %s", got)
+		}
+		expected := [][]driver.Value{
+			{"18446744073709551615", []any{"a", "b\"c"}, map[string]any{"x": int64(1), "y": nil}, 1.5},
+			{"2", []any{}, map[string]any{}, nil},
+		}
+		if got := readTextRows(t, rows); !reflect.DeepEqual(got, expected) {
+			t.Errorf("chunks of %d bytes: expected %#v, got %#v", size, expected, got)
+		}
+	}
+}
+
+func TestTextRowsProjection(t *testing.T) {
+	data := "id\tname\nUInt64\tLowCardinality(String)\n1\ta\n"
+	rows, err := TSV.PrepareRows(&chunkResult{buf: []byte(data)}, []byte(data), defaultBufferSize, false,
+		WithProjection([]string{"name"}), WithUnwrapLowCardinality())
+	if err != nil {
+		t.Fatalf("prepare rows fail, err: %s", err)
+	}
+	if got := rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(0); got != "String" {
+		t.Errorf("expected String, got %s", got)
+	}
+	if got := readTextRows(t, rows); !reflect.DeepEqual(got, [][]driver.Value{{"a"}}) {
+		t.Errorf("unexpected rows %q", got)
+	}
+
+	if _, err := TSV.PrepareRows(&chunkResult{buf: []byte(data)}, []byte(data), defaultBufferSize, false, WithProjection([]string{"missing"})); err == nil {
+		t.Errorf("expected an error projecting a missing column")
+	}
+
+	rows, err = CSV.PrepareRows(&chunkResult{}, nil, defaultBufferSize, false)
+	if err != nil {
+		t.Fatalf("prepare empty rows fail, err: %s", err)
+	}
+	if got := readTextRows(t, rows); len(rows.Columns()) != 0 || got != nil {
+		t.Errorf("expected no columns and no rows, got %v and %q", rows.Columns(), got)
+	}
+}
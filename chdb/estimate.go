@@ -0,0 +1,73 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoEstimate is returned by EstimateCost when EXPLAIN ESTIMATE reports nothing for the query,
+// which happens when it doesn't read from MergeTree tables.
+var ErrNoEstimate = errors.New("query does not support EXPLAIN ESTIMATE")
+
+// TableEstimate is the estimated amount of data read from a table.
+type TableEstimate struct {
+	Database string
+	Table    string
+	Parts    int64
+	Rows     int64
+	Marks    int64
+}
+
+// CostEstimate is the estimated amount of data a query reads, as reported by EXPLAIN ESTIMATE.
+// Parts, Rows and Marks are the totals across Tables.
+type CostEstimate struct {
+	Parts  int64
+	Rows   int64
+	Marks  int64
+	Tables []TableEstimate
+}
+
+// EstimateCost estimates the amount of data the query would read without running it, using EXPLAIN ESTIMATE.
+// Only reads from MergeTree tables are estimated; ErrNoEstimate is returned for queries which don't read any.
+func (s *Session) EstimateCost(queryStr string) (CostEstimate, error) {
+	var estimate CostEstimate
+	res, err := s.Query("EXPLAIN ESTIMATE "+trimQuery(queryStr), "TSVRaw")
+	if err != nil {
+		return estimate, err
+	}
+	defer res.Free()
+	out := strings.TrimSuffix(res.String(), "\n")
+	if out == "" {
+		return estimate, ErrNoEstimate
+	}
+	for _, line := range strings.Split(out, "\n") {
+		table, err := parseTableEstimate(line)
+		if err != nil {
+			return estimate, err
+		}
+		estimate.Parts += table.Parts
+		estimate.Rows += table.Rows
+		estimate.Marks += table.Marks
+		estimate.Tables = append(estimate.Tables, table)
+	}
+	return estimate, nil
+}
+
+// parseTableEstimate parses a line of EXPLAIN ESTIMATE: database, table, parts, rows and marks.
+func parseTableEstimate(line string) (TableEstimate, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 5 {
+		return TableEstimate{}, fmt.Errorf("unexpected EXPLAIN ESTIMATE output: %q", line)
+	}
+	var counts [3]int64
+	for i := range counts {
+		n, err := strconv.ParseInt(fields[2+i], 10, 64)
+		if err != nil {
+			return TableEstimate{}, fmt.Errorf("unexpected EXPLAIN ESTIMATE output: %q", line)
+		}
+		counts[i] = n
+	}
+	return TableEstimate{Database: fields[0], Table: fields[1], Parts: counts[0], Rows: counts[1], Marks: counts[2]}, nil
+}
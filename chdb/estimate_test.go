@@ -0,0 +1,45 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestEstimateCost")
+	if _, err := sess.Query("CREATE TABLE TestEstimateCost (id UInt64) ENGINE = MergeTree() ORDER BY id SETTINGS index_granularity = 1000"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("INSERT INTO TestEstimateCost SELECT number FROM numbers(10000)"); err != nil {
+		t.Fatal(err)
+	}
+
+	estimate, err := sess.EstimateCost("SELECT * FROM TestEstimateCost;")
+	if err != nil {
+		t.Fatalf("EstimateCost fail, err: %s", err)
+	}
+	if estimate.Rows != 10000 || estimate.Parts != 1 || estimate.Marks != 10 {
+		t.Errorf("expected 10000 rows in 1 part and 10 marks, got %+v", estimate)
+	}
+	if len(estimate.Tables) != 1 || estimate.Tables[0].Table != "TestEstimateCost" {
+		t.Errorf("expected the estimate of TestEstimateCost, got %+v", estimate.Tables)
+	}
+
+	if _, err := sess.EstimateCost("SELECT number FROM numbers(10)"); !errors.Is(err, ErrNoEstimate) {
+		t.Errorf("expected ErrNoEstimate for a table function, got %v", err)
+	}
+}
+
+func TestParseTableEstimate(t *testing.T) {
+	got, err := parseTableEstimate("default\tt\t2\t1500\t3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (TableEstimate{Database: "default", Table: "t", Parts: 2, Rows: 1500, Marks: 3}) {
+		t.Errorf("unexpected estimate %+v", got)
+	}
+	if _, err := parseTableEstimate("default\tt\tx\t1\t1"); err == nil {
+		t.Errorf("expected an error for a malformed line")
+	}
+}
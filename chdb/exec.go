@@ -0,0 +1,82 @@
+package chdb
+
+import (
+	"fmt"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+// ExecResult holds the statistics of a statement run with Exec.
+// chDB reports the rows and bytes written by INSERT statements through the read counters of their result.
+type ExecResult struct {
+	// RowsWritten is the number of rows written by the statement.
+	RowsWritten uint64
+	// BytesWritten is the number of bytes written by the statement.
+	BytesWritten uint64
+	// Elapsed is the time chDB took to run the statement.
+	Elapsed time.Duration
+}
+
+// NewExecResult returns the statistics of the result of a statement.
+func NewExecResult(res chdbpurego.ChdbResult) ExecResult {
+	return ExecResult{
+		RowsWritten:  res.RowsRead(),
+		BytesWritten: res.BytesRead(),
+		Elapsed:      time.Duration(res.Elapsed() * float64(time.Second)),
+	}
+}
+
+// QueryStats holds the statistics chDB reports for the result of a query.
+type QueryStats = chdbpurego.QueryStats
+
+// ResultStats returns the statistics of the result of a query, e.g. to log its cost. They are taken from the Stats
+// method of the results implementing chdbpurego.ChdbResultStats, and from their counters otherwise.
+func ResultStats(res chdbpurego.ChdbResult) QueryStats {
+	if s, ok := res.(chdbpurego.ChdbResultStats); ok {
+		return s.Stats()
+	}
+	return QueryStats{
+		Elapsed:   time.Duration(res.Elapsed() * float64(time.Second)),
+		RowsRead:  res.RowsRead(),
+		BytesRead: res.BytesRead(),
+	}
+}
+
+// Exec runs a statement, e.g. INSERT or CREATE TABLE, discarding its output and returning its statistics.
+func (s *Session) Exec(queryStr string) (ExecResult, error) {
+	res, err := s.Query(queryStr)
+	if err != nil {
+		return ExecResult{}, err
+	}
+	defer res.Free()
+	return NewExecResult(res), nil
+}
+
+// StatementResult holds the outcome of a statement of a script run with ExecScript.
+type StatementResult struct {
+	// Statement is the statement as split from the script.
+	Statement string
+	// Result holds the statistics of the statement, when it succeeded.
+	Result ExecResult
+	// Err is the error of the statement, if any.
+	Err error
+}
+
+// ExecScript runs the semicolon-separated statements of a script one after the other, e.g. a schema migration file.
+// Semicolons inside string literals, quoted identifiers and comments don't split statements. It stops at the first
+// failing statement and returns the results of the statements run so far, the failing one included, along with its
+// error. Statements are not run in a transaction: the ones preceding a failure are not rolled back.
+func (s *Session) ExecScript(script string) ([]StatementResult, error) {
+	statements := sqlfmt.SplitStatements(script)
+	results := make([]StatementResult, 0, len(statements))
+	for i, statement := range statements {
+		res, err := s.Exec(statement)
+		results = append(results, StatementResult{Statement: statement, Result: res, Err: err})
+		if err != nil {
+			return results, fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return results, nil
+}
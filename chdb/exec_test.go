@@ -0,0 +1,83 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	sess := testSession(t)
+
+	if _, err := sess.Exec("CREATE TABLE TestExec (id UInt64) ENGINE = MergeTree ORDER BY id"); err != nil {
+		t.Fatalf("Exec fail, err: %s", err)
+	}
+	defer sess.Exec("DROP TABLE IF EXISTS TestExec")
+
+	res, err := sess.Exec("INSERT INTO TestExec SELECT number FROM numbers(100)")
+	if err != nil {
+		t.Fatalf("Exec fail, err: %s", err)
+	}
+	if res.RowsWritten != 100 {
+		t.Errorf("expected 100 rows written, got %d", res.RowsWritten)
+	}
+	if res.BytesWritten == 0 {
+		t.Errorf("expected bytes to be written")
+	}
+
+	var queryErr *QueryError
+	if _, err := sess.Exec("INSERT INTO TestExecMissing VALUES (1)"); !errors.As(err, &queryErr) {
+		t.Errorf("expected a QueryError, got %v", err)
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	sess := testSession(t)
+	defer sess.Exec("DROP TABLE IF EXISTS TestExecScript")
+
+	results, err := sess.ExecScript(`
+		-- schema; version 1
+		CREATE TABLE TestExecScript (id UInt64, name String) ENGINE = MergeTree ORDER BY id;
+		INSERT INTO TestExecScript VALUES (1, 'a;b'), (2, 'c');
+		/* the last statement has no semicolon */
+		INSERT INTO TestExecScript SELECT number + 10, toString(number) FROM numbers(3)
+	`)
+	if err != nil {
+		t.Fatalf("ExecScript fail, err: %s", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 statement results, got %d", len(results))
+	}
+	if results[1].Result.RowsWritten != 2 || results[2].Result.RowsWritten != 3 {
+		t.Errorf("expected 2 and 3 rows written, got %d and %d", results[1].Result.RowsWritten, results[2].Result.RowsWritten)
+	}
+
+	results, err = sess.ExecScript("INSERT INTO TestExecScript VALUES (3, 'd'); INSERT INTO TestExecScriptMissing VALUES (1); INSERT INTO TestExecScript VALUES (4, 'e')")
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected a QueryError, got %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+		t.Errorf("expected the script to stop at the second statement, got %+v", results)
+	}
+	var count int
+	if err := sess.QueryScalar("SELECT count() FROM TestExecScript", &count); err != nil || count != 6 {
+		t.Errorf("expected 6 rows, got %d, err: %v", count, err)
+	}
+}
+
+func TestResultStats(t *testing.T) {
+	sess := testSession(t)
+
+	res, err := sess.Query("SELECT number FROM numbers(1000)")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	defer res.Free()
+	stats := ResultStats(res)
+	if stats.RowsRead != 1000 {
+		t.Errorf("expected 1000 rows read, got %d", stats.RowsRead)
+	}
+	if stats.BytesRead == 0 || stats.Elapsed <= 0 {
+		t.Errorf("expected bytes read and elapsed time, got %+v", stats)
+	}
+}
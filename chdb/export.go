@@ -0,0 +1,86 @@
+package chdb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// QueryToCSVFile streams the query result as CSV into the file at path, creating or truncating it,
+// and returns the number of exported rows. With withHeader, a first line holds the column names.
+// Chunks are written as they arrive, the result is never buffered as a whole.
+// On error the partially written file is removed.
+func (s *Session) QueryToCSVFile(queryStr, path string, withHeader bool) (n int64, err error) {
+	format := "CSV"
+	if withHeader {
+		format = "CSVWithNames"
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("create export file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	var counter csvRowCounter
+	err = s.forEachChunk(queryStr, format, func(buf []byte) error {
+		counter.feed(buf)
+		_, err := w.Write(buf)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("write export file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("close export file: %w", err)
+	}
+	n = counter.rows
+	if withHeader && n > 0 {
+		n--
+	}
+	return n, nil
+}
+
+// csvRowCounter counts the records of a CSV stream, ignoring the newlines inside quoted fields.
+type csvRowCounter struct {
+	rows    int64
+	inQuote bool
+}
+
+func (c *csvRowCounter) feed(buf []byte) {
+	for _, b := range buf {
+		switch {
+		case b == '"':
+			// escaped quotes are doubled, so they toggle the state twice
+			c.inQuote = !c.inQuote
+		case b == '\n' && !c.inQuote:
+			c.rows++
+		}
+	}
+}
+
+// QueryToArrowFile writes the query result into the file at path as an Arrow IPC file, creating or truncating it.
+// The file is written by the engine itself through INTO OUTFILE, block by block, so the result is never buffered
+// as a whole. The query must not have its own FORMAT clause.
+func (s *Session) QueryToArrowFile(queryStr, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("%s\nINTO OUTFILE %s TRUNCATE FORMAT Arrow", trimQuery(queryStr), quoteString(abs))
+	res, err := s.Query(query)
+	if err != nil {
+		return fmt.Errorf("export to %s: %w", path, err)
+	}
+	res.Free()
+	return nil
+}
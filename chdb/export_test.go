@@ -0,0 +1,90 @@
+package chdb
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueryToCSVFile(t *testing.T) {
+	sess := testSession(t)
+	query := "SELECT number AS id, if(number % 10 = 0, 'multi\nline \"quoted\"', toString(number)) AS name FROM numbers(1000)"
+
+	for _, withHeader := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "export.csv")
+		n, err := sess.QueryToCSVFile(query, path, withHeader)
+		if err != nil {
+			t.Fatalf("QueryToCSVFile fail, err: %s", err)
+		}
+		if n != 1000 {
+			t.Errorf("expected 1000 exported rows, got %d", n)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatalf("read exported csv fail, err: %s", err)
+		}
+		expected := 1000
+		if withHeader {
+			expected++
+			if records[0][0] != "id" || records[0][1] != "name" {
+				t.Errorf("expected a header line, got %v", records[0])
+			}
+		}
+		if len(records) != expected {
+			t.Errorf("expected %d csv records, got %d", expected, len(records))
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "failed.csv")
+	if _, err := sess.QueryToCSVFile("SELECT * FROM TestQueryToCSVFileMissing", path, false); err == nil {
+		t.Errorf("expected an error for a failing query")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the partial file to be removed, stat err: %v", err)
+	}
+	if _, err := sess.QueryToCSVFile("SELECT 1", filepath.Join(t.TempDir(), "missing", "dir.csv"), false); err == nil {
+		t.Errorf("expected an error creating a file in a missing directory")
+	}
+}
+
+func TestCSVRowCounter(t *testing.T) {
+	var c csvRowCounter
+	c.feed([]byte("1,\"a\nb\"\n2,\"c\"\"\n"))
+	c.feed([]byte("d\"\n3,e\n"))
+	if c.rows != 3 {
+		t.Errorf("expected 3 rows, got %d", c.rows)
+	}
+}
+
+func TestQueryToArrowFile(t *testing.T) {
+	sess := testSession(t)
+	path := filepath.Join(t.TempDir(), "export.arrow")
+	if err := sess.QueryToArrowFile("SELECT number AS id, toString(number) AS name FROM numbers(1000);", path); err != nil {
+		t.Fatalf("QueryToArrowFile fail, err: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 12 || string(data[:6]) != "ARROW1" || string(data[len(data)-6:]) != "ARROW1" {
+		t.Fatalf("expected an Arrow IPC file, got %d bytes", len(data))
+	}
+	n, err := sess.CountRows("SELECT * FROM file(" + quoteString(path) + ", 'Arrow')")
+	if err != nil {
+		t.Fatalf("read back exported file fail, err: %s", err)
+	}
+	if n != 1000 {
+		t.Errorf("expected 1000 rows in the exported file, got %d", n)
+	}
+
+	if err := sess.QueryToArrowFile("SELECT 1", filepath.Join(t.TempDir(), "missing", "dir.arrow")); err == nil {
+		t.Errorf("expected an error writing in a missing directory")
+	}
+}
@@ -0,0 +1,126 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+// ErrInvalidFormat is returned when an output format is not one of the supported ClickHouse formats.
+var ErrInvalidFormat = errors.New("invalid output format")
+
+// ErrChunkedFormat is returned by Session.QueryContext for the formats whose streamed chunks are complete documents,
+// which can't be concatenated into a single result.
+var ErrChunkedFormat = errors.New("output format can't be gathered from streamed chunks")
+
+// outputFormats lists the accepted output formats. Formats mapped to true also accept
+// the WithNames and WithNamesAndTypes suffixes, e.g. CSVWithNames.
+var outputFormats = map[string]bool{
+	"TabSeparated":              true,
+	"TabSeparatedRaw":           true,
+	"TSV":                       true,
+	"TSVRaw":                    true,
+	"CSV":                       true,
+	"CustomSeparated":           true,
+	"JSONCompactEachRow":        true,
+	"JSONCompactStringsEachRow": true,
+	"RowBinary":                 true,
+
+	"Arrow":                          false,
+	"ArrowStream":                    false,
+	"Avro":                           false,
+	"BSONEachRow":                    false,
+	"CapnProto":                      false,
+	"JSON":                           false,
+	"JSONColumns":                    false,
+	"JSONColumnsWithMetadata":        false,
+	"JSONCompact":                    false,
+	"JSONCompactColumns":             false,
+	"JSONCompactStrings":             false,
+	"JSONEachRow":                    false,
+	"JSONEachRowWithProgress":        false,
+	"JSONLines":                      false,
+	"JSONObjectEachRow":              false,
+	"JSONStrings":                    false,
+	"JSONStringsEachRow":             false,
+	"JSONStringsEachRowWithProgress": false,
+	"LineAsString":                   false,
+	"Markdown":                       false,
+	"MsgPack":                        false,
+	"Native":                         false,
+	"NDJSON":                         false,
+	"Npy":                            false,
+	"Null":                           false,
+	"ORC":                            false,
+	"Parquet":                        false,
+	"Pretty":                         false,
+	"PrettyCompact":                  false,
+	"PrettyCompactMonoBlock":         false,
+	"PrettyCompactNoEscapes":         false,
+	"PrettyJSONEachRow":              false,
+	"PrettyMonoBlock":                false,
+	"PrettyNoEscapes":                false,
+	"PrettySpace":                    false,
+	"PrettySpaceNoEscapes":           false,
+	"Prometheus":                     false,
+	"Protobuf":                       false,
+	"ProtobufSingle":                 false,
+	"RawBLOB":                        false,
+	"SQLInsert":                      false,
+	"Template":                       false,
+	"TSKV":                           false,
+	"Values":                         false,
+	"Vertical":                       false,
+	"XML":                            false,
+}
+
+// chunkedFormats lists the output formats whose streamed chunks are complete documents, e.g. one Parquet file each.
+var chunkedFormats = map[string]bool{
+	"Arrow":       true,
+	"ArrowStream": true,
+	"Avro":        true,
+	"Npy":         true,
+	"ORC":         true,
+	"Parquet":     true,
+}
+
+// validateFormat checks the format against the accepted output formats, since it is handed to the native library as is.
+func validateFormat(format string) error {
+	if _, ok := outputFormats[format]; ok {
+		return nil
+	}
+	for _, suffix := range []string{"WithNamesAndTypes", "WithNames"} {
+		if base, ok := strings.CutSuffix(format, suffix); ok && outputFormats[base] {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+}
+
+// validateFormatOption checks that a setting name is a valid name of a format setting, i.e. starts with format_ or
+// output_format_.
+func validateFormatOption(name string) error {
+	if !strings.HasPrefix(name, "format_") && !strings.HasPrefix(name, "output_format_") {
+		return fmt.Errorf("%q is not a format setting", name)
+	}
+	return sqlfmt.ValidateSettingName(name)
+}
+
+// QueryWithFormatOptions runs the query like Query in the given format, applying the given format settings,
+// e.g. format_csv_delimiter or output_format_json_quote_64bit_integers, to this query only.
+// Only format_* and output_format_* settings are accepted. The query must not already end with a SETTINGS clause.
+func (s *Session) QueryWithFormatOptions(queryStr, format string, formatOpts map[string]string) (chdbpurego.ChdbResult, error) {
+	for name := range formatOpts {
+		if err := validateFormatOption(name); err != nil {
+			return nil, err
+		}
+	}
+	clause, err := formatSettings(formatOpts)
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(trimQuery(queryStr)+clause, format)
+}
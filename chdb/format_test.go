@@ -0,0 +1,111 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// recordingConn is a fake connection recording the formats reaching the native layer.
+type recordingConn struct {
+	formats []string
+}
+
+func (c *recordingConn) Query(queryStr string, formatStr string) (chdbpurego.ChdbResult, error) {
+	c.formats = append(c.formats, formatStr)
+	return nil, nil
+}
+
+func (c *recordingConn) QueryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	c.formats = append(c.formats, formatStr)
+	return nil, nil
+}
+
+func (c *recordingConn) Ready() bool { return true }
+
+func (c *recordingConn) Close() {}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{"CSV", "CSVWithNames", "TSVWithNamesAndTypes", "Parquet", "JSONEachRow", "JSONCompactEachRowWithNames"} {
+		if err := validateFormat(format); err != nil {
+			t.Errorf("validateFormat(%q) fail, err: %s", format, err)
+		}
+	}
+	for _, format := range []string{"", "csv", "ParquetWithNames", "CSV\x00", "CSV; DROP TABLE t", " CSV", "CSVWithNamesWithNames"} {
+		if err := validateFormat(format); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("validateFormat(%q) expected ErrInvalidFormat, got %v", format, err)
+		}
+	}
+}
+
+func FuzzQueryFormat(f *testing.F) {
+	for _, seed := range []string{"CSV", "Parquet", "JSONEachRow", "CSVWithNames", "TSVRawWithNamesAndTypes", "", "CSV\n", "%s%n", "Pretty\x00Compact"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, format string) {
+		conn := &recordingConn{}
+		sess := &Session{conn: conn, defaultFormat: defaultOutputFormat}
+		_, queryErr := sess.Query("SELECT 1", format)
+		_, streamErr := sess.QueryStream("SELECT 1", format)
+
+		valid := validateFormat(format) == nil
+		if format == "" {
+			// an empty format falls back to the session default one
+			valid = true
+		}
+		if valid != (queryErr == nil) || valid != (streamErr == nil) {
+			t.Fatalf("format %q: validation and query errors disagree: %v, %v", format, queryErr, streamErr)
+		}
+		for _, got := range conn.formats {
+			if err := validateFormat(got); err != nil {
+				t.Fatalf("format %q reached the native layer unvalidated", got)
+			}
+		}
+		if !valid && len(conn.formats) > 0 {
+			t.Fatalf("invalid format %q reached the native layer", format)
+		}
+	})
+}
+
+func TestQueryWithFormatOptions(t *testing.T) {
+	sess := testSession(t)
+
+	ret, err := sess.QueryWithFormatOptions("SELECT number, toString(number) FROM numbers(2);", "CSV", map[string]string{
+		"format_csv_delimiter": "|",
+	})
+	if err != nil {
+		t.Fatalf("QueryWithFormatOptions fail, err: %s", err)
+	}
+	if ret.String() != "0|\"0\"\n1|\"1\"\n" {
+		t.Errorf("expected the CSV delimiter to be applied, got %q", ret.String())
+	}
+
+	// the options only apply to that query
+	ret, err = sess.Query("SELECT 1, 2", "CSV")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "1,2\n" {
+		t.Errorf("expected the default CSV delimiter, got %q", ret.String())
+	}
+
+	for _, name := range []string{"max_threads", "format_csv_delimiter = '|', max_threads"} {
+		if _, err := sess.QueryWithFormatOptions("SELECT 1", "CSV", map[string]string{name: "1"}); err == nil {
+			t.Errorf("expected an error for the setting %q", name)
+		}
+	}
+}
+
+func TestValidateFormatOption(t *testing.T) {
+	for _, name := range []string{"format_csv_delimiter", "output_format_json_quote_64bit_integers"} {
+		if err := validateFormatOption(name); err != nil {
+			t.Errorf("expected %q to be accepted, got %s", name, err)
+		}
+	}
+	for _, name := range []string{"max_threads", "input_format_null_as_default", "format_csv_delimiter=1", "Format_csv_delimiter"} {
+		if err := validateFormatOption(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package chdb
+
+import (
+	"fmt"
+)
+
+// ImportURL inserts into table the data served at url, read with the given ClickHouse input format,
+// using the url() table function. It returns the number of inserted rows.
+// HTTP errors reported by the engine are returned as errors.
+func (s *Session) ImportURL(table, url, format string) (int64, error) {
+	query := fmt.Sprintf("INSERT INTO %s SELECT * FROM url(%s, %s)", quoteIdentifier(table), quoteString(url), quoteString(format))
+	res, err := s.Query(query)
+	if err != nil {
+		return 0, fmt.Errorf("import from %s: %w", url, err)
+	}
+	defer res.Free()
+	// chdb return the number of rows inserted trough rows_read
+	return int64(res.RowsRead()), nil
+}
@@ -0,0 +1,43 @@
+package chdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImportURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.csv" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("1,alice\n2,bob\n3,carol\n"))
+	}))
+	defer server.Close()
+
+	sess := testSession(t)
+	_, err := sess.Query("CREATE TABLE IF NOT EXISTS TestImportURL (id UInt32, name String) ENGINE = MergeTree() ORDER BY id;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := sess.ImportURL("TestImportURL", server.URL+"/data.csv", "CSV")
+	if err != nil {
+		t.Fatalf("ImportURL fail, err: %s", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 imported rows, got %d", n)
+	}
+	ret, err := sess.Query("SELECT name FROM TestImportURL ORDER BY id;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.String() != "\"alice\"\n\"bob\"\n\"carol\"\n" {
+		t.Errorf("unexpected table content: %s", ret.String())
+	}
+
+	if _, err := sess.ImportURL("TestImportURL", server.URL+"/missing.csv", "CSV"); err == nil {
+		t.Errorf("expected an error when the url is not found")
+	}
+}
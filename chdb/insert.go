@@ -0,0 +1,213 @@
+package chdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+// InsertStruct inserts a single row into table, taking the column values from the fields of the given struct.
+// Columns are mapped like CreateTableFromStruct does: from the `chdb` struct tags, falling back to the field names.
+// Nil pointers are inserted as NULL, time.Time values with nanosecond precision and []byte values as raw strings.
+func (s *Session) InsertStruct(table string, row any) error {
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return fmt.Errorf("row is a nil pointer")
+		}
+		v = v.Elem()
+	}
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+	columns := make([]string, len(fields))
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		lit, err := valueLiteral(v.FieldByIndex(f.index))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.column, err)
+		}
+		columns[i] = quoteIdentifier(f.column)
+		values[i] = lit
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(table), strings.Join(columns, ", "), strings.Join(values, ", "))
+	res, err := s.Query(query)
+	if err != nil {
+		return err
+	}
+	res.Free()
+	return nil
+}
+
+// valueLiteral renders a Go value as a ClickHouse literal.
+func valueLiteral(v reflect.Value) (string, error) {
+	return sqlfmt.Literal(v)
+}
+
+// InsertStructs inserts the elements of a slice of structs, or of pointers to structs, into table with an Appender.
+// Columns are mapped like InsertStruct does, and the values are converted like Appender does: nil pointers are
+// inserted as NULL, time.Time values as dates or times, strings and floats as decimals, and slices as arrays.
+// It returns the number of inserted rows. Nil elements are rejected before any row is inserted, but the insert is
+// otherwise not atomic: the rows are inserted in blocks, and when a block fails, the blocks inserted before it stay
+// in the table while the following rows are dropped. The returned count then reports the rows of those blocks.
+func (s *Session) InsertStructs(table string, slice any) (int64, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0, fmt.Errorf("expected a slice of structs, got %T", slice)
+	}
+	fields, err := structFields(v.Type().Elem())
+	if err != nil {
+		return 0, err
+	}
+	if v.Len() == 0 {
+		return 0, nil
+	}
+	elems := make([]reflect.Value, v.Len())
+	for i := range elems {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				return 0, fmt.Errorf("element %d is a nil pointer", i)
+			}
+			elem = elem.Elem()
+		}
+		elems[i] = elem
+	}
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	a, err := s.Appender(table, WithAppendColumns(columns...))
+	if err != nil {
+		return 0, err
+	}
+	values := make([]any, len(fields))
+	for _, elem := range elems {
+		for j, f := range fields {
+			values[j] = elem.FieldByIndex(f.index).Interface()
+		}
+		if err := a.Append(values...); err != nil {
+			// the failed block stays buffered, to be dropped rather than inserted by Close
+			a.Discard()
+			a.Close()
+			return a.Inserted(), err
+		}
+	}
+	if err := a.Close(); err != nil {
+		a.Discard()
+		return a.Inserted(), err
+	}
+	return a.Inserted(), nil
+}
+
+// InsertArrow inserts the rows of an Arrow record into table, matching the columns of the table to the fields of
+// the record by name. The record is written as an ArrowStream payload to a temporary file, since queries can't carry
+// binary data, and inserted from it with the file table function. It returns the number of inserted rows.
+func (s *Session) InsertArrow(table string, rec arrow.Record) (int64, error) {
+	return s.insertArrow(table, rec.Schema(), func(w *ipc.Writer) error {
+		return w.Write(rec)
+	})
+}
+
+// InsertArrowReader inserts the rows of every record read from rr into table, like InsertArrow, in a single
+// INSERT statement. It returns the number of inserted rows.
+func (s *Session) InsertArrowReader(table string, rr array.RecordReader) (int64, error) {
+	return s.insertArrow(table, rr.Schema(), func(w *ipc.Writer) error {
+		for rr.Next() {
+			if err := w.Write(rr.Record()); err != nil {
+				return err
+			}
+		}
+		return rr.Err()
+	})
+}
+
+func (s *Session) insertArrow(table string, schema *arrow.Schema, write func(w *ipc.Writer) error) (int64, error) {
+	columns := make([]string, schema.NumFields())
+	for i, f := range schema.Fields() {
+		columns[i] = f.Name
+	}
+	res, err := s.insertFromFile(table, columns, "ArrowStream", func(w io.Writer) error {
+		iw := ipc.NewWriter(w, ipc.WithSchema(schema))
+		if err := write(iw); err != nil {
+			iw.Close()
+			return err
+		}
+		return iw.Close()
+	})
+	return int64(res.RowsWritten), err
+}
+
+// LoadData inserts into table the data read from r in the given input format, e.g. CSV, JSONEachRow or Parquet.
+// The data is copied to a temporary file as it is read, since queries can't carry it, so it is never held in memory
+// as a whole, and inserted from the file with the types of the table. It returns the number of inserted rows.
+func (s *Session) LoadData(table, format string, r io.Reader) (int64, error) {
+	res, err := s.insertFromFile(table, nil, format, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+	return int64(res.RowsWritten), err
+}
+
+// insertFromFile inserts into the given columns of table, or all of them when nil, the data written by write to
+// a temporary file in the staging directory of the session, read with the given input format. The file is removed
+// once the statement has run.
+func (s *Session) insertFromFile(table string, columns []string, format string, write func(w io.Writer) error) (ExecResult, error) {
+	dir, err := s.stagingDir()
+	if err != nil {
+		return ExecResult{}, fmt.Errorf("insert into %s: %w", table, err)
+	}
+	f, err := os.CreateTemp(dir, "chdb_insert_*")
+	if err != nil {
+		return ExecResult{}, err
+	}
+	defer os.Remove(f.Name())
+	bw := bufio.NewWriter(f)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ExecResult{}, fmt.Errorf("insert into %s: %w", table, err)
+	}
+	target := quoteIdentifier(table)
+	if columns != nil {
+		quoted := make([]string, len(columns))
+		for i, name := range columns {
+			quoted[i] = quoteIdentifier(name)
+		}
+		target += " (" + strings.Join(quoted, ", ") + ")"
+	}
+	// the data is read with the structure of the inserted columns, see use_structure_from_insertion_table_in_table_functions
+	query := fmt.Sprintf("INSERT INTO %s SELECT * FROM file(%s, %s)", target, quoteString(f.Name()), quoteString(format))
+	res, err := s.Exec(query)
+	if err != nil {
+		return ExecResult{}, fmt.Errorf("insert into %s: %w", table, err)
+	}
+	return res, nil
+}
+
+// stagingDir returns the directory of the files staged by the session: the session directory, which
+// WithTempDirFunc may have created, or the default temporary directory for in-memory sessions. In-memory sessions
+// opened with WithNoTempFallback have no staging directory, and fail with ErrNoSessionPath.
+func (s *Session) stagingDir() (string, error) {
+	if s.path != "" {
+		return s.path, nil
+	}
+	if s.noTempFallback {
+		return "", ErrNoSessionPath
+	}
+	return os.TempDir(), nil
+}
@@ -0,0 +1,213 @@
+package chdb
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+func TestInsertStruct(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestInsertStruct")
+	if err := sess.CreateTableFromStruct("TestInsertStruct", testModel{}, ""); err != nil {
+		t.Fatalf("CreateTableFromStruct fail, err: %s", err)
+	}
+
+	score := 9.5
+	createdAt := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+	rows := []testModel{
+		{ID: 1, Name: "it's \\ quoted", Score: &score, Tags: []string{"a", "b'c"}, CreatedAt: createdAt, Payload: []byte{0, 1, 0xff}},
+		{ID: 2, Name: "nulls", CreatedAt: createdAt.Add(-time.Hour)},
+	}
+	for i := range rows {
+		if err := sess.InsertStruct("TestInsertStruct", &rows[i]); err != nil {
+			t.Fatalf("InsertStruct fail, err: %s", err)
+		}
+	}
+
+	ret, err := sess.Query("SELECT id, name, score, tags, toUnixTimestamp64Nano(created_at), hex(Payload) FROM TestInsertStruct ORDER BY id", "TSVRaw")
+	if err != nil {
+		t.Fatalf("select fail, err: %s", err)
+	}
+	got := strings.Split(strings.TrimSuffix(ret.String(), "\n"), "\n")
+	expected := []string{
+		"1\tit's \\ quoted\t9.5\t['a','b\\'c']\t1709296245123456789\t0001FF",
+		"2\tnulls\t\\N\t[]\t1709292645123456789\t",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected rows %q, got %q", expected, got)
+	}
+}
+
+func TestInsertArrow(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestInsertArrow")
+	if _, err := sess.Exec("CREATE TABLE TestInsertArrow (id UInt32, name String, extra UInt8 DEFAULT 7) ENGINE = Memory"); err != nil {
+		t.Fatalf("create table fail, err: %s", err)
+	}
+	defer sess.Query("DROP TABLE IF EXISTS TestInsertArrow")
+
+	// fields are matched by name, in any order
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "id", Type: arrow.PrimitiveTypes.Uint32},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.StringBuilder).AppendValues([]string{"a", "b"}, nil)
+	b.Field(1).(*array.Uint32Builder).AppendValues([]uint32{1, 2}, nil)
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	n, err := sess.InsertArrow("TestInsertArrow", rec)
+	if err != nil {
+		t.Fatalf("InsertArrow fail, err: %s", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 inserted rows, got %d", n)
+	}
+	rr, err := array.NewRecordReader(schema, []arrow.Record{rec, rec})
+	if err != nil {
+		t.Fatalf("create record reader fail, err: %s", err)
+	}
+	defer rr.Release()
+	if n, err := sess.InsertArrowReader("TestInsertArrow", rr); err != nil || n != 4 {
+		t.Fatalf("expected 4 rows inserted by InsertArrowReader, got %d, err: %v", n, err)
+	}
+
+	ret, err := sess.Query("SELECT id, name, extra FROM TestInsertArrow ORDER BY id, name", "TSVRaw")
+	if err != nil {
+		t.Fatalf("select fail, err: %s", err)
+	}
+	defer ret.Free()
+	expected := "1\ta\t7\n1\ta\t7\n1\ta\t7\n2\tb\t7\n2\tb\t7\n2\tb\t7\n"
+	if got := ret.String(); got != expected {
+		t.Errorf("expected rows %q, got %q", expected, got)
+	}
+}
+
+func TestInsertStructs(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestInsertStructs")
+	if err := sess.CreateTableFromStruct("TestInsertStructs", testModel{}, ""); err != nil {
+		t.Fatalf("CreateTableFromStruct fail, err: %s", err)
+	}
+	defer sess.Query("DROP TABLE IF EXISTS TestInsertStructs")
+
+	score := 9.5
+	createdAt := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+	rows := []*testModel{
+		{ID: 1, Name: "a", Score: &score, Tags: []string{"x", "y"}, CreatedAt: createdAt, Payload: []byte{0, 0xff}},
+		{ID: 2, Name: "b", CreatedAt: createdAt},
+	}
+	n, err := sess.InsertStructs("TestInsertStructs", rows)
+	if err != nil {
+		t.Fatalf("InsertStructs fail, err: %s", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 inserted rows, got %d", n)
+	}
+	ret, err := sess.Query("SELECT id, name, score, tags, toUnixTimestamp64Nano(created_at), hex(Payload) FROM TestInsertStructs ORDER BY id", "TSVRaw")
+	if err != nil {
+		t.Fatalf("select fail, err: %s", err)
+	}
+	defer ret.Free()
+	expected := "1\ta\t9.5\t['x','y']\t1709296245123456789\t00FF\n2\tb\t\\N\t[]\t1709296245123456789\t\n"
+	if got := ret.String(); got != expected {
+		t.Errorf("expected rows %q, got %q", expected, got)
+	}
+
+	if _, err := sess.InsertStructs("TestInsertStructs", testModel{}); err == nil {
+		t.Errorf("expected an error for a struct instead of a slice")
+	}
+	if n, err := sess.InsertStructs("TestInsertStructs", []testModel{}); err != nil || n != 0 {
+		t.Errorf("expected no rows for an empty slice, got %d, err: %v", n, err)
+	}
+	if n, err := sess.InsertStructs("TestInsertStructs", []*testModel{{ID: 3, CreatedAt: createdAt}, nil}); err == nil || n != 0 {
+		t.Errorf("expected an error for a nil element, got %d rows, err: %v", n, err)
+	}
+	var count int
+	if err := sess.QueryScalar("SELECT count() FROM TestInsertStructs", &count); err != nil || count != 2 {
+		t.Errorf("expected no rows inserted along a nil element, got %d rows, err: %v", count, err)
+	}
+}
+
+func TestInsertStructsDecimals(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestInsertStructsDecimals")
+	if _, err := sess.Exec("CREATE TABLE TestInsertStructsDecimals (price Decimal(10, 2), day Date) ENGINE = Memory"); err != nil {
+		t.Fatalf("create table fail, err: %s", err)
+	}
+	defer sess.Query("DROP TABLE IF EXISTS TestInsertStructsDecimals")
+	type item struct {
+		Price string    `chdb:"price"`
+		Day   time.Time `chdb:"day"`
+	}
+	if _, err := sess.InsertStructs("TestInsertStructsDecimals", []item{{"12.345", time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC)}}); err != nil {
+		t.Fatalf("InsertStructs fail, err: %s", err)
+	}
+	ret, err := sess.Query("SELECT price, day FROM TestInsertStructsDecimals", "TSVRaw")
+	if err != nil {
+		t.Fatalf("select fail, err: %s", err)
+	}
+	defer ret.Free()
+	if got := ret.String(); got != "12.35\t2024-01-02\n" {
+		t.Errorf("unexpected row %q", got)
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestLoadData")
+	if _, err := sess.Exec("CREATE TABLE TestLoadData (id UInt32, name String) ENGINE = Memory"); err != nil {
+		t.Fatalf("create table fail, err: %s", err)
+	}
+	defer sess.Query("DROP TABLE IF EXISTS TestLoadData")
+
+	n, err := sess.LoadData("TestLoadData", "CSV", strings.NewReader("1,a\n2,b\n"))
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 rows loaded from CSV, got %d, err: %v", n, err)
+	}
+	// JSONEachRow fields are matched by name
+	n, err = sess.LoadData("TestLoadData", "JSONEachRow", strings.NewReader(`{"name":"c","id":3}`+"\n"))
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 row loaded from JSONEachRow, got %d, err: %v", n, err)
+	}
+	if _, err := sess.LoadData("TestLoadData", "CSV", strings.NewReader("x,y\n")); err == nil {
+		t.Errorf("expected an error for invalid data")
+	}
+
+	ret, err := sess.Query("SELECT id, name FROM TestLoadData ORDER BY id", "TSVRaw")
+	if err != nil {
+		t.Fatalf("select fail, err: %s", err)
+	}
+	defer ret.Free()
+	if got := ret.String(); got != "1\ta\n2\tb\n3\tc\n" {
+		t.Errorf("unexpected rows %q", got)
+	}
+}
+
+func TestStagingDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		sess     *Session
+		expected string
+		err      error
+	}{
+		{&Session{path: dir}, dir, nil},
+		{&Session{path: dir, noTempFallback: true}, dir, nil},
+		{&Session{}, os.TempDir(), nil},
+		{&Session{noTempFallback: true}, "", ErrNoSessionPath},
+	} {
+		got, err := tc.sess.stagingDir()
+		if got != tc.expected || err != tc.err {
+			t.Errorf("path %q, noTempFallback %t: expected %q and %v, got %q and %v", tc.sess.path, tc.sess.noTempFallback, tc.expected, tc.err, got, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package jsonval decodes the values of ClickHouse JSON columns, for the Parquet and Native decoders
+// and for the JSON results of the database/sql driver.
+package jsonval
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Modes select how Decode decodes JSON values.
+const (
+	// String decodes JSON values as their text. It is the default.
+	String = ""
+	// Raw decodes JSON values as a json.RawMessage.
+	Raw = "raw"
+	// Map decodes JSON objects as a map[string]any, see Normalize.
+	Map = "map"
+)
+
+// Decode decodes the text of a JSON value according to mode.
+func Decode(data []byte, mode string) (any, error) {
+	switch mode {
+	case Raw:
+		return json.RawMessage(bytes.Clone(data)), nil
+	case Map:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			return nil, err
+		}
+		return Normalize(v), nil
+	}
+	return string(data), nil
+}
+
+// Type returns the Go type Decode decodes values into with the given mode.
+func Type(mode string) reflect.Type {
+	switch mode {
+	case Raw:
+		return reflect.TypeOf(json.RawMessage(nil))
+	case Map:
+		return reflect.TypeOf(map[string]any(nil))
+	}
+	return reflect.TypeOf("")
+}
+
+// Normalize converts the numbers of a JSON value decoded with json.Decoder.UseNumber to int64 when they are integers,
+// and to float64 otherwise. Arrays and objects are converted in place.
+func Normalize(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []any:
+		for i := range v {
+			v[i] = Normalize(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = Normalize(v[k])
+		}
+	}
+	return v
+}
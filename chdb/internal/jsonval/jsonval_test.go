@@ -0,0 +1,42 @@
+package jsonval
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	const text = `{"a":1,"b":[1.5,"x"],"c":{"d":null}}`
+	for _, tc := range []struct {
+		mode     string
+		expected any
+	}{
+		{String, text},
+		{Raw, json.RawMessage(text)},
+		{Map, map[string]any{"a": int64(1), "b": []any{1.5, "x"}, "c": map[string]any{"d": nil}}},
+	} {
+		got, err := Decode([]byte(text), tc.mode)
+		if err != nil {
+			t.Fatalf("%q: decode fail, err: %s", tc.mode, err)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%q: expected %#v, got %#v", tc.mode, tc.expected, got)
+		}
+		if reflect.TypeOf(got) != Type(tc.mode) {
+			t.Errorf("%q: expected a %s, got a %T", tc.mode, Type(tc.mode), got)
+		}
+	}
+	if _, err := Decode([]byte(`{"a":`), Map); err == nil {
+		t.Errorf("expected an error for invalid JSON")
+	}
+}
+
+func TestDecodeRawCopies(t *testing.T) {
+	data := []byte(`{"a":1}`)
+	got, _ := Decode(data, Raw)
+	data[2] = 'b'
+	if string(got.(json.RawMessage)) != `{"a":1}` {
+		t.Errorf("expected a copy of the data, got %s", got)
+	}
+}
@@ -0,0 +1,710 @@
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/google/uuid"
+)
+
+// column decodes the values of a column of a given type.
+type column interface {
+	// prefix reads the state written before the data of the column, e.g. the version of LowCardinality keys.
+	prefix(r *reader) error
+	// decode reads the values of n rows.
+	decode(r *reader, n int) ([]any, error)
+	// goType returns the type of the non-null values, or nil when it varies.
+	goType() reflect.Type
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bytesType  = reflect.TypeOf([]byte(nil))
+	uuidType   = reflect.TypeOf(uuid.UUID{})
+	anysType   = reflect.TypeOf([]any(nil))
+	pointType  = reflect.TypeOf([2]float64{})
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+	ratType    = reflect.TypeOf((*big.Rat)(nil))
+	addrType   = reflect.TypeOf(netip.Addr{})
+)
+
+// newColumn returns the decoder of the columns of type t.
+func newColumn(t string, opts Options) (column, error) {
+	name, args := splitType(t)
+	switch name {
+	case "Int8":
+		return fixed(1, reflect.TypeOf(int8(0)), func(b []byte) any { return int8(b[0]) }), nil
+	case "Int16":
+		return fixed(2, reflect.TypeOf(int16(0)), func(b []byte) any { return int16(binary.LittleEndian.Uint16(b)) }), nil
+	case "Int32":
+		return fixed(4, reflect.TypeOf(int32(0)), func(b []byte) any { return int32(binary.LittleEndian.Uint32(b)) }), nil
+	case "Int64", "IntervalNanosecond", "IntervalMicrosecond", "IntervalMillisecond", "IntervalSecond", "IntervalMinute",
+		"IntervalHour", "IntervalDay", "IntervalWeek", "IntervalMonth", "IntervalQuarter", "IntervalYear":
+		return fixed(8, reflect.TypeOf(int64(0)), func(b []byte) any { return int64(binary.LittleEndian.Uint64(b)) }), nil
+	case "UInt8":
+		if opts.WidenUnsigned {
+			return fixed(1, reflect.TypeOf(int16(0)), func(b []byte) any { return int16(b[0]) }), nil
+		}
+		return fixed(1, reflect.TypeOf(uint8(0)), func(b []byte) any { return b[0] }), nil
+	case "UInt16":
+		if opts.WidenUnsigned {
+			return fixed(2, reflect.TypeOf(int32(0)), func(b []byte) any { return int32(binary.LittleEndian.Uint16(b)) }), nil
+		}
+		return fixed(2, reflect.TypeOf(uint16(0)), func(b []byte) any { return binary.LittleEndian.Uint16(b) }), nil
+	case "UInt32":
+		if opts.WidenUnsigned {
+			return fixed(4, reflect.TypeOf(int64(0)), func(b []byte) any { return int64(binary.LittleEndian.Uint32(b)) }), nil
+		}
+		return fixed(4, reflect.TypeOf(uint32(0)), func(b []byte) any { return binary.LittleEndian.Uint32(b) }), nil
+	case "UInt64":
+		if opts.WidenUnsigned {
+			// widened values are int64 or uint64 depending on whether they fit
+			return fixed(8, nil, func(b []byte) any {
+				if v := binary.LittleEndian.Uint64(b); v > math.MaxInt64 {
+					return v
+				}
+				return int64(binary.LittleEndian.Uint64(b))
+			}), nil
+		}
+		return fixed(8, reflect.TypeOf(uint64(0)), func(b []byte) any { return binary.LittleEndian.Uint64(b) }), nil
+	case "Int128", "UInt128", "Int256", "UInt256":
+		size := 16
+		if strings.HasSuffix(name, "256") {
+			size = 32
+		}
+		if opts.BigIntBytes {
+			return &fixedColumn{size: size, typ: bytesType, bytes: true}, nil
+		}
+		signed := name[0] == 'I'
+		return fixed(size, bigIntType, func(b []byte) any { return BigInt(b, signed) }), nil
+	case "Float32":
+		return fixed(4, reflect.TypeOf(float32(0)), func(b []byte) any { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }), nil
+	case "Float64":
+		return fixed(8, reflect.TypeOf(float64(0)), func(b []byte) any { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }), nil
+	case "Bool":
+		return fixed(1, reflect.TypeOf(false), func(b []byte) any { return b[0] != 0 }), nil
+	case "String":
+		return &stringColumn{}, nil
+	case "JSON", "Object":
+		return &jsonColumn{mode: opts.JSONAs}, nil
+	case "FixedString":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid type %s: %w", t, err)
+		}
+		return &fixedColumn{size: n, typ: bytesType, bytes: true}, nil
+	case "UUID":
+		if opts.UUIDStrings {
+			return fixed(16, reflect.TypeOf(""), func(b []byte) any { return readUUID(b).String() }), nil
+		}
+		return fixed(16, uuidType, func(b []byte) any { return readUUID(b) }), nil
+	case "IPv4":
+		if opts.IPStrings {
+			return fixed(4, reflect.TypeOf(""), func(b []byte) any { return IPv4(binary.LittleEndian.Uint32(b)).String() }), nil
+		}
+		return fixed(4, addrType, func(b []byte) any { return IPv4(binary.LittleEndian.Uint32(b)) }), nil
+	case "IPv6":
+		if opts.IPStrings {
+			return fixed(16, reflect.TypeOf(""), func(b []byte) any { return netip.AddrFrom16([16]byte(b)).String() }), nil
+		}
+		return fixed(16, addrType, func(b []byte) any { return netip.AddrFrom16([16]byte(b)) }), nil
+	case "Date":
+		return fixed(2, timeType, func(b []byte) any {
+			return time.Unix(int64(binary.LittleEndian.Uint16(b))*86400, 0).UTC()
+		}), nil
+	case "Date32":
+		return fixed(4, timeType, func(b []byte) any {
+			return time.Unix(int64(int32(binary.LittleEndian.Uint32(b)))*86400, 0).UTC()
+		}), nil
+	case "DateTime":
+		loc, err := columnLocation(args, opts)
+		if err != nil {
+			return nil, err
+		}
+		return fixed(4, timeType, func(b []byte) any { return time.Unix(int64(binary.LittleEndian.Uint32(b)), 0).In(loc) }), nil
+	case "DateTime64":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		precision, err := strconv.Atoi(args[0])
+		if err != nil || precision < 0 || precision > 9 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		loc, err := columnLocation(args[1:], opts)
+		if err != nil {
+			return nil, err
+		}
+		scale := int64(math.Pow10(precision))
+		return fixed(8, timeType, func(b []byte) any {
+			// split the ticks rather than converting them to nanoseconds, which would overflow for dates after 2262
+			ticks := int64(binary.LittleEndian.Uint64(b))
+			sec, frac := ticks/scale, ticks%scale
+			if frac < 0 {
+				sec, frac = sec-1, frac+scale
+			}
+			return time.Unix(sec, frac*(1e9/scale)).In(loc)
+		}), nil
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		size, scale, err := decimalSize(name, args)
+		if err != nil {
+			return nil, err
+		}
+		if opts.DecimalStrings {
+			// the strings keep the exact value and, unlike *big.Rat values, scan into numeric types
+			return fixed(size, reflect.TypeOf(""), func(b []byte) any { return formatDecimal(BigInt(b, true), scale) }), nil
+		}
+		return fixed(size, ratType, func(b []byte) any { return decimalRat(BigInt(b, true), scale) }), nil
+	case "Enum8", "Enum16":
+		values, err := parseEnum(args)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name == "Enum8" && opts.EnumNames:
+			return fixed(1, reflect.TypeOf(""), func(b []byte) any { return values[int16(int8(b[0]))] }), nil
+		case name == "Enum8":
+			return fixed(1, reflect.TypeOf(int8(0)), func(b []byte) any { return int8(b[0]) }), nil
+		case opts.EnumNames:
+			return fixed(2, reflect.TypeOf(""), func(b []byte) any { return values[int16(binary.LittleEndian.Uint16(b))] }), nil
+		}
+		return fixed(2, reflect.TypeOf(int16(0)), func(b []byte) any { return int16(binary.LittleEndian.Uint16(b)) }), nil
+	case "Nothing":
+		return fixed(1, nil, func([]byte) any { return nil }), nil
+	case "Nullable":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		nested, err := newColumn(args[0], opts)
+		if err != nil {
+			return nil, err
+		}
+		return &nullableColumn{nested: nested}, nil
+	case "LowCardinality":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		keysType := args[0]
+		nullable := false
+		if keysName, keysArgs := splitType(keysType); keysName == "Nullable" && len(keysArgs) == 1 {
+			keysType, nullable = keysArgs[0], true
+		}
+		keys, err := newColumn(keysType, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &lowCardinalityColumn{keys: keys, nullable: nullable}, nil
+	case "SimpleAggregateFunction":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		return newColumn(args[1], opts)
+	case "Array":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		elem, err := newColumn(args[0], opts)
+		if err != nil {
+			return nil, err
+		}
+		return newArrayColumn(elem, false), nil
+	case "Tuple", "Nested":
+		elems := make([]column, len(args))
+		for i, arg := range args {
+			_, typ := tupleElement(arg)
+			c, err := newColumn(typ, opts)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = c
+		}
+		if name == "Nested" {
+			// Nested columns are stored like arrays of tuples
+			return newArrayColumn(&tupleColumn{elems: elems}, false), nil
+		}
+		return &tupleColumn{elems: elems}, nil
+	case "Map":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid type %s", t)
+		}
+		key, err := newColumn(args[0], opts)
+		if err != nil {
+			return nil, err
+		}
+		value, err := newColumn(args[1], opts)
+		if err != nil {
+			return nil, err
+		}
+		return newMapColumn(key, value), nil
+	case "Point":
+		return &tupleColumn{elems: []column{mustColumn("Float64"), mustColumn("Float64")}, point: true}, nil
+	case "Ring", "LineString":
+		return newArrayColumn(mustColumn("Point"), true), nil
+	case "Polygon", "MultiLineString":
+		return newArrayColumn(mustColumn("Ring"), true), nil
+	case "MultiPolygon":
+		return newArrayColumn(mustColumn("Polygon"), true), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func mustColumn(t string) column {
+	c, err := newColumn(t, Options{})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// nullable reports whether the values of a column may be NULL.
+func nullable(c column) bool {
+	switch c := c.(type) {
+	case *nullableColumn:
+		return true
+	case *lowCardinalityColumn:
+		return c.nullable
+	}
+	return false
+}
+
+// BigInt decodes a little endian integer, in two's complement when signed, which is how ClickHouse stores the values
+// of Int128, UInt128, Int256 and UInt256 columns.
+func BigInt(b []byte, signed bool) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	v := new(big.Int).SetBytes(be)
+	if signed && len(b) > 0 && b[len(b)-1]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return v
+}
+
+// IPv4 returns the address of an IPv4 value, which ClickHouse stores as a UInt32.
+func IPv4(v uint32) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+// readUUID reads a UUID, stored as two little endian halves, the most significant one first.
+func readUUID(b []byte) uuid.UUID {
+	var u uuid.UUID
+	binary.BigEndian.PutUint64(u[:8], binary.LittleEndian.Uint64(b[:8]))
+	binary.BigEndian.PutUint64(u[8:], binary.LittleEndian.Uint64(b[8:]))
+	return u
+}
+
+// decimalRat returns the value of an unscaled decimal value with scale fractional digits.
+func decimalRat(v *big.Int, scale int) *big.Rat {
+	return new(big.Rat).SetFrac(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+}
+
+// formatDecimal formats an unscaled decimal value with scale fractional digits.
+func formatDecimal(v *big.Int, scale int) string {
+	digits := new(big.Int).Abs(v).String()
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+	}
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// fixedColumn decodes values of a fixed size.
+type fixedColumn struct {
+	size  int
+	typ   reflect.Type
+	read  func([]byte) any
+	bytes bool // values are returned as byte slices, e.g. for FixedString
+}
+
+func fixed(size int, typ reflect.Type, read func([]byte) any) *fixedColumn {
+	return &fixedColumn{size: size, typ: typ, read: read}
+}
+
+func (c *fixedColumn) prefix(*reader) error { return nil }
+
+func (c *fixedColumn) decode(r *reader, n int) ([]any, error) {
+	data, err := r.bytes(n * c.size)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, n)
+	for i := range out {
+		b := data[i*c.size : (i+1)*c.size : (i+1)*c.size]
+		switch {
+		case c.bytes && r.unsafe:
+			out[i] = b
+		case c.bytes:
+			out[i] = bytes.Clone(b)
+		default:
+			out[i] = c.read(b)
+		}
+	}
+	return out, nil
+}
+
+func (c *fixedColumn) goType() reflect.Type { return c.typ }
+
+type stringColumn struct{}
+
+func (c *stringColumn) prefix(*reader) error { return nil }
+
+func (c *stringColumn) decode(r *reader, n int) ([]any, error) {
+	out := make([]any, n)
+	for i := range out {
+		s, err := r.string(false)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+func (c *stringColumn) goType() reflect.Type { return reflect.TypeOf("") }
+
+// jsonColumn decodes JSON values serialized as length prefixed strings, as selected by a jsonval mode.
+type jsonColumn struct {
+	mode string
+}
+
+func (c *jsonColumn) prefix(*reader) error { return nil }
+
+func (c *jsonColumn) decode(r *reader, n int) ([]any, error) {
+	out := make([]any, n)
+	for i := range out {
+		size, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(len(r.buf)) {
+			return nil, ErrTruncated
+		}
+		b, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		// the decoded values never reference the buffer
+		if out[i], err = jsonval.Decode(b, c.mode); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (c *jsonColumn) goType() reflect.Type { return jsonval.Type(c.mode) }
+
+// nullableColumn decodes a map of NULL flags followed by the values of the nested column.
+type nullableColumn struct {
+	nested column
+}
+
+func (c *nullableColumn) prefix(r *reader) error { return c.nested.prefix(r) }
+
+func (c *nullableColumn) decode(r *reader, n int) ([]any, error) {
+	nulls, err := r.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.nested.decode(r, n)
+	if err != nil {
+		return nil, err
+	}
+	for i, null := range nulls {
+		if null != 0 {
+			out[i] = nil
+		}
+	}
+	return out, nil
+}
+
+func (c *nullableColumn) goType() reflect.Type { return c.nested.goType() }
+
+// arrayColumn decodes the offsets of the arrays of n rows followed by the values of all their elements.
+type arrayColumn struct {
+	elem     column
+	elemType reflect.Type // type of the elements of typed slices, nil for []any
+	geo      bool
+}
+
+func newArrayColumn(elem column, geo bool) *arrayColumn {
+	c := &arrayColumn{elem: elem, geo: geo}
+	t := elem.goType()
+	if inner, ok := elem.(*arrayColumn); ok && inner.geo {
+		c.elemType = t
+	} else if t != nil && (t.Kind() != reflect.Slice || t == bytesType) {
+		c.elemType = t
+		if nullable(elem) && t.Kind() != reflect.Pointer {
+			// nullable elements of typed slices are stored as pointers, leaving nil for NULL
+			c.elemType = reflect.PointerTo(t)
+		}
+	}
+	return c
+}
+
+func (c *arrayColumn) prefix(r *reader) error { return c.elem.prefix(r) }
+
+func (c *arrayColumn) decode(r *reader, n int) ([]any, error) {
+	offsets := make([]uint64, n)
+	for i := range offsets {
+		o, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && o < offsets[i-1] || o > uint64(len(r.buf)) {
+			return nil, fmt.Errorf("invalid array offset %d", o)
+		}
+		offsets[i] = o
+	}
+	var total int
+	if n > 0 {
+		total = int(offsets[n-1])
+	}
+	elems, err := c.elem.decode(r, total)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, n)
+	start := 0
+	for i, o := range offsets {
+		values := elems[start:int(o)]
+		start = int(o)
+		if c.elemType == nil {
+			out[i] = values
+			continue
+		}
+		slice := reflect.MakeSlice(reflect.SliceOf(c.elemType), len(values), len(values))
+		for j, v := range values {
+			if v == nil {
+				continue
+			}
+			dst := slice.Index(j)
+			if dst.Kind() == reflect.Pointer && dst.Type() != reflect.TypeOf(v) {
+				p := reflect.New(dst.Type().Elem())
+				dst.Set(p)
+				dst = p.Elem()
+			}
+			dst.Set(reflect.ValueOf(v))
+		}
+		out[i] = slice.Interface()
+	}
+	return out, nil
+}
+
+func (c *arrayColumn) goType() reflect.Type {
+	if c.elemType == nil {
+		return anysType
+	}
+	return reflect.SliceOf(c.elemType)
+}
+
+// mapColumn decodes Map columns, stored like arrays of (key, value) tuples, into maps: typed maps when the types of
+// the keys and the values are known, e.g. map[string]uint8 for Map(String, UInt8), and map[K]any otherwise.
+// Keys which can't be map keys, e.g. the []byte of FixedString keys, are converted to strings.
+type mapColumn struct {
+	pairs *arrayColumn
+	typ   reflect.Type
+}
+
+func newMapColumn(key, value column) *mapColumn {
+	keyType := key.goType()
+	if keyType == nil || !keyType.Comparable() {
+		keyType = reflect.TypeOf("")
+	}
+	valueType := value.goType()
+	if valueType == nil {
+		valueType = reflect.TypeOf((*any)(nil)).Elem()
+	} else if nullable(value) && valueType.Kind() != reflect.Slice && valueType.Kind() != reflect.Pointer {
+		valueType = reflect.PointerTo(valueType)
+	}
+	return &mapColumn{
+		pairs: &arrayColumn{elem: &tupleColumn{elems: []column{key, value}}},
+		typ:   reflect.MapOf(keyType, valueType),
+	}
+}
+
+func (c *mapColumn) prefix(r *reader) error { return c.pairs.prefix(r) }
+
+func (c *mapColumn) decode(r *reader, n int) ([]any, error) {
+	arrays, err := c.pairs.decode(r, n)
+	if err != nil {
+		return nil, err
+	}
+	for i, array := range arrays {
+		pairs := array.([]any)
+		m := reflect.MakeMapWithSize(c.typ, len(pairs))
+		for _, pair := range pairs {
+			kv := pair.([]any)
+			key := reflect.ValueOf(kv[0])
+			if c.typ.Key().Kind() == reflect.String && key.Kind() != reflect.String {
+				if b, ok := kv[0].([]byte); ok {
+					key = reflect.ValueOf(string(b))
+				} else {
+					key = reflect.ValueOf(fmt.Sprint(kv[0]))
+				}
+			}
+			value := reflect.New(c.typ.Elem()).Elem()
+			if kv[1] != nil {
+				if value.Kind() == reflect.Pointer && value.Type() != reflect.TypeOf(kv[1]) {
+					value.Set(reflect.New(value.Type().Elem()))
+					value.Elem().Set(reflect.ValueOf(kv[1]))
+				} else {
+					value.Set(reflect.ValueOf(kv[1]))
+				}
+			}
+			m.SetMapIndex(key, value)
+		}
+		arrays[i] = m.Interface()
+	}
+	return arrays, nil
+}
+
+func (c *mapColumn) goType() reflect.Type { return c.typ }
+
+// tupleColumn decodes the values of every element column one after the other.
+type tupleColumn struct {
+	elems []column
+	point bool // Point tuples are decoded as [2]float64
+}
+
+func (c *tupleColumn) prefix(r *reader) error {
+	for _, e := range c.elems {
+		if err := e.prefix(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *tupleColumn) decode(r *reader, n int) ([]any, error) {
+	columns := make([][]any, len(c.elems))
+	for i, e := range c.elems {
+		values, err := e.decode(r, n)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = values
+	}
+	out := make([]any, n)
+	for i := range out {
+		if c.point {
+			out[i] = [2]float64{columns[0][i].(float64), columns[1][i].(float64)}
+			continue
+		}
+		row := make([]any, len(columns))
+		for j := range columns {
+			row[j] = columns[j][i]
+		}
+		out[i] = row
+	}
+	return out, nil
+}
+
+func (c *tupleColumn) goType() reflect.Type {
+	if c.point {
+		return pointType
+	}
+	return anysType
+}
+
+// LowCardinality serialization flags, see SerializationLowCardinality in ClickHouse.
+const (
+	lowCardinalityKeysVersion    = 1
+	lowCardinalityIndexTypeMask  = 0xff
+	lowCardinalityGlobalDict     = 1 << 8
+	lowCardinalityAdditionalKeys = 1 << 9
+)
+
+// lowCardinalityColumn decodes dictionary encoded values: a dictionary of keys followed by the index of every value.
+type lowCardinalityColumn struct {
+	keys     column
+	nullable bool // the key at index 0 stands for NULL
+}
+
+func (c *lowCardinalityColumn) prefix(r *reader) error {
+	version, err := r.uint64()
+	if err != nil {
+		return err
+	}
+	if version != lowCardinalityKeysVersion {
+		return fmt.Errorf("unsupported LowCardinality keys version %d", version)
+	}
+	return nil
+}
+
+func (c *lowCardinalityColumn) decode(r *reader, n int) ([]any, error) {
+	out := make([]any, 0, n)
+	for len(out) < n {
+		flags, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		if flags&lowCardinalityGlobalDict != 0 || flags&lowCardinalityAdditionalKeys == 0 {
+			return nil, fmt.Errorf("unsupported LowCardinality serialization %#x", flags)
+		}
+		numKeys, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		if numKeys > uint64(len(r.buf)) {
+			return nil, ErrTruncated
+		}
+		keys, err := c.keys.decode(r, int(numKeys))
+		if err != nil {
+			return nil, err
+		}
+		numIndexes, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		if numIndexes == 0 || numIndexes > uint64(n-len(out)) {
+			return nil, fmt.Errorf("invalid LowCardinality index count %d", numIndexes)
+		}
+		size := 1 << (flags & lowCardinalityIndexTypeMask)
+		data, err := r.bytes(int(numIndexes) * size)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < int(numIndexes); i++ {
+			var index uint64
+			switch size {
+			case 1:
+				index = uint64(data[i])
+			case 2:
+				index = uint64(binary.LittleEndian.Uint16(data[i*2:]))
+			case 4:
+				index = uint64(binary.LittleEndian.Uint32(data[i*4:]))
+			default:
+				index = binary.LittleEndian.Uint64(data[i*8:])
+			}
+			if index >= uint64(len(keys)) {
+				return nil, fmt.Errorf("invalid LowCardinality index %d", index)
+			}
+			if c.nullable && index == 0 {
+				out = append(out, nil)
+				continue
+			}
+			out = append(out, keys[index])
+		}
+	}
+	return out, nil
+}
+
+func (c *lowCardinalityColumn) goType() reflect.Type { return c.keys.goType() }
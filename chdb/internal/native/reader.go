@@ -0,0 +1,171 @@
+// Package native decodes results in the ClickHouse Native format, as written by the Native output format, and
+// encodes blocks for its input format: a sequence of blocks, each of them made of the values of its columns stored
+// one column after the other.
+package native
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"unsafe"
+)
+
+// ErrTruncated is returned when the buffer ends in the middle of a block.
+var ErrTruncated = errors.New("truncated Native block")
+
+// Options configures the decoding of the values.
+type Options struct {
+	// UnsafeStrings makes decoded strings and byte slices reference the buffer instead of copying it.
+	// Such values are only valid until the buffer is released.
+	UnsafeStrings bool
+	// WidenUnsigned decodes unsigned integers as the next larger signed type, e.g. uint32 as int64.
+	// UInt64 values are decoded as int64 when they fit, and are kept as uint64 otherwise.
+	WidenUnsigned bool
+	// UUIDStrings decodes UUID values as their canonical string instead of a uuid.UUID.
+	UUIDStrings bool
+	// IPStrings decodes IPv4 and IPv6 values as their text form instead of a netip.Addr.
+	IPStrings bool
+	// EnumNames decodes Enum8 and Enum16 values as their names instead of their int8 and int16 values.
+	EnumNames bool
+	// DecimalStrings decodes Decimal values as their exact decimal text, e.g. "-12.34", instead of a *big.Rat.
+	DecimalStrings bool
+	// BigIntBytes decodes Int128, UInt128, Int256 and UInt256 values as their raw little endian bytes instead of
+	// a *big.Int.
+	BigIntBytes bool
+	// JSONAs is the jsonval mode of the values of JSON and Object columns, which must be serialized as strings, as
+	// ClickHouse writes them with the output_format_native_write_json_as_string setting.
+	JSONAs string
+	// Location is the time zone of the DateTime and DateTime64 values of columns declared without one, UTC when nil.
+	// Values of columns declared with a time zone, e.g. DateTime('Europe/Paris'), are returned in it.
+	Location *time.Location
+}
+
+// Block holds the decoded values of a block, column by column.
+type Block struct {
+	// Names of the columns.
+	Names []string
+	// ClickHouse types of the columns, e.g. "Nullable(UInt32)".
+	Types []string
+	// Rows is the number of rows of the block.
+	Rows int
+	// Columns holds the values of every column, with NULL as nil.
+	Columns [][]any
+}
+
+// Reader reads the blocks of a Native buffer.
+type Reader struct {
+	r       reader
+	opts    Options
+	columns map[string]column
+}
+
+// NewReader returns a reader of the blocks of buf.
+func NewReader(buf []byte, opts Options) *Reader {
+	return &Reader{r: reader{buf: buf, unsafe: opts.UnsafeStrings}, opts: opts, columns: map[string]column{}}
+}
+
+// Next decodes the next block, returning io.EOF once the buffer is exhausted.
+func (br *Reader) Next() (*Block, error) {
+	r := &br.r
+	if r.off == len(r.buf) {
+		return nil, io.EOF
+	}
+	numColumns, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	numRows, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if numRows > math.MaxInt32 || numColumns > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid Native block of %d columns and %d rows", numColumns, numRows)
+	}
+	b := &Block{
+		Names:   make([]string, numColumns),
+		Types:   make([]string, numColumns),
+		Rows:    int(numRows),
+		Columns: make([][]any, numColumns),
+	}
+	for i := range b.Columns {
+		if b.Names[i], err = r.string(true); err != nil {
+			return nil, err
+		}
+		if b.Types[i], err = r.string(true); err != nil {
+			return nil, err
+		}
+		c, ok := br.columns[b.Types[i]]
+		if !ok {
+			if c, err = newColumn(b.Types[i], br.opts); err != nil {
+				return nil, fmt.Errorf("column %s: %w", b.Names[i], err)
+			}
+			br.columns[b.Types[i]] = c
+		}
+		// columns without rows have no data at all
+		if b.Rows == 0 {
+			continue
+		}
+		if err := c.prefix(r); err != nil {
+			return nil, fmt.Errorf("column %s: %w", b.Names[i], err)
+		}
+		if b.Columns[i], err = c.decode(r, b.Rows); err != nil {
+			return nil, fmt.Errorf("column %s: %w", b.Names[i], err)
+		}
+	}
+	return b, nil
+}
+
+// reader reads the primitive values of a Native buffer.
+type reader struct {
+	buf    []byte
+	off    int
+	unsafe bool
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || len(r.buf)-r.off < n {
+		return nil, ErrTruncated
+	}
+	b := r.buf[r.off : r.off+n : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+func (r *reader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.off:])
+	if n <= 0 {
+		return 0, ErrTruncated
+	}
+	r.off += n
+	return v, nil
+}
+
+func (r *reader) uint64() (uint64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// string reads a length prefixed string, copied unless the reader is unsafe and clone is false.
+func (r *reader) string(clone bool) (string, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(len(r.buf)) {
+		return "", ErrTruncated
+	}
+	b, err := r.bytes(int(n))
+	if err != nil {
+		return "", err
+	}
+	if r.unsafe && !clone {
+		return unsafe.String(unsafe.SliceData(b), len(b)), nil
+	}
+	return string(b), nil
+}
@@ -0,0 +1,391 @@
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/google/uuid"
+)
+
+// blockWriter encodes Native blocks for the tests.
+type blockWriter struct {
+	buf []byte
+}
+
+func (w *blockWriter) uvarint(v uint64) *blockWriter {
+	w.buf = binary.AppendUvarint(w.buf, v)
+	return w
+}
+
+func (w *blockWriter) string(s string) *blockWriter {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+	return w
+}
+
+func (w *blockWriter) raw(b ...byte) *blockWriter {
+	w.buf = append(w.buf, b...)
+	return w
+}
+
+func (w *blockWriter) uint64(vs ...uint64) *blockWriter {
+	for _, v := range vs {
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, v)
+	}
+	return w
+}
+
+func (w *blockWriter) uint32(vs ...uint32) *blockWriter {
+	for _, v := range vs {
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+	}
+	return w
+}
+
+func (w *blockWriter) header(columns, rows int) *blockWriter {
+	return w.uvarint(uint64(columns)).uvarint(uint64(rows))
+}
+
+func (w *blockWriter) column(name, typ string) *blockWriter {
+	return w.string(name).string(typ)
+}
+
+func TestReader(t *testing.T) {
+	w := &blockWriter{}
+	w.header(9, 2)
+	w.column("id", "UInt32").uint32(1, 2)
+	w.column("name", "Nullable(String)").raw(0, 1).string("a").string("")
+	// the offsets of the arrays are followed by the null map of all the elements, then by their values
+	w.column("tags", "Array(Nullable(String))").uint64(2, 3).raw(0, 1, 0).string("x").string("").string("y")
+	w.column("price", "Decimal(9, 2)").uint32(1234, uint32(0xffffffff)) // 12.34 and -0.01
+	w.column("ts", "DateTime64(3, 'UTC')").uint64(1704164645006, 0)
+	w.column("pair", "Tuple(a Int8, b String)").raw(1, 0xff).string("p").string("q")
+	w.column("m", "Map(String, UInt32)").uint64(1, 1).string("k").uint32(7)
+	w.column("lc", "LowCardinality(Nullable(String))").
+		uint64(lowCardinalityKeysVersion).
+		uint64(lowCardinalityAdditionalKeys). // UInt8 indexes
+		uint64(2).string("").string("v").
+		uint64(2).raw(1, 0)
+	w.column("e", "Enum8('a' = 1, 'b=c' = -2)").raw(1, 0xfe)
+	// a second block, read by the next call
+	w.header(9, 0)
+	for _, c := range [][2]string{
+		{"id", "UInt32"}, {"name", "Nullable(String)"}, {"tags", "Array(Nullable(String))"}, {"price", "Decimal(9, 2)"},
+		{"ts", "DateTime64(3, 'UTC')"}, {"pair", "Tuple(a Int8, b String)"}, {"m", "Map(String, UInt32)"},
+		{"lc", "LowCardinality(Nullable(String))"}, {"e", "Enum8('a' = 1, 'b=c' = -2)"},
+	} {
+		w.column(c[0], c[1])
+	}
+
+	r := NewReader(w.buf, Options{EnumNames: true, DecimalStrings: true})
+	b, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if b.Rows != 2 || !reflect.DeepEqual(b.Names, []string{"id", "name", "tags", "price", "ts", "pair", "m", "lc", "e"}) {
+		t.Fatalf("unexpected block %d rows, columns %v", b.Rows, b.Names)
+	}
+	x, y := "x", "y"
+	expected := [][]any{
+		{uint32(1), uint32(2)},
+		{"a", nil},
+		{[]*string{&x, nil}, []*string{&y}},
+		{"12.34", "-0.01"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC), time.Unix(0, 0).UTC()},
+		{[]any{int8(1), "p"}, []any{int8(-1), "q"}},
+		{map[string]uint32{"k": 7}, map[string]uint32{}},
+		{"v", nil},
+		{"a", "b=c"},
+	}
+	for i, column := range expected {
+		if !reflect.DeepEqual(b.Columns[i], column) {
+			t.Errorf("column %s: expected %#v, got %#v", b.Names[i], column, b.Columns[i])
+		}
+	}
+
+	b, err = r.Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if b.Rows != 0 || len(b.Names) != 9 {
+		t.Errorf("expected an empty block of 9 columns, got %d rows and %v", b.Rows, b.Names)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	if _, err := NewReader(w.buf[:len(w.buf)/2], Options{}).Next(); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+}
+
+func TestReaderEnums(t *testing.T) {
+	w := &blockWriter{}
+	w.header(2, 1)
+	w.column("e8", "Enum8('a' = 1, 'b' = -2)").raw(0xfe)
+	w.column("e16", "Enum16('x' = 1000)").raw(0xe8, 0x03)
+	b, err := NewReader(w.buf, Options{}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns, [][]any{{int8(-2)}, {int16(1000)}}) {
+		t.Errorf("expected the enum values, got %#v", b.Columns)
+	}
+	b, err = NewReader(w.buf, Options{EnumNames: true}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns, [][]any{{"b"}, {"x"}}) {
+		t.Errorf("expected the enum names, got %#v", b.Columns)
+	}
+}
+
+func TestReaderIPs(t *testing.T) {
+	w := &blockWriter{}
+	w.header(2, 1)
+	w.column("v4", "IPv4").uint32(0xc0a80001)
+	w.column("v6", "Nullable(IPv6)").raw(0).raw(netip.MustParseAddr("2001:db8::1").AsSlice()...)
+	b, err := NewReader(w.buf, Options{}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	expected := [][]any{{netip.MustParseAddr("192.168.0.1")}, {netip.MustParseAddr("2001:db8::1")}}
+	if !reflect.DeepEqual(b.Columns, expected) {
+		t.Errorf("expected %v, got %#v", expected, b.Columns)
+	}
+	b, err = NewReader(w.buf, Options{IPStrings: true}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns, [][]any{{"192.168.0.1"}, {"2001:db8::1"}}) {
+		t.Errorf("expected the text of the addresses, got %#v", b.Columns)
+	}
+	if got := ScanType("Nullable(IPv6)", Options{}); got != reflect.TypeOf((*netip.Addr)(nil)) {
+		t.Errorf("expected *netip.Addr scan type, got %s", got)
+	}
+}
+
+func TestReaderWidenUnsigned(t *testing.T) {
+	w := &blockWriter{}
+	w.header(2, 2)
+	w.column("small", "UInt32").uint32(1, math.MaxUint32)
+	w.column("big", "UInt64").uint64(1, math.MaxUint64)
+
+	b, err := NewReader(w.buf, Options{WidenUnsigned: true}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns[0], []any{int64(1), int64(math.MaxUint32)}) {
+		t.Errorf("expected widened UInt32 values, got %#v", b.Columns[0])
+	}
+	if !reflect.DeepEqual(b.Columns[1], []any{int64(1), uint64(math.MaxUint64)}) {
+		t.Errorf("expected widened UInt64 values, got %#v", b.Columns[1])
+	}
+}
+
+func TestReaderTimeZones(t *testing.T) {
+	tokyo, err := Location("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %s", err)
+	}
+	newYork, err := Location("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %s", err)
+	}
+	w := &blockWriter{}
+	w.header(3, 1)
+	w.column("local", "DateTime").uint32(1704164645)
+	w.column("tokyo", "DateTime64(3, 'Asia/Tokyo')").uint64(1704164645006)
+	// 2299-12-31 23:59:59.123456, past the nanoseconds of an int64
+	w.column("far", "DateTime64(6)").uint64(10413791999123456)
+	b, err := NewReader(w.buf, Options{Location: newYork}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	for i, expected := range []time.Time{
+		time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).In(newYork),
+		time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC).In(tokyo),
+		time.Date(2299, 12, 31, 23, 59, 59, 123456000, time.UTC).In(newYork),
+	} {
+		got := b.Columns[i][0].(time.Time)
+		if !got.Equal(expected) || got.Location() != expected.Location() {
+			t.Errorf("column %s: expected %v, got %v", b.Names[i], expected, got)
+		}
+	}
+
+	if _, err := newColumn("DateTime('Not/AZone')", Options{}); err == nil {
+		t.Errorf("expected an error for an unknown time zone")
+	}
+}
+
+func TestReaderBigInts(t *testing.T) {
+	w := &blockWriter{}
+	w.header(3, 1)
+	w.column("i", "Int128").uint64(math.MaxUint64, math.MaxUint64) // -1
+	w.column("u", "UInt256").uint64(0, 1, 0, 0)                    // 2^64
+	w.column("a", "Array(Nullable(Int128))").uint64(2).raw(0, 1).uint64(5, 0, 0, 0)
+	b, err := NewReader(w.buf, Options{}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	expected := []any{big.NewInt(-1), new(big.Int).Lsh(big.NewInt(1), 64), []*big.Int{big.NewInt(5), nil}}
+	for i, v := range expected {
+		if !reflect.DeepEqual(b.Columns[i][0], v) {
+			t.Errorf("column %s: expected %v, got %#v", b.Names[i], v, b.Columns[i][0])
+		}
+	}
+
+	b, err = NewReader(w.buf, Options{BigIntBytes: true}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if raw := b.Columns[0][0]; !reflect.DeepEqual(raw, bytes.Repeat([]byte{0xff}, 16)) {
+		t.Errorf("expected the raw bytes of -1, got %#v", raw)
+	}
+}
+
+func TestReaderDecimals(t *testing.T) {
+	w := &blockWriter{}
+	w.header(1, 2).column("price", "Decimal(9, 2)").uint32(1234, uint32(0xffffffff)) // 12.34 and -0.01
+	b, err := NewReader(w.buf, Options{}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	for i, expected := range []*big.Rat{big.NewRat(1234, 100), big.NewRat(-1, 100)} {
+		if r, ok := b.Columns[0][i].(*big.Rat); !ok || r.Cmp(expected) != 0 {
+			t.Errorf("row %d: expected %s, got %#v", i, expected.FloatString(2), b.Columns[0][i])
+		}
+	}
+	if got := ScanType("Decimal(9, 2)", Options{}); got != reflect.TypeOf((*big.Rat)(nil)) {
+		t.Errorf("expected *big.Rat scan type, got %s", got)
+	}
+	b, err = NewReader(w.buf, Options{DecimalStrings: true}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns[0], []any{"12.34", "-0.01"}) {
+		t.Errorf("expected the decimal strings, got %#v", b.Columns[0])
+	}
+	if got := ScanType("Decimal(9, 2)", Options{DecimalStrings: true}); got != reflect.TypeOf("") {
+		t.Errorf("expected string scan type, got %s", got)
+	}
+}
+
+func TestReaderJSON(t *testing.T) {
+	w := &blockWriter{}
+	w.header(1, 2).column("j", "JSON").string(`{"a":1,"b":[0.5]}`).string(`{}`)
+	for mode, expected := range map[string][]any{
+		jsonval.String: {`{"a":1,"b":[0.5]}`, `{}`},
+		jsonval.Raw:    {json.RawMessage(`{"a":1,"b":[0.5]}`), json.RawMessage(`{}`)},
+		jsonval.Map:    {map[string]any{"a": int64(1), "b": []any{0.5}}, map[string]any{}},
+	} {
+		b, err := NewReader(w.buf, Options{JSONAs: mode}).Next()
+		if err != nil {
+			t.Fatalf("%q: Next fail, err: %s", mode, err)
+		}
+		if !reflect.DeepEqual(b.Columns[0], expected) {
+			t.Errorf("%q: expected %#v, got %#v", mode, expected, b.Columns[0])
+		}
+		if got := ScanType("JSON", Options{JSONAs: mode}); got != jsonval.Type(mode) {
+			t.Errorf("%q: expected %s scan type, got %s", mode, jsonval.Type(mode), got)
+		}
+	}
+}
+
+func TestReaderUUID(t *testing.T) {
+	u := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	w := &blockWriter{}
+	// the halves of a UUID are little endian
+	w.header(1, 1).column("id", "UUID").uint64(binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:]))
+
+	b, err := NewReader(w.buf, Options{}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns[0], []any{u}) {
+		t.Errorf("expected %v, got %#v", u, b.Columns[0])
+	}
+	b, err = NewReader(w.buf, Options{UUIDStrings: true}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(b.Columns[0], []any{u.String()}) {
+		t.Errorf("expected %q, got %#v", u.String(), b.Columns[0])
+	}
+}
+
+func TestReaderNested(t *testing.T) {
+	w := &blockWriter{}
+	w.header(1, 2)
+	// Nested columns are stored like arrays of tuples: the offsets, then the values of every element
+	w.column("n", "Nested(id UInt32, `full name` String)").uint64(2, 2).uint32(1, 2).string("a").string("b")
+	b, err := NewReader(w.buf, Options{}).Next()
+	if err != nil {
+		t.Fatalf("Next fail, err: %s", err)
+	}
+	expected := []any{[]any{[]any{uint32(1), "a"}, []any{uint32(2), "b"}}, []any{}}
+	if !reflect.DeepEqual(b.Columns[0], expected) {
+		t.Errorf("expected %#v, got %#v", expected, b.Columns[0])
+	}
+}
+
+func TestTypes(t *testing.T) {
+	if name, args := splitType("Map(String, Tuple(a Int8, b Enum8('x,y' = 1)))"); name != "Map" || !reflect.DeepEqual(args, []string{"String", "Tuple(a Int8, b Enum8('x,y' = 1))"}) {
+		t.Errorf("unexpected split %s %v", name, args)
+	}
+	for typ, expected := range map[string]reflect.Type{
+		"Nullable(UInt8)":                  reflect.TypeOf((*uint8)(nil)),
+		"LowCardinality(Nullable(String))": reflect.TypeOf((*string)(nil)),
+		"Array(Nullable(Int64))":           reflect.TypeOf([]*int64(nil)),
+		"Array(Array(Int64))":              reflect.TypeOf([]any(nil)),
+		"Ring":                             reflect.TypeOf([][2]float64(nil)),
+		"Polygon":                          reflect.TypeOf([][][2]float64(nil)),
+		"Decimal(38, 10)":                  reflect.TypeOf((*big.Rat)(nil)),
+		"Map(String, Nullable(UInt8))":     reflect.TypeOf(map[string]*uint8(nil)),
+		"Map(FixedString(2), Array(Int8))": reflect.TypeOf(map[string][]int8(nil)),
+		"Variant(String, UInt8)":           reflect.TypeOf((*any)(nil)).Elem(),
+		"Nullable(UInt256)":                reflect.TypeOf((*big.Int)(nil)),
+		"Array(Nullable(Int128))":          reflect.TypeOf([]*big.Int(nil)),
+	} {
+		if got := ScanType(typ, Options{}); got != expected {
+			t.Errorf("%s: expected scan type %s, got %s", typ, expected, got)
+		}
+	}
+	if p, s, ok := PrecisionScale("Nullable(Decimal(20, 4))"); !ok || p != 20 || s != 4 {
+		t.Errorf("expected precision 20 and scale 4, got %d, %d, %t", p, s, ok)
+	}
+	if p, _, ok := PrecisionScale("DateTime64(6, 'UTC')"); !ok || p != 6 {
+		t.Errorf("expected precision 6, got %d, %t", p, ok)
+	}
+	if got := EnumValues("Nullable(Enum16('a' = 1, 'b\\'c' = -300))"); !reflect.DeepEqual(got, map[string]int16{"a": 1, "b'c": -300}) {
+		t.Errorf("unexpected enum values %v", got)
+	}
+	if got := EnumValues("Int8"); got != nil {
+		t.Errorf("expected no enum values, got %v", got)
+	}
+	if !Nullable("LowCardinality(Nullable(String))") || Nullable("Array(Nullable(String))") {
+		t.Errorf("unexpected nullability")
+	}
+	for typ, expected := range map[string][]string{
+		"Tuple(a Int8, b Array(String))":            {"a", "b"},
+		"Tuple(Int8, Tuple(x Int8))":                {"1", "2"},
+		"Array(Tuple(`full name` String, id Int8))": {"full name", "id"},
+		"Nested(id UInt32, name String)":            {"id", "name"},
+		"Map(String, Tuple(a Int8))":                nil,
+		"String":                                    nil,
+	} {
+		if got := ElementNames(typ); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected element names %q, got %q", typ, expected, got)
+		}
+	}
+}
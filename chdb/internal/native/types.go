@@ -0,0 +1,284 @@
+package native
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// splitType splits a ClickHouse type into its name and its arguments, e.g. "Map(String, UInt8)"
+// into "Map" and ["String", "UInt8"].
+func splitType(t string) (name string, args []string) {
+	t = strings.TrimSpace(t)
+	open := strings.IndexByte(t, '(')
+	if open < 0 || !strings.HasSuffix(t, ")") {
+		return t, nil
+	}
+	return t[:open], splitArgs(t[open+1 : len(t)-1])
+}
+
+// splitArgs splits a list of type arguments on its top-level commas.
+func splitArgs(s string) []string {
+	var (
+		args  []string
+		depth int
+		quote bool
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote && c == '\\':
+			i++
+		case c == '\'':
+			quote = !quote
+		case quote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" || len(args) > 0 {
+		args = append(args, rest)
+	}
+	return args
+}
+
+// tupleElement splits a tuple element into its name and its type, e.g. "id UInt64" into "id" and "UInt64".
+// The name is empty for unnamed elements, and may be quoted with backquotes.
+func tupleElement(arg string) (name, typ string) {
+	if strings.HasPrefix(arg, "`") {
+		if end := strings.IndexByte(arg[1:], '`'); end >= 0 {
+			return arg[1 : end+1], strings.TrimSpace(arg[end+2:])
+		}
+	}
+	space := strings.IndexByte(arg, ' ')
+	if space < 0 {
+		return "", arg
+	}
+	if open := strings.IndexByte(arg, '('); open >= 0 && open < space {
+		return "", arg
+	}
+	return arg[:space], strings.TrimSpace(arg[space+1:])
+}
+
+// ElementNames returns the names of the elements of a Tuple or Nested type, or of the tuples of an array of them,
+// e.g. [id name] for Array(Tuple(id UInt64, name String)). Unnamed elements are named after their position from 1,
+// as ClickHouse does. It returns nil for other types.
+func ElementNames(t string) []string {
+	name, args := splitType(UnwrapLowCardinality(t))
+	switch name {
+	case "Nullable", "Array":
+		if len(args) == 1 {
+			return ElementNames(args[0])
+		}
+	case "Tuple", "Nested":
+		names := make([]string, len(args))
+		for i, arg := range args {
+			if names[i], _ = tupleElement(arg); names[i] == "" {
+				names[i] = strconv.Itoa(i + 1)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// EnumValues returns the values of an Enum8 or Enum16 type by name, e.g. {"a": 1, "b": 2} for Enum8('a' = 1, 'b' = 2),
+// looking through Nullable and LowCardinality. It returns nil for other types.
+func EnumValues(t string) map[string]int16 {
+	name, args := splitType(UnwrapLowCardinality(t))
+	if name == "Nullable" && len(args) == 1 {
+		name, args = splitType(args[0])
+	}
+	if name != "Enum8" && name != "Enum16" {
+		return nil
+	}
+	values, err := parseEnum(args)
+	if err != nil {
+		return nil
+	}
+	out := make(map[string]int16, len(values))
+	for v, name := range values {
+		out[name] = v
+	}
+	return out
+}
+
+// parseEnum parses the values of an Enum8 or Enum16 type, e.g. 'a' = 1, 'b' = 2.
+func parseEnum(args []string) (map[int16]string, error) {
+	values := make(map[int16]string, len(args))
+	for _, arg := range args {
+		eq := strings.LastIndexByte(arg, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid enum value %q", arg)
+		}
+		name, err := unquote(strings.TrimSpace(arg[:eq]))
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(arg[eq+1:]), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum value %q: %w", arg, err)
+		}
+		values[int16(v)] = name
+	}
+	return values, nil
+}
+
+// unquote unquotes a ClickHouse string literal.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", fmt.Errorf("invalid string literal %s", s)
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
+
+// locations caches the time zones loaded by Location.
+var locations sync.Map
+
+// Location returns the time zone of the given name, e.g. "Europe/Paris", loading it once.
+func Location(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// columnLocation returns the time zone of the values of a DateTime or DateTime64 column, given the time zone
+// argument of its type if any, e.g. ['Europe/Paris'].
+func columnLocation(args []string, opts Options) (*time.Location, error) {
+	if len(args) == 0 {
+		if opts.Location != nil {
+			return opts.Location, nil
+		}
+		return time.UTC, nil
+	}
+	name, err := unquote(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return Location(name)
+}
+
+// decimalSize returns the size in bytes of the values of a Decimal type and its scale.
+func decimalSize(name string, args []string) (size, scale int, err error) {
+	var precision int
+	switch name {
+	case "Decimal32":
+		precision = 9
+	case "Decimal64":
+		precision = 18
+	case "Decimal128":
+		precision = 38
+	case "Decimal256":
+		precision = 76
+	}
+	if name == "Decimal" {
+		if len(args) != 2 {
+			return 0, 0, fmt.Errorf("invalid Decimal arguments %v", args)
+		}
+		if precision, err = strconv.Atoi(args[0]); err != nil {
+			return 0, 0, err
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		return 0, 0, fmt.Errorf("invalid %s arguments %v", name, args)
+	}
+	if scale, err = strconv.Atoi(args[0]); err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case precision <= 9:
+		size = 4
+	case precision <= 18:
+		size = 8
+	case precision <= 38:
+		size = 16
+	default:
+		size = 32
+	}
+	return size, scale, nil
+}
+
+// Nullable reports whether the values of a column of the given type may be NULL.
+func Nullable(t string) bool {
+	name, args := splitType(t)
+	switch name {
+	case "Nullable":
+		return true
+	case "LowCardinality":
+		return len(args) == 1 && Nullable(args[0])
+	}
+	return false
+}
+
+// UnwrapLowCardinality returns the type of the values of a LowCardinality type, e.g. String for LowCardinality(String),
+// and other types as they are.
+func UnwrapLowCardinality(t string) string {
+	name, args := splitType(t)
+	if name == "LowCardinality" && len(args) == 1 {
+		return args[0]
+	}
+	return t
+}
+
+// PrecisionScale returns the precision and the scale of Decimal types, and the precision of DateTime64 types.
+func PrecisionScale(t string) (precision, scale int64, ok bool) {
+	name, args := splitType(UnwrapLowCardinality(t))
+	if name == "Nullable" && len(args) == 1 {
+		name, args = splitType(args[0])
+	}
+	switch name {
+	case "DateTime64":
+		if len(args) > 0 {
+			if p, err := strconv.Atoi(args[0]); err == nil {
+				return int64(p), 0, true
+			}
+		}
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		size, s, err := decimalSize(name, args)
+		if err != nil {
+			return 0, 0, false
+		}
+		p := map[int]int{4: 9, 8: 18, 16: 38, 32: 76}[size]
+		if name == "Decimal" {
+			p, _ = strconv.Atoi(args[0])
+		}
+		return int64(p), int64(s), true
+	}
+	return 0, 0, false
+}
+
+// ScanType returns the Go type values of a column of the given type are decoded into, a pointer for nullable
+// scalars, e.g. *uint8 for Nullable(UInt8), and *big.Int for any large integer. It returns the empty interface type for unsupported types and for values whose type varies.
+func ScanType(t string, opts Options) reflect.Type {
+	c, err := newColumn(t, opts)
+	if err != nil || c.goType() == nil {
+		return reflect.TypeOf((*any)(nil)).Elem()
+	}
+	if nullable(c) && c.goType().Kind() != reflect.Slice && c.goType().Kind() != reflect.Pointer {
+		return reflect.PointerTo(c.goType())
+	}
+	return c.goType()
+}
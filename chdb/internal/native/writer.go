@@ -0,0 +1,761 @@
+package native
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Writer encodes blocks of rows in the Native format, as read by the Native input format, e.g. with the file
+// table function. LowCardinality columns are written as their inner type, and JSON columns as strings, which
+// ClickHouse converts back when the block is inserted with INSERT ... SELECT.
+type Writer struct {
+	names   []string
+	types   []string // types written in the block header
+	columns []encoder
+}
+
+// NewWriter returns a writer of blocks of columns of the given names and ClickHouse types.
+func NewWriter(names, types []string) (*Writer, error) {
+	if len(names) != len(types) {
+		return nil, fmt.Errorf("%d column names for %d types", len(names), len(types))
+	}
+	w := &Writer{names: names, types: make([]string, len(types)), columns: make([]encoder, len(types))}
+	for i, t := range types {
+		c, written, err := newEncoder(t)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", names[i], err)
+		}
+		w.columns[i], w.types[i] = c, written
+	}
+	return w, nil
+}
+
+// AppendBlock appends to dst a block holding the given rows, each of them holding a value for every column, nil for
+// NULL. Values are converted to the types of their columns, e.g. any Go integer to an Int32 column as long as it
+// fits, time.Time values to dates, strings to UUID, IP or Decimal columns and Go maps to Map columns.
+func (w *Writer) AppendBlock(dst []byte, rows [][]any) ([]byte, error) {
+	for i, row := range rows {
+		if len(row) != len(w.columns) {
+			return nil, fmt.Errorf("row %d: %d values for %d columns", i, len(row), len(w.columns))
+		}
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(w.columns)))
+	dst = binary.AppendUvarint(dst, uint64(len(rows)))
+	values := make([]any, len(rows))
+	for i, c := range w.columns {
+		dst = appendString(dst, w.names[i])
+		dst = appendString(dst, w.types[i])
+		if len(rows) == 0 {
+			continue
+		}
+		for j, row := range rows {
+			values[j] = row[i]
+		}
+		var err error
+		if dst, err = c.encode(dst, values); err != nil {
+			return nil, fmt.Errorf("column %s: %w", w.names[i], err)
+		}
+	}
+	return dst, nil
+}
+
+// encoder encodes the values of a column of a given type.
+type encoder interface {
+	// encode appends the values of the rows to b.
+	encode(b []byte, values []any) ([]byte, error)
+}
+
+// zero stands for the values hidden by NULL in the nested column of a Nullable column, encoded as zero values.
+type zero struct{}
+
+// newEncoder returns the encoder of the columns of type t, and the type written in the block header.
+func newEncoder(t string) (encoder, string, error) {
+	name, args := splitType(t)
+	switch name {
+	case "Int8", "Int16", "Int32", "Int64":
+		bits, _ := strconv.Atoi(name[len("Int"):])
+		return newFixedEncoder(bits/8, func(b []byte, v any) error {
+			i, err := signedValue(v, bits)
+			putUint(b, uint64(i))
+			return err
+		}), t, nil
+	case "UInt8", "UInt16", "UInt32", "UInt64":
+		bits, _ := strconv.Atoi(name[len("UInt"):])
+		return newFixedEncoder(bits/8, func(b []byte, v any) error {
+			u, err := unsignedValue(v, bits)
+			putUint(b, u)
+			return err
+		}), t, nil
+	case "Int128", "UInt128", "Int256", "UInt256":
+		size := 16
+		if strings.HasSuffix(name, "256") {
+			size = 32
+		}
+		signed := name[0] == 'I'
+		return newFixedEncoder(size, func(b []byte, v any) error {
+			i, err := bigIntValue(v)
+			if err != nil {
+				return err
+			}
+			return putBigInt(b, i, signed)
+		}), t, nil
+	case "Float32":
+		return newFixedEncoder(4, func(b []byte, v any) error {
+			f, err := floatValue(v)
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(f)))
+			return err
+		}), t, nil
+	case "Float64":
+		return newFixedEncoder(8, func(b []byte, v any) error {
+			f, err := floatValue(v)
+			binary.LittleEndian.PutUint64(b, math.Float64bits(f))
+			return err
+		}), t, nil
+	case "Bool":
+		return newFixedEncoder(1, func(b []byte, v any) error {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Bool {
+				return fmt.Errorf("cannot encode %T as a Bool", v)
+			}
+			if rv.Bool() {
+				b[0] = 1
+			}
+			return nil
+		}), t, nil
+	case "String":
+		return stringEncoder{}, t, nil
+	case "JSON", "Object":
+		return stringEncoder{json: true}, "String", nil
+	case "FixedString":
+		if len(args) != 1 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid type %s: %w", t, err)
+		}
+		return newFixedEncoder(n, func(b []byte, v any) error {
+			s, ok := stringValue(v)
+			if !ok {
+				return fmt.Errorf("cannot encode %T as a FixedString", v)
+			}
+			if len(s) > n {
+				return fmt.Errorf("value of %d bytes too long for %s", len(s), t)
+			}
+			copy(b, s)
+			return nil
+		}), t, nil
+	case "UUID":
+		return newFixedEncoder(16, func(b []byte, v any) error {
+			u, err := uuidValue(v)
+			binary.LittleEndian.PutUint64(b, binary.BigEndian.Uint64(u[:8]))
+			binary.LittleEndian.PutUint64(b[8:], binary.BigEndian.Uint64(u[8:]))
+			return err
+		}), t, nil
+	case "IPv4":
+		return newFixedEncoder(4, func(b []byte, v any) error {
+			addr, err := addrValue(v)
+			if err != nil {
+				return err
+			}
+			if addr = addr.Unmap(); !addr.Is4() {
+				return fmt.Errorf("cannot encode %s as an IPv4", addr)
+			}
+			a := addr.As4()
+			binary.LittleEndian.PutUint32(b, binary.BigEndian.Uint32(a[:]))
+			return nil
+		}), t, nil
+	case "IPv6":
+		return newFixedEncoder(16, func(b []byte, v any) error {
+			addr, err := addrValue(v)
+			if err != nil {
+				return err
+			}
+			a := addr.As16()
+			copy(b, a[:])
+			return nil
+		}), t, nil
+	case "Date", "Date32":
+		size, lowest, highest := 2, int64(0), int64(math.MaxUint16)
+		if name == "Date32" {
+			size, lowest, highest = 4, math.MinInt32, math.MaxInt32
+		}
+		return newFixedEncoder(size, func(b []byte, v any) error {
+			ts, ok := v.(time.Time)
+			if !ok {
+				return fmt.Errorf("cannot encode %T as a %s", v, name)
+			}
+			// the calendar date of the time in its own time zone
+			year, month, day := ts.Date()
+			days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+			if days < lowest || days > highest {
+				return fmt.Errorf("date %s out of the range of %s", ts.Format(time.DateOnly), name)
+			}
+			putUint(b, uint64(days))
+			return nil
+		}), t, nil
+	case "DateTime":
+		return newFixedEncoder(4, func(b []byte, v any) error {
+			ts, ok := v.(time.Time)
+			if !ok {
+				return fmt.Errorf("cannot encode %T as a DateTime", v)
+			}
+			if sec := ts.Unix(); sec < 0 || sec > math.MaxUint32 {
+				return fmt.Errorf("time %s out of the range of DateTime", ts)
+			}
+			binary.LittleEndian.PutUint32(b, uint32(ts.Unix()))
+			return nil
+		}), t, nil
+	case "DateTime64":
+		if len(args) == 0 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		precision, err := strconv.Atoi(args[0])
+		if err != nil || precision < 0 || precision > 9 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		scale := int64(math.Pow10(precision))
+		return newFixedEncoder(8, func(b []byte, v any) error {
+			ts, ok := v.(time.Time)
+			if !ok {
+				return fmt.Errorf("cannot encode %T as a DateTime64", v)
+			}
+			sec := ts.Unix()
+			if sec > math.MaxInt64/scale || sec < math.MinInt64/scale+1 {
+				return fmt.Errorf("time %s out of the range of %s", ts, t)
+			}
+			binary.LittleEndian.PutUint64(b, uint64(sec*scale+int64(ts.Nanosecond())/(1e9/scale)))
+			return nil
+		}), t, nil
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		size, scale, err := decimalSize(name, args)
+		if err != nil {
+			return nil, "", err
+		}
+		precision, _, _ := PrecisionScale(t)
+		limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(precision), nil)
+		return newFixedEncoder(size, func(b []byte, v any) error {
+			d, err := decimalValue(v, scale)
+			if err != nil {
+				return err
+			}
+			if new(big.Int).Abs(d).Cmp(limit) >= 0 {
+				return fmt.Errorf("value %v overflows %s", v, t)
+			}
+			return putBigInt(b, d, true)
+		}), t, nil
+	case "Enum8", "Enum16":
+		values, err := parseEnum(args)
+		if err != nil {
+			return nil, "", err
+		}
+		names := make(map[string]int16, len(values))
+		for v, name := range values {
+			names[name] = v
+		}
+		size, bits := 1, 8
+		if name == "Enum16" {
+			size, bits = 2, 16
+		}
+		return newFixedEncoder(size, func(b []byte, v any) error {
+			var i int64
+			if s, ok := v.(string); ok {
+				n, ok := names[s]
+				if !ok {
+					return fmt.Errorf("unknown %s value %q", name, s)
+				}
+				i = int64(n)
+			} else {
+				var err error
+				if i, err = signedValue(v, bits); err != nil {
+					return err
+				}
+				if _, ok := values[int16(i)]; !ok {
+					return fmt.Errorf("unknown %s value %d", name, i)
+				}
+			}
+			putUint(b, uint64(i))
+			return nil
+		}), t, nil
+	case "Nullable":
+		if len(args) != 1 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		nested, written, err := newEncoder(args[0])
+		if err != nil {
+			return nil, "", err
+		}
+		return &nullableEncoder{nested: nested}, "Nullable(" + written + ")", nil
+	case "LowCardinality":
+		if len(args) != 1 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		// LowCardinality columns are written as their inner type, which avoids writing dictionaries
+		return newEncoder(args[0])
+	case "SimpleAggregateFunction":
+		if len(args) != 2 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		return newEncoder(args[1])
+	case "Array":
+		if len(args) != 1 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		elem, written, err := newEncoder(args[0])
+		if err != nil {
+			return nil, "", err
+		}
+		return &arrayEncoder{elem: elem}, "Array(" + written + ")", nil
+	case "Tuple", "Nested":
+		elems := make([]encoder, len(args))
+		written := make([]string, len(args))
+		for i, arg := range args {
+			elemName, typ := tupleElement(arg)
+			c, elemType, err := newEncoder(typ)
+			if err != nil {
+				return nil, "", err
+			}
+			elems[i], written[i] = c, elemType
+			if elemName != "" {
+				written[i] = "`" + elemName + "` " + elemType
+			}
+		}
+		header := name + "(" + strings.Join(written, ", ") + ")"
+		if name == "Nested" {
+			// Nested columns are stored like arrays of tuples
+			return &arrayEncoder{elem: &tupleEncoder{elems: elems}}, header, nil
+		}
+		return &tupleEncoder{elems: elems}, header, nil
+	case "Map":
+		if len(args) != 2 {
+			return nil, "", fmt.Errorf("invalid type %s", t)
+		}
+		key, keyType, err := newEncoder(args[0])
+		if err != nil {
+			return nil, "", err
+		}
+		value, valueType, err := newEncoder(args[1])
+		if err != nil {
+			return nil, "", err
+		}
+		return &mapEncoder{key: key, value: value}, "Map(" + keyType + ", " + valueType + ")", nil
+	case "Point":
+		return &tupleEncoder{elems: []encoder{mustEncoder("Float64"), mustEncoder("Float64")}}, t, nil
+	case "Ring", "LineString":
+		return &arrayEncoder{elem: mustEncoder("Point")}, t, nil
+	case "Polygon", "MultiLineString":
+		return &arrayEncoder{elem: mustEncoder("Ring")}, t, nil
+	case "MultiPolygon":
+		return &arrayEncoder{elem: mustEncoder("Polygon")}, t, nil
+	}
+	return nil, "", fmt.Errorf("unsupported type %s", t)
+}
+
+func mustEncoder(t string) encoder {
+	c, _, err := newEncoder(t)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// fixedEncoder encodes values of a fixed size, zero filled before put is called on the non zero values.
+type fixedEncoder struct {
+	size int
+	put  func(b []byte, v any) error
+}
+
+func newFixedEncoder(size int, put func(b []byte, v any) error) *fixedEncoder {
+	return &fixedEncoder{size: size, put: put}
+}
+
+func (c *fixedEncoder) encode(b []byte, values []any) ([]byte, error) {
+	start := len(b)
+	b = append(b, make([]byte, len(values)*c.size)...)
+	for i, v := range values {
+		v, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(zero); ok {
+			continue
+		}
+		if err := c.put(b[start+i*c.size:start+(i+1)*c.size], v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// stringEncoder encodes length prefixed strings. JSON values which aren't strings are marshaled.
+type stringEncoder struct {
+	json bool
+}
+
+func (c stringEncoder) encode(b []byte, values []any) ([]byte, error) {
+	for _, v := range values {
+		v, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(zero); ok {
+			b = append(b, 0)
+			continue
+		}
+		s, ok := stringValue(v)
+		if !ok && c.json {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			s, ok = string(data), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("cannot encode %T as a String", v)
+		}
+		b = appendString(b, s)
+	}
+	return b, nil
+}
+
+// nullableEncoder encodes a map of NULL flags followed by the values of the nested column.
+type nullableEncoder struct {
+	nested encoder
+}
+
+func (c *nullableEncoder) encode(b []byte, values []any) ([]byte, error) {
+	nested := make([]any, len(values))
+	for i, v := range values {
+		if isNull(v) {
+			b = append(b, 1)
+			nested[i] = zero{}
+			continue
+		}
+		b = append(b, 0)
+		nested[i] = v
+	}
+	return c.nested.encode(b, nested)
+}
+
+// arrayEncoder encodes the offsets of the arrays of the rows followed by the values of all their elements.
+type arrayEncoder struct {
+	elem encoder
+}
+
+func (c *arrayEncoder) encode(b []byte, values []any) ([]byte, error) {
+	var (
+		elems  []any
+		offset uint64
+	)
+	for _, v := range values {
+		v, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(zero); !ok {
+			rv := reflect.ValueOf(v)
+			if k := rv.Kind(); k != reflect.Slice && k != reflect.Array || rv.Type() == reflect.TypeOf("") {
+				return nil, fmt.Errorf("cannot encode %T as an Array", v)
+			}
+			for i := 0; i < rv.Len(); i++ {
+				elems = append(elems, rv.Index(i).Interface())
+			}
+			offset += uint64(rv.Len())
+		}
+		b = binary.LittleEndian.AppendUint64(b, offset)
+	}
+	return c.elem.encode(b, elems)
+}
+
+// mapEncoder encodes maps like arrays of key and value tuples: their offsets, then their keys and their values.
+type mapEncoder struct {
+	key, value encoder
+}
+
+func (c *mapEncoder) encode(b []byte, values []any) ([]byte, error) {
+	var (
+		keys, elems []any
+		offset      uint64
+	)
+	for _, v := range values {
+		v, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(zero); !ok {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Map {
+				return nil, fmt.Errorf("cannot encode %T as a Map", v)
+			}
+			iter := rv.MapRange()
+			for iter.Next() {
+				keys = append(keys, iter.Key().Interface())
+				elems = append(elems, iter.Value().Interface())
+			}
+			offset += uint64(rv.Len())
+		}
+		b = binary.LittleEndian.AppendUint64(b, offset)
+	}
+	b, err := c.key.encode(b, keys)
+	if err != nil {
+		return nil, err
+	}
+	return c.value.encode(b, elems)
+}
+
+// tupleEncoder encodes the values of every element one after the other. Tuples are given as slices or arrays.
+type tupleEncoder struct {
+	elems []encoder
+}
+
+func (c *tupleEncoder) encode(b []byte, values []any) ([]byte, error) {
+	columns := make([][]any, len(c.elems))
+	for i := range columns {
+		columns[i] = make([]any, len(values))
+	}
+	for j, v := range values {
+		v, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(zero); ok {
+			for i := range columns {
+				columns[i][j] = zero{}
+			}
+			continue
+		}
+		rv := reflect.ValueOf(v)
+		if k := rv.Kind(); k != reflect.Slice && k != reflect.Array || rv.Len() != len(c.elems) {
+			return nil, fmt.Errorf("cannot encode %T as a Tuple of %d elements", v, len(c.elems))
+		}
+		for i := range columns {
+			columns[i][j] = rv.Index(i).Interface()
+		}
+	}
+	for i, elem := range c.elems {
+		var err error
+		if b, err = elem.encode(b, columns[i]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// value dereferences pointers, returning an error for NULL since only Nullable columns accept it.
+func value(v any) (any, error) {
+	if isNull(v) {
+		return nil, fmt.Errorf("NULL value in a column which isn't Nullable")
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.Type() != bigIntType {
+		return value(rv.Elem().Interface())
+	}
+	return v, nil
+}
+
+// isNull reports whether v is nil or a nil pointer.
+func isNull(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// putUint writes the low bytes of v in little endian order, as many as b holds.
+func putUint(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (8 * i))
+	}
+}
+
+// signedValue returns the value of a Go integer, checking that it fits in an integer of the given size.
+func signedValue(v any, bits int) (int64, error) {
+	rv := reflect.ValueOf(v)
+	var i int64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows Int%d", rv.Uint(), bits)
+		}
+		i = int64(rv.Uint())
+	default:
+		return 0, fmt.Errorf("cannot encode %T as an Int%d", v, bits)
+	}
+	if bits < 64 && (i < -1<<(bits-1) || i >= 1<<(bits-1)) {
+		return 0, fmt.Errorf("value %d overflows Int%d", i, bits)
+	}
+	return i, nil
+}
+
+// unsignedValue returns the value of a Go integer, checking that it fits in an unsigned integer of the given size.
+func unsignedValue(v any, bits int) (uint64, error) {
+	rv := reflect.ValueOf(v)
+	var u uint64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < 0 {
+			return 0, fmt.Errorf("negative value %d for UInt%d", rv.Int(), bits)
+		}
+		u = uint64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u = rv.Uint()
+	default:
+		return 0, fmt.Errorf("cannot encode %T as a UInt%d", v, bits)
+	}
+	if bits < 64 && u >= 1<<bits {
+		return 0, fmt.Errorf("value %d overflows UInt%d", u, bits)
+	}
+	return u, nil
+}
+
+// floatValue returns the value of a Go float or integer.
+func floatValue(v any) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("cannot encode %T as a float", v)
+}
+
+// stringValue returns the bytes of a string or of a byte slice, of any named type.
+func stringValue(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.String:
+		return rv.String(), true
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		return string(rv.Bytes()), true
+	}
+	return "", false
+}
+
+// bigIntValue returns the value of a *big.Int, a Go integer or the decimal text of an integer.
+func bigIntValue(v any) (*big.Int, error) {
+	switch v := v.(type) {
+	case *big.Int:
+		return v, nil
+	case big.Int:
+		return &v, nil
+	case string:
+		i, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", v)
+		}
+		return i, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as a big integer", v)
+}
+
+// putBigInt writes an integer in little endian order, in two's complement when signed, checking that it fits in b.
+func putBigInt(b []byte, v *big.Int, signed bool) error {
+	bits := uint(len(b) * 8)
+	limit := new(big.Int).Lsh(big.NewInt(1), bits)
+	lowest := new(big.Int)
+	if signed {
+		limit.Rsh(limit, 1)
+		lowest.Neg(limit)
+	}
+	if v.Cmp(lowest) < 0 || v.Cmp(limit) >= 0 {
+		return fmt.Errorf("value %s overflows %d bits", v, bits)
+	}
+	u := v
+	if v.Sign() < 0 {
+		u = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+	be := u.FillBytes(make([]byte, len(b)))
+	for i := range be {
+		b[len(b)-1-i] = be[i]
+	}
+	return nil
+}
+
+// decimalValue returns the unscaled value of a decimal given as its text, a Go float or integer, or a *big.Int,
+// rounded half away from zero to scale fractional digits.
+func decimalValue(v any, scale int) (*big.Int, error) {
+	r := new(big.Rat)
+	switch v := v.(type) {
+	case string:
+		if _, ok := r.SetString(v); !ok {
+			return nil, fmt.Errorf("invalid decimal %q", v)
+		}
+	case float32, float64:
+		f, _ := floatValue(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid decimal %v", f)
+		}
+		r.SetFloat64(f)
+	default:
+		i, err := bigIntValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode %T as a Decimal", v)
+		}
+		r.SetInt(i)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	num, denom := r.Num(), r.Denom()
+	q, m := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if m.Abs(m).Lsh(m, 1).Cmp(denom) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q, nil
+}
+
+// uuidValue returns the value of a uuid.UUID, a 16 byte array or the text of a UUID.
+func uuidValue(v any) (uuid.UUID, error) {
+	switch v := v.(type) {
+	case uuid.UUID:
+		return v, nil
+	case [16]byte:
+		return v, nil
+	case string:
+		return uuid.Parse(v)
+	}
+	return uuid.UUID{}, fmt.Errorf("cannot encode %T as a UUID", v)
+}
+
+// addrValue returns the value of a netip.Addr or of the text of an address.
+func addrValue(v any) (netip.Addr, error) {
+	switch v := v.(type) {
+	case netip.Addr:
+		if !v.IsValid() {
+			return v, fmt.Errorf("invalid IP address")
+		}
+		return v, nil
+	case string:
+		return netip.ParseAddr(v)
+	}
+	return netip.Addr{}, fmt.Errorf("cannot encode %T as an IP address", v)
+}
@@ -0,0 +1,171 @@
+package native
+
+import (
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	id := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	one := int32(1)
+	for _, tc := range []struct {
+		typ      string
+		written  string // type of the block header, the same as typ when empty
+		values   []any
+		expected []any
+	}{
+		{typ: "Int8", values: []any{int8(-1), 127}, expected: []any{int8(-1), int8(127)}},
+		{typ: "Int32", values: []any{&one, uint16(7)}, expected: []any{int32(1), int32(7)}},
+		{typ: "UInt64", values: []any{uint64(1 << 63), 3}, expected: []any{uint64(1 << 63), uint64(3)}},
+		{typ: "Int128", values: []any{big.NewInt(-5), "170141183460469231731687303715884105727"},
+			expected: []any{big.NewInt(-5), new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))}},
+		{typ: "Float64", values: []any{1.5, 2}, expected: []any{1.5, float64(2)}},
+		{typ: "Bool", values: []any{true, false}, expected: []any{true, false}},
+		{typ: "String", values: []any{"a", []byte("bc"), label("d")}, expected: []any{"a", "bc", "d"}},
+		{typ: "FixedString(3)", values: []any{"ab", []byte("xyz")}, expected: []any{[]byte("ab\x00"), []byte("xyz")}},
+		{typ: "UUID", values: []any{id, id.String()}, expected: []any{id, id}},
+		{typ: "IPv4", values: []any{netip.MustParseAddr("192.168.0.1"), "::ffff:10.0.0.1"},
+			expected: []any{netip.MustParseAddr("192.168.0.1"), netip.MustParseAddr("10.0.0.1")}},
+		{typ: "IPv6", values: []any{"2001:db8::1"}, expected: []any{netip.MustParseAddr("2001:db8::1")}},
+		{typ: "Date", values: []any{ts.In(time.FixedZone("UTC-5", -5*3600))}, expected: []any{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{typ: "Date32", values: []any{time.Date(1900, 1, 1, 12, 0, 0, 0, time.UTC)}, expected: []any{time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{typ: "DateTime", values: []any{ts}, expected: []any{ts.Truncate(time.Second)}},
+		{typ: "DateTime64(6)", values: []any{ts, time.Date(1960, 1, 1, 0, 0, 0, 5000, time.UTC)},
+			expected: []any{ts.Truncate(time.Microsecond), time.Date(1960, 1, 1, 0, 0, 0, 5000, time.UTC)}},
+		{typ: "Decimal(9, 2)", values: []any{"1.005", -0.125, 3}, expected: []any{big.NewRat(101, 100), big.NewRat(-13, 100), big.NewRat(3, 1)}},
+		{typ: "Enum8('a' = 1, 'b' = 2)", values: []any{"b", 1}, expected: []any{int8(2), int8(1)}},
+		{typ: "Nullable(String)", values: []any{nil, "x", (*string)(nil)}, expected: []any{nil, "x", nil}},
+		{typ: "LowCardinality(Nullable(String))", written: "Nullable(String)", values: []any{"a", nil}, expected: []any{"a", nil}},
+		{typ: "JSON", written: "String", values: []any{`{"a":1}`, map[string]any{"b": 2}}, expected: []any{`{"a":1}`, `{"b":2}`}},
+		{typ: "Array(Nullable(UInt8))", values: []any{[]any{1, nil}, []uint8{}, [1]int{3}},
+			expected: []any{[]*uint8{ptr(uint8(1)), nil}, []*uint8{}, []*uint8{ptr(uint8(3))}}},
+		{typ: "Array(LowCardinality(String))", written: "Array(String)", values: []any{[]string{"a"}}, expected: []any{[]string{"a"}}},
+		{typ: "Map(String, UInt8)", values: []any{map[string]int{"a": 1}, map[string]int{}},
+			expected: []any{map[string]uint8{"a": 1}, map[string]uint8{}}},
+		{typ: "Tuple(id UInt8, name LowCardinality(String))", written: "Tuple(`id` UInt8, `name` String)",
+			values: []any{[]any{1, "a"}}, expected: []any{[]any{uint8(1), "a"}}},
+		{typ: "Nullable(Tuple(UInt8, String))", values: []any{nil}, expected: []any{nil}},
+		{typ: "Point", values: []any{[2]float64{1, 2}}, expected: []any{[2]float64{1, 2}}},
+	} {
+		w, err := NewWriter([]string{"c"}, []string{tc.typ})
+		if err != nil {
+			t.Fatalf("%s: new writer fail, err: %s", tc.typ, err)
+		}
+		rows := make([][]any, len(tc.values))
+		for i, v := range tc.values {
+			rows[i] = []any{v}
+		}
+		buf, err := w.AppendBlock(nil, rows)
+		if err != nil {
+			t.Fatalf("%s: append block fail, err: %s", tc.typ, err)
+		}
+		block, err := NewReader(buf, Options{}).Next()
+		if err != nil {
+			t.Fatalf("%s: read block fail, err: %s", tc.typ, err)
+		}
+		written := tc.written
+		if written == "" {
+			written = tc.typ
+		}
+		if block.Types[0] != written || block.Rows != len(tc.values) {
+			t.Errorf("%s: expected %d rows of %s, got %d rows of %s", tc.typ, len(tc.values), written, block.Rows, block.Types[0])
+		}
+		for i, expected := range tc.expected {
+			got := block.Columns[0][i]
+			if e, ok := expected.(*big.Int); ok {
+				if g, ok := got.(*big.Int); !ok || g.Cmp(e) != 0 {
+					t.Errorf("%s: row %d: expected %s, got %v", tc.typ, i, e, got)
+				}
+				continue
+			}
+			if e, ok := expected.(*big.Rat); ok {
+				if g, ok := got.(*big.Rat); !ok || g.Cmp(e) != 0 {
+					t.Errorf("%s: row %d: expected %s, got %v", tc.typ, i, e.FloatString(2), got)
+				}
+				continue
+			}
+			if e, ok := expected.(time.Time); ok {
+				if g, ok := got.(time.Time); !ok || !g.Equal(e) {
+					t.Errorf("%s: row %d: expected %s, got %v", tc.typ, i, e, got)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s: row %d: expected %#v, got %#v", tc.typ, i, expected, got)
+			}
+		}
+	}
+}
+
+type label string
+
+func ptr[T any](v T) *T { return &v }
+
+func TestWriterErrors(t *testing.T) {
+	for _, tc := range []struct {
+		typ   string
+		value any
+		err   string
+	}{
+		{"Int8", 128, "overflows Int8"},
+		{"UInt16", -1, "negative value"},
+		{"UInt32", "1", "cannot encode string"},
+		{"String", nil, "NULL value"},
+		{"FixedString(1)", "ab", "too long"},
+		{"Enum8('a' = 1)", "b", "unknown Enum8 value"},
+		{"Enum8('a' = 1)", 2, "unknown Enum8 value"},
+		{"Decimal(4, 2)", "100", "overflows"},
+		{"Int128", new(big.Int).Lsh(big.NewInt(1), 127), "overflows"},
+		{"Date", time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), "out of the range"},
+		{"IPv4", "2001:db8::1", "as an IPv4"},
+		{"Tuple(UInt8, String)", []any{1}, "Tuple of 2 elements"},
+	} {
+		w, err := NewWriter([]string{"c"}, []string{tc.typ})
+		if err != nil {
+			t.Fatalf("%s: new writer fail, err: %s", tc.typ, err)
+		}
+		_, err = w.AppendBlock(nil, [][]any{{tc.value}})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.typ, tc.err, err)
+		}
+	}
+	if _, err := NewWriter([]string{"c"}, []string{"Dynamic"}); err == nil {
+		t.Errorf("expected an error for an unsupported type")
+	}
+	w, _ := NewWriter([]string{"a", "b"}, []string{"UInt8", "UInt8"})
+	if _, err := w.AppendBlock(nil, [][]any{{1}}); err == nil {
+		t.Errorf("expected an error for a short row")
+	}
+}
+
+func TestWriterMultipleColumns(t *testing.T) {
+	w, err := NewWriter([]string{"id", "name"}, []string{"UInt32", "String"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := w.AppendBlock(nil, [][]any{{1, "a"}, {2, "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf, err = w.AppendBlock(buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(buf, Options{})
+	block, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(block.Names, []string{"id", "name"}) || !reflect.DeepEqual(block.Columns, [][]any{{uint32(1), uint32(2)}, {"a", "b"}}) {
+		t.Errorf("unexpected block %+v", block)
+	}
+	if empty, err := r.Next(); err != nil || empty.Rows != 0 {
+		t.Errorf("expected an empty block, got %+v, err: %v", empty, err)
+	}
+}
@@ -0,0 +1,114 @@
+package pqconv
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Assign stores a decoded value into the variable pointed to by dest, following the conversion rules of
+// database/sql Scan for the common cases: sql.Scanner implementations, *any, assignable types,
+// numeric conversions, string and []byte conversions and pointers to any of those. Like database/sql, numeric
+// conversions fail when the value doesn't fit the destination, and []byte values are copied, so that the destination
+// doesn't alias the buffer of the source.
+func Assign(dest, src any) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	if d, ok := dest.(*any); ok {
+		if b, ok := src.([]byte); ok {
+			src = bytes.Clone(b)
+		}
+		*d = src
+		return nil
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination not a pointer: %T", dest)
+	}
+	return assignValue(dv.Elem(), src)
+}
+
+func assignValue(dv reflect.Value, src any) error {
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("converting NULL to %s is unsupported", dv.Type())
+	}
+	sv := reflect.ValueOf(src)
+	if b, ok := src.([]byte); ok && dv.Kind() == reflect.Slice && sv.Type().ConvertibleTo(dv.Type()) {
+		dv.Set(reflect.ValueOf(bytes.Clone(b)).Convert(dv.Type()))
+		return nil
+	}
+	if sv.Type().AssignableTo(dv.Type()) {
+		if b, ok := src.([]byte); ok {
+			sv = reflect.ValueOf(bytes.Clone(b))
+		}
+		dv.Set(sv)
+		return nil
+	}
+	if dv.Kind() == reflect.Pointer {
+		v := reflect.New(dv.Type().Elem())
+		if err := assignValue(v.Elem(), src); err != nil {
+			return err
+		}
+		dv.Set(v)
+		return nil
+	}
+	switch {
+	case isNumeric(sv.Kind()) && isNumeric(dv.Kind()):
+		if !fits(sv, dv.Type()) {
+			return fmt.Errorf("converting driver.Value type %T (%v) to a %s: value out of range", src, src, dv.Type())
+		}
+		dv.Set(sv.Convert(dv.Type()))
+		return nil
+	case sv.Kind() == reflect.String && dv.Kind() == reflect.Slice && dv.Type().Elem().Kind() == reflect.Uint8,
+		sv.Kind() == reflect.Slice && sv.Type().Elem().Kind() == reflect.Uint8 && dv.Kind() == reflect.String:
+		dv.Set(sv.Convert(dv.Type()))
+		return nil
+	}
+	return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type %s", src, dv.Type())
+}
+
+// fits reports whether the numeric value v converts to the numeric type t without overflowing or, for a float
+// converted to an integer, without losing its fractional part.
+func fits(v reflect.Value, t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case v.CanInt():
+			return !reflect.Zero(t).OverflowInt(v.Int())
+		case v.CanUint():
+			return v.Uint() <= math.MaxInt64 && !reflect.Zero(t).OverflowInt(int64(v.Uint()))
+		}
+		f := v.Float()
+		return f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 && !reflect.Zero(t).OverflowInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case v.CanInt():
+			return v.Int() >= 0 && !reflect.Zero(t).OverflowUint(uint64(v.Int()))
+		case v.CanUint():
+			return !reflect.Zero(t).OverflowUint(v.Uint())
+		}
+		f := v.Float()
+		return f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 && !reflect.Zero(t).OverflowUint(uint64(f))
+	case reflect.Float32:
+		return !v.CanFloat() || !reflect.Zero(t).OverflowFloat(v.Float())
+	}
+	return true
+}
+
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package pqconv
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAssign(t *testing.T) {
+	var (
+		i   int
+		u   uint64
+		f   float64
+		s   string
+		b   []byte
+		p   *int32
+		a   any
+		ns  sql.NullString
+		nip *int64
+	)
+	for _, tc := range []struct {
+		dest any
+		src  any
+	}{
+		{&i, int32(42)},
+		{&u, uint32(7)},
+		{&f, float32(1.5)},
+		{&s, []byte("abc")},
+		{&b, "xyz"},
+		{&p, int32(3)},
+		{&a, "any"},
+		{&ns, "ok"},
+		{&nip, nil},
+	} {
+		if err := Assign(tc.dest, tc.src); err != nil {
+			t.Fatalf("Assign(%T, %T) fail, err: %s", tc.dest, tc.src, err)
+		}
+	}
+	if i != 42 || u != 7 || f != 1.5 || s != "abc" || string(b) != "xyz" || p == nil || *p != 3 || a != "any" || ns.String != "ok" || nip != nil {
+		t.Errorf("unexpected assigned values: %v %v %v %v %v %v %v %v %v", i, u, f, s, b, p, a, ns, nip)
+	}
+	if err := Assign(&i, nil); err == nil {
+		t.Errorf("expected an error assigning NULL to an int")
+	}
+	if err := Assign(&i, "abc"); err == nil {
+		t.Errorf("expected an error assigning a string to an int")
+	}
+	if err := Assign(i, 1); err == nil {
+		t.Errorf("expected an error for a non pointer destination")
+	}
+
+	var (
+		i8  int8
+		u16 uint16
+		f32 float32
+	)
+	for _, tc := range []struct {
+		dest any
+		src  any
+	}{
+		{&i8, int64(128)},
+		{&i8, uint8(200)},
+		{&u, int64(-1)},
+		{&u16, uint32(70000)},
+		{&i, 1.5},
+		{&u, float64(-2)},
+		{&i, float64(1e20)},
+		{&f32, 1e300},
+	} {
+		if err := Assign(tc.dest, tc.src); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("Assign(%T, %T(%v)): expected an out of range error, got %v", tc.dest, tc.src, tc.src, err)
+		}
+	}
+	if err := Assign(&i8, int64(-128)); err != nil || i8 != -128 {
+		t.Errorf("expected -128, got %d, err: %v", i8, err)
+	}
+	if err := Assign(&u16, 3.0); err != nil || u16 != 3 {
+		t.Errorf("expected 3, got %d, err: %v", u16, err)
+	}
+
+	src := []byte("abc")
+	var raw json.RawMessage
+	for _, dest := range []any{&b, &a, &raw} {
+		if err := Assign(dest, src); err != nil {
+			t.Fatalf("Assign(%T, []byte) fail, err: %s", dest, err)
+		}
+	}
+	src[0] = 'x'
+	if string(b) != "abc" || string(a.([]byte)) != "abc" || string(raw) != "abc" {
+		t.Errorf("expected copies of the source bytes, got %q %q %q", b, a, raw)
+	}
+}
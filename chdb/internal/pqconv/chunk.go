@@ -0,0 +1,32 @@
+package pqconv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrTruncatedChunk is returned when a chunk is not a complete Parquet file.
+var ErrTruncatedChunk = errors.New("truncated chunk")
+
+const parquetMagic = "PAR1"
+
+// CheckChunk validates that buf holds a complete Parquet file: it must start and end with the
+// magic bytes, and the footer length must fit in the buffer. It doesn't decode the footer.
+func CheckChunk(buf []byte) error {
+	// header magic, footer length and trailing magic
+	if len(buf) < 2*len(parquetMagic)+4 {
+		return fmt.Errorf("%w: %d bytes is too short for a parquet file", ErrTruncatedChunk, len(buf))
+	}
+	if string(buf[:4]) != parquetMagic {
+		return fmt.Errorf("%w: missing parquet header magic bytes", ErrTruncatedChunk)
+	}
+	if string(buf[len(buf)-4:]) != parquetMagic {
+		return fmt.Errorf("%w: missing parquet footer magic bytes", ErrTruncatedChunk)
+	}
+	footerLen := binary.LittleEndian.Uint32(buf[len(buf)-8 : len(buf)-4])
+	if int64(footerLen) > int64(len(buf)-12) {
+		return fmt.Errorf("%w: footer length %d exceeds the %d bytes chunk", ErrTruncatedChunk, footerLen, len(buf))
+	}
+	return nil
+}
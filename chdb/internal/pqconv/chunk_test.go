@@ -0,0 +1,37 @@
+package pqconv
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestCheckChunk(t *testing.T) {
+	type row struct {
+		ID   int64  `parquet:"id"`
+		Name string `parquet:"name"`
+	}
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []row{{1, "a"}, {2, "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if err := CheckChunk(data); err != nil {
+		t.Fatalf("CheckChunk fail on a complete file, err: %s", err)
+	}
+
+	for name, chunk := range map[string][]byte{
+		"empty":        nil,
+		"header only":  data[:4],
+		"missing tail": data[:len(data)-1],
+		"half":         data[:len(data)/2],
+		"missing head": data[1:],
+		"short footer": append([]byte("PAR1"), data[len(data)-8:]...),
+	} {
+		if err := CheckChunk(chunk); !errors.Is(err, ErrTruncatedChunk) {
+			t.Errorf("%s: expected ErrTruncatedChunk, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package pqconv
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// DecimalPrecisionScale returns the precision and the scale of a DECIMAL leaf node, i.e. those of the
+// Decimal(P, S) column it comes from.
+func DecimalPrecisionScale(n parquet.Node) (precision, scale int, ok bool) {
+	if !n.Leaf() {
+		return 0, 0, false
+	}
+	lt := n.Type().LogicalType()
+	if lt == nil || lt.Decimal == nil {
+		return 0, 0, false
+	}
+	return int(lt.Decimal.Precision), int(lt.Decimal.Scale), true
+}
+
+// isDecimal reports whether t is annotated with the DECIMAL logical type.
+func isDecimal(t parquet.Type) bool {
+	lt := t.LogicalType()
+	return lt != nil && lt.Decimal != nil
+}
+
+// decimalValue decodes a DECIMAL value into its exact decimal representation, e.g. "-12.34", or into a *big.Rat
+// when asRat is set. The unscaled value is stored as an INT32, an INT64, or a big-endian two's complement byte array.
+func decimalValue(t parquet.Type, v parquet.Value, asRat bool) (any, error) {
+	unscaled := new(big.Int)
+	switch t.Kind() {
+	case parquet.Int32:
+		unscaled.SetInt64(int64(v.Int32()))
+	case parquet.Int64:
+		unscaled.SetInt64(v.Int64())
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		b := v.ByteArray()
+		unscaled.SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+		}
+	default:
+		return nil, fmt.Errorf("could not decode decimal stored as %s", t.Kind())
+	}
+	scale := int(t.LogicalType().Decimal.Scale)
+	if asRat {
+		return decimalRat(unscaled, scale), nil
+	}
+	return formatDecimal(unscaled, scale), nil
+}
+
+// decimalRat returns the value of an unscaled decimal value with scale fractional digits.
+func decimalRat(unscaled *big.Int, scale int) *big.Rat {
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+}
+
+// formatDecimal formats an unscaled decimal value with scale fractional digits.
+func formatDecimal(v *big.Int, scale int) string {
+	digits := new(big.Int).Abs(v).String()
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+	}
+	if scale <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
@@ -0,0 +1,588 @@
+package pqconv
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+// NOTE: this function is strictly unsafe and can lead to undefined behavior if the underlying slice is going out of scope or if it is being modified while in use.
+// Use this function ONLY if you know that both of the conditions are respected and you need to allocate less memory possible.
+func bytesToString(data []byte) string {
+	return *(*string)(unsafe.Pointer(&data))
+}
+
+// Decoder converts parquet values into Go values.
+type Decoder struct {
+	// UnsafeStrings makes decoded strings and byte slices reference the parquet buffer instead of copying it.
+	// Such values are only valid until the buffer is released or reused.
+	UnsafeStrings bool
+	// WidenUnsigned decodes unsigned integers as the next larger signed type, e.g. uint32 as int64,
+	// for consumers which don't handle unsigned types. UInt64 values are decoded as int64 when they fit,
+	// and are kept as uint64 otherwise.
+	WidenUnsigned bool
+	// UUIDStrings decodes UUID values as their canonical string instead of a uuid.UUID.
+	UUIDStrings bool
+	// DecimalStrings decodes DECIMAL values as their exact decimal text, e.g. "-12.34", instead of a *big.Rat.
+	DecimalStrings bool
+	// JSONAs selects how the values of JSON columns are decoded, one of the jsonval modes: as their text by default.
+	JSONAs string
+	// Location is the time zone of decoded timestamps, UTC when nil. Parquet doesn't record the time zone of
+	// ClickHouse columns, so it applies to all of them.
+	Location *time.Location
+	// Converters decode the top-level leaf columns with the matching names, instead of the built-in decoding.
+	// They are called with NULL values too.
+	Converters map[string]func(parquet.Value) (any, error)
+	// Projection restricts Row to the top-level fields with the given indexes, see Project.
+	// All the fields are decoded when it is nil.
+	Projection []int
+}
+
+// Row decodes a parquet row into one value per top-level field, calling set for each of them.
+// With a Projection, only the projected fields are decoded and set is called with their index in the Projection.
+func (d *Decoder) Row(fields []parquet.Field, row parquet.Row, set func(index int, value any)) error {
+	columns := make([][]parquet.Value, 0, len(fields))
+	row.Range(func(_ int, values []parquet.Value) bool {
+		columns = append(columns, values)
+		return true
+	})
+	if d.Projection != nil {
+		return d.projectedRow(fields, columns, set)
+	}
+	off := 0
+	for i, f := range fields {
+		n := leafCount(f)
+		if off+n > len(columns) {
+			return fmt.Errorf("row has %d columns, schema expects more", len(columns))
+		}
+		v, err := d.field(f, columns[off:off+n])
+		if err != nil {
+			return err
+		}
+		set(i, v)
+		off += n
+	}
+	return nil
+}
+
+// projectedRow decodes the projected fields of a row.
+func (d *Decoder) projectedRow(fields []parquet.Field, columns [][]parquet.Value, set func(index int, value any)) error {
+	offsets := make([]int, len(fields)+1)
+	for i, f := range fields {
+		offsets[i+1] = offsets[i] + leafCount(f)
+	}
+	if offsets[len(fields)] > len(columns) {
+		return fmt.Errorf("row has %d columns, schema expects more", len(columns))
+	}
+	for i, index := range d.Projection {
+		v, err := d.field(fields[index], columns[offsets[index]:offsets[index+1]])
+		if err != nil {
+			return err
+		}
+		set(i, v)
+	}
+	return nil
+}
+
+// field decodes the value of a top-level field from the values of its leaf columns.
+func (d *Decoder) field(f parquet.Field, columns [][]parquet.Value) (any, error) {
+	if convert, ok := d.Converters[f.Name()]; ok && f.Leaf() {
+		return convert(columns[0][0])
+	}
+	return d.node(f, levels{}, columns)
+}
+
+// Project returns the fields with the given names, in the order of names, along with their indexes in fields
+// to be used as a Decoder Projection. It errors when a name doesn't match any field.
+func Project(fields []parquet.Field, names []string) ([]parquet.Field, []int, error) {
+	projected := make([]parquet.Field, len(names))
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		index := -1
+		for j, f := range fields {
+			if f.Name() == name {
+				index = j
+				break
+			}
+		}
+		if index < 0 {
+			return nil, nil, fmt.Errorf("projected column %q not found in the result", name)
+		}
+		projected[i], indexes[i] = fields[index], index
+	}
+	return projected, indexes, nil
+}
+
+// Value decodes a single non-null leaf value of the given parquet type.
+func (d *Decoder) Value(t parquet.Type, v parquet.Value) (any, error) {
+	if isDecimal(t) {
+		return decimalValue(t, v, !d.DecimalStrings)
+	}
+	if isJSON(t) {
+		return jsonval.Decode(v.ByteArray(), d.JSONAs)
+	}
+	if isDate(t) {
+		return time.Unix(int64(v.Int32())*secondsPerDay, 0).UTC(), nil
+	}
+	if isUUID(t) {
+		u, err := uuid.FromBytes(v.ByteArray())
+		if err != nil {
+			return nil, err
+		}
+		if d.UUIDStrings {
+			return u.String(), nil
+		}
+		return u, nil
+	}
+	switch t.String() {
+	case "STRING":
+		// we check if the user has initialized the connection with the unsafeStringReader parameter, and in that case we use `bytesToString` method.
+		// otherwise, we fallback to the traditional way and we allocate a new string
+		if d.UnsafeStrings {
+			return bytesToString(v.ByteArray()), nil
+		}
+		return string(v.ByteArray()), nil
+	case "INT8", "INT(8,true)":
+		return int8(v.Int32()), nil //check if this is correct
+	case "INT16", "INT(16,true)":
+		return int16(v.Int32()), nil
+	case "INT64", "INT(64,true)":
+		return v.Int64(), nil
+	case "INT(64,false)":
+		if d.WidenUnsigned && v.Uint64() <= math.MaxInt64 {
+			return int64(v.Uint64()), nil
+		}
+		return v.Uint64(), nil
+	case "INT(32,false)":
+		if d.WidenUnsigned {
+			return int64(v.Uint32()), nil
+		}
+		return v.Uint32(), nil
+	case "INT(8,false)":
+		if d.WidenUnsigned {
+			return int16(v.Uint32()), nil
+		}
+		return uint8(v.Uint32()), nil //check if this is correct
+	case "INT(16,false)":
+		if d.WidenUnsigned {
+			return int32(v.Uint32()), nil
+		}
+		return uint16(v.Uint32()), nil
+	case "INT32", "INT(32,true)":
+		return v.Int32(), nil
+	case "FLOAT", "FLOAT32":
+		return v.Float(), nil
+	case "DOUBLE":
+		return v.Double(), nil
+	case "BOOLEAN":
+		return v.Boolean(), nil
+	case "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY":
+		if d.UnsafeStrings {
+			return v.ByteArray(), nil
+		}
+		return bytes.Clone(v.ByteArray()), nil
+	case "TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)", "TIME(isAdjustedToUTC=true,unit=MILLIS)":
+		return time.UnixMilli(v.Int64()).In(d.location()), nil
+	case "TIMESTAMP(isAdjustedToUTC=true,unit=MICROS)", "TIME(isAdjustedToUTC=true,unit=MICROS)":
+		return time.UnixMicro(v.Int64()).In(d.location()), nil
+	case "TIMESTAMP(isAdjustedToUTC=true,unit=NANOS)", "TIME(isAdjustedToUTC=true,unit=NANOS)":
+		return time.Unix(0, v.Int64()).In(d.location()), nil
+	case "TIMESTAMP(isAdjustedToUTC=false,unit=MILLIS)", "TIME(isAdjustedToUTC=false,unit=MILLIS)":
+		return time.UnixMilli(v.Int64()), nil
+	case "TIMESTAMP(isAdjustedToUTC=false,unit=MICROS)", "TIME(isAdjustedToUTC=false,unit=MICROS)":
+		return time.UnixMicro(v.Int64()), nil
+	case "TIMESTAMP(isAdjustedToUTC=false,unit=NANOS)", "TIME(isAdjustedToUTC=false,unit=NANOS)":
+		return time.Unix(0, v.Int64()), nil
+	}
+	return nil, fmt.Errorf("could not cast to type: %s", t)
+}
+
+// location returns the time zone of decoded timestamps.
+func (d *Decoder) location() *time.Location {
+	if d.Location != nil {
+		return d.Location
+	}
+	return time.UTC
+}
+
+// secondsPerDay converts the days since the epoch of DATE values to seconds.
+const secondsPerDay = 24 * 60 * 60
+
+// isDate reports whether t is annotated with the DATE logical type, the number of days since 1970-01-01 which
+// ClickHouse writes for Date and Date32 columns. Values are decoded as midnight UTC, including dates before 1970.
+func isDate(t parquet.Type) bool {
+	lt := t.LogicalType()
+	return lt != nil && lt.Date != nil
+}
+
+// isJSON reports whether t is annotated with the JSON logical type.
+func isJSON(t parquet.Type) bool {
+	lt := t.LogicalType()
+	return lt != nil && lt.Json != nil
+}
+
+// isUUID reports whether t is annotated with the UUID logical type.
+func isUUID(t parquet.Type) bool {
+	lt := t.LogicalType()
+	return lt != nil && lt.UUID != nil
+}
+
+// levels tracks the repetition and definition levels reached while walking nested nodes.
+type levels struct {
+	repetitionDepth int
+	definitionLevel int
+}
+
+// node assembles the value of a schema node from the values of its leaf columns.
+func (d *Decoder) node(n parquet.Node, lv levels, columns [][]parquet.Value) (any, error) {
+	if n.Optional() {
+		lv.definitionLevel++
+		if columns[0][0].DefinitionLevel() < lv.definitionLevel {
+			return nil, nil
+		}
+	}
+	if n.Leaf() {
+		v := columns[0][0]
+		if v.IsNull() {
+			return nil, nil
+		}
+		return d.Value(n.Type(), v)
+	}
+	if isMap(n) {
+		return d.mapValue(n, lv, columns)
+	}
+	if isList(n) {
+		return d.list(n, lv, columns)
+	}
+	return d.group(n, lv, columns)
+}
+
+// mapValue assembles a MAP node into a map, see mapType.
+func (d *Decoder) mapValue(n parquet.Node, lv levels, columns [][]parquet.Value) (any, error) {
+	key, value := mapKeyValue(n)
+	lv.repetitionDepth++
+	lv.definitionLevel++
+	var segments [][][]parquet.Value
+	if columns[0][0].DefinitionLevel() >= lv.definitionLevel {
+		segments = splitRepeated(columns, lv.repetitionDepth)
+	}
+	t := d.mapType(key, value)
+	out := reflect.MakeMapWithSize(t, len(segments))
+	keyColumns := leafCount(key)
+	for _, seg := range segments {
+		k, err := d.node(key, lv, seg[:keyColumns])
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.node(value, lv, seg[keyColumns:])
+		if err != nil {
+			return nil, err
+		}
+		kv := reflect.ValueOf(k)
+		if t.Key().Kind() == reflect.String && kv.Kind() != reflect.String {
+			kv = reflect.ValueOf(fmt.Sprint(k))
+		} else if kv.Type() != t.Key() {
+			return nil, fmt.Errorf("map key decoded as %s, expected %s", kv.Type(), t.Key())
+		}
+		vv := reflect.New(t.Elem()).Elem()
+		if v != nil {
+			if vv.Kind() == reflect.Pointer {
+				vv.Set(reflect.New(vv.Type().Elem()))
+				vv.Elem().Set(reflect.ValueOf(v))
+			} else {
+				vv.Set(reflect.ValueOf(v))
+			}
+		}
+		out.SetMapIndex(kv, vv)
+	}
+	return out.Interface(), nil
+}
+
+// mapType returns the type of the maps a MAP node is decoded into: a typed map when the types of its keys and
+// values are known, e.g. map[string]uint8 for Map(String, UInt8) and map[uint64]*string for
+// Map(UInt64, Nullable(String)), and array values are decoded into the slices of listType, e.g. map[uint8][]string
+// for Map(UInt8, Array(String)). Values of other types are decoded into a map[K]any, and keys which can't be
+// map keys, e.g. the []byte of FixedString keys, are formatted as strings.
+func (d *Decoder) mapType(key, value parquet.Node) reflect.Type {
+	keyType := d.elementType(key)
+	if keyType == nil || !keyType.Comparable() || keyType.Kind() == reflect.Pointer {
+		keyType = reflect.TypeOf("")
+	}
+	valueType := d.elementType(value)
+	if valueType == nil && isList(value) {
+		valueType = d.listType(value)
+	}
+	if valueType == nil {
+		valueType = reflect.TypeOf((*any)(nil)).Elem()
+	}
+	return reflect.MapOf(keyType, valueType)
+}
+
+// list assembles a LIST node into a slice, typed when the element type is known.
+func (d *Decoder) list(n parquet.Node, lv levels, columns [][]parquet.Value) (any, error) {
+	elem := listElement(n)
+	lv.repetitionDepth++
+	lv.definitionLevel++
+	var segments [][][]parquet.Value
+	if columns[0][0].DefinitionLevel() >= lv.definitionLevel {
+		segments = splitRepeated(columns, lv.repetitionDepth)
+	}
+	out := reflect.MakeSlice(d.listType(n), len(segments), len(segments))
+	for i, seg := range segments {
+		v, err := d.node(elem, lv, seg)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		dst := out.Index(i)
+		if dst.Kind() == reflect.Pointer {
+			// nullable elements of typed slices are stored as pointers, leaving nil for NULL
+			p := reflect.New(dst.Type().Elem())
+			dst.Set(p)
+			dst = p.Elem()
+		}
+		src := reflect.ValueOf(v)
+		if dst.Kind() != reflect.Interface && src.Type() != dst.Type() {
+			return nil, fmt.Errorf("list element decoded as %s, expected %s", src.Type(), dst.Type())
+		}
+		dst.Set(src)
+	}
+	return out.Interface(), nil
+}
+
+// listType returns the type of the slices a LIST node is decoded into: a typed slice when the type of its elements
+// is known, see elementType, and a []any otherwise.
+func (d *Decoder) listType(n parquet.Node) reflect.Type {
+	elem := listElement(n)
+	if t := geoType(elem); t != nil {
+		return reflect.SliceOf(t)
+	}
+	if t := d.elementType(elem); t != nil {
+		return reflect.SliceOf(t)
+	}
+	return reflect.TypeOf([]any(nil))
+}
+
+// elementType returns the Go type of the elements of a typed slice of list elements: the decoded type of
+// the leaf for required elements, e.g. bool for Array(Bool), and a pointer to it for nullable ones, e.g. *int64
+// for Array(Nullable(Int64)). It returns nil when the elements are decoded into a []any.
+func (d *Decoder) elementType(n parquet.Node) reflect.Type {
+	if !n.Leaf() || n.Repeated() {
+		return nil
+	}
+	t := d.valueType(n.Type())
+	if t != nil && n.Optional() {
+		return reflect.PointerTo(t)
+	}
+	return t
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+	uuidType  = reflect.TypeOf(uuid.UUID{})
+	ratType   = reflect.TypeOf((*big.Rat)(nil))
+)
+
+// valueType returns the Go type Value decodes values of the given parquet type into,
+// or nil when it is unsupported or not always the same.
+func (d *Decoder) valueType(t parquet.Type) reflect.Type {
+	if isUUID(t) && !d.UUIDStrings {
+		return uuidType
+	}
+	if isDecimal(t) && !d.DecimalStrings {
+		return ratType
+	}
+	if isDecimal(t) || isUUID(t) {
+		return reflect.TypeOf("")
+	}
+	if isDate(t) {
+		return timeType
+	}
+	if isJSON(t) {
+		return jsonval.Type(d.JSONAs)
+	}
+	switch t.String() {
+	case "STRING":
+		return reflect.TypeOf("")
+	case "INT8", "INT(8,true)":
+		return reflect.TypeOf(int8(0))
+	case "INT16", "INT(16,true)":
+		return reflect.TypeOf(int16(0))
+	case "INT32", "INT(32,true)":
+		return reflect.TypeOf(int32(0))
+	case "INT64", "INT(64,true)":
+		return reflect.TypeOf(int64(0))
+	case "INT(8,false)":
+		if d.WidenUnsigned {
+			return reflect.TypeOf(int16(0))
+		}
+		return reflect.TypeOf(uint8(0))
+	case "INT(16,false)":
+		if d.WidenUnsigned {
+			return reflect.TypeOf(int32(0))
+		}
+		return reflect.TypeOf(uint16(0))
+	case "INT(32,false)":
+		if d.WidenUnsigned {
+			return reflect.TypeOf(int64(0))
+		}
+		return reflect.TypeOf(uint32(0))
+	case "INT(64,false)":
+		// widened values are int64 or uint64 depending on whether they fit
+		if d.WidenUnsigned {
+			return nil
+		}
+		return reflect.TypeOf(uint64(0))
+	case "FLOAT", "FLOAT32":
+		return reflect.TypeOf(float32(0))
+	case "DOUBLE":
+		return reflect.TypeOf(float64(0))
+	case "BOOLEAN":
+		return reflect.TypeOf(false)
+	case "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY":
+		return bytesType
+	}
+	if strings.HasPrefix(t.String(), "TIMESTAMP(") || strings.HasPrefix(t.String(), "TIME(") {
+		if _, err := d.Value(t, parquet.ValueOf(int64(0))); err == nil {
+			return timeType
+		}
+	}
+	return nil
+}
+
+// group assembles a group node. Point shaped groups become [2]float64, other groups a []any of their fields.
+func (d *Decoder) group(n parquet.Node, lv levels, columns [][]parquet.Value) (any, error) {
+	fields := n.Fields()
+	values := make([]any, len(fields))
+	off := 0
+	for i, f := range fields {
+		k := leafCount(f)
+		v, err := d.node(f, lv, columns[off:off+k])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		off += k
+	}
+	if isPoint(n) {
+		return [2]float64{values[0].(float64), values[1].(float64)}, nil
+	}
+	return values, nil
+}
+
+// splitRepeated splits the values of the given columns into the elements of a repeated node.
+// An element starts at every value whose repetition level is not deeper than the node depth.
+func splitRepeated(columns [][]parquet.Value, depth int) [][][]parquet.Value {
+	var out [][][]parquet.Value
+	cursors := make([]int, len(columns))
+	for cursors[0] < len(columns[0]) {
+		seg := make([][]parquet.Value, len(columns))
+		for j, col := range columns {
+			start := cursors[j]
+			end := start + 1
+			for end < len(col) && col[end].RepetitionLevel() > depth {
+				end++
+			}
+			seg[j] = col[start:end]
+			cursors[j] = end
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+func leafCount(n parquet.Node) int {
+	if n.Leaf() {
+		return 1
+	}
+	count := 0
+	for _, f := range n.Fields() {
+		count += leafCount(f)
+	}
+	return count
+}
+
+// isList reports whether the node is a LIST. The check is structural since the LIST
+// annotation of groups is not preserved when the schema is read back from a file.
+func isList(n parquet.Node) bool {
+	if n.Leaf() || n.Repeated() {
+		return false
+	}
+	fields := n.Fields()
+	return len(fields) == 1 && fields[0].Repeated()
+}
+
+// isMap reports whether the node is a MAP, made of a repeated group of keys and values.
+func isMap(n parquet.Node) bool {
+	if n.Leaf() || n.Repeated() {
+		return false
+	}
+	lt := n.Type().LogicalType()
+	if lt == nil || lt.Map == nil {
+		return false
+	}
+	fields := n.Fields()
+	return len(fields) == 1 && fields[0].Repeated() && !fields[0].Leaf() && len(fields[0].Fields()) == 2
+}
+
+// mapKeyValue returns the key and the value nodes of a MAP node.
+func mapKeyValue(n parquet.Node) (key, value parquet.Node) {
+	kv := n.Fields()[0].Fields()
+	return kv[0], kv[1]
+}
+
+// listElement returns the element node of a LIST node, handling both the
+// three-level (list/element) and the legacy two-level layouts.
+func listElement(n parquet.Node) parquet.Node {
+	repeated := n.Fields()[0]
+	if repeated.Leaf() || len(repeated.Fields()) != 1 {
+		return parquet.Required(repeated)
+	}
+	return repeated.Fields()[0]
+}
+
+// isPoint reports whether the node is a tuple of two Float64, which is how ClickHouse stores a Point.
+func isPoint(n parquet.Node) bool {
+	if n.Leaf() || isList(n) {
+		return false
+	}
+	fields := n.Fields()
+	if len(fields) != 2 {
+		return false
+	}
+	for _, f := range fields {
+		if !f.Leaf() || f.Optional() || f.Repeated() || f.Type().Kind() != parquet.Double {
+			return false
+		}
+	}
+	return true
+}
+
+// geoType returns the Go type of geo shaped nodes: a Point is decoded as [2]float64,
+// and Ring, Polygon and MultiPolygon as slices of it. It returns nil for other nodes.
+func geoType(n parquet.Node) reflect.Type {
+	if n.Optional() {
+		return nil
+	}
+	if isPoint(n) {
+		return reflect.TypeOf([2]float64{})
+	}
+	if isList(n) {
+		if t := geoType(listElement(n)); t != nil {
+			return reflect.SliceOf(t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,536 @@
+package pqconv
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/jsonval"
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestDecoderRowNested(t *testing.T) {
+	point := parquet.Group{"1": parquet.Leaf(parquet.DoubleType), "2": parquet.Leaf(parquet.DoubleType)}
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"arr":  parquet.Optional(parquet.List(parquet.Optional(parquet.String()))),
+		"n":    parquet.Optional(parquet.Int(64)),
+		"poly": parquet.List(parquet.List(point)),
+		"pt":   point,
+	})
+	rows := []parquet.Row{
+		{
+			// arr: ['a', NULL, 'c']
+			parquet.ValueOf("a").Level(0, 3, 0), parquet.NullValue().Level(1, 2, 0), parquet.ValueOf("c").Level(1, 3, 0),
+			// n: 5
+			parquet.ValueOf(int64(5)).Level(0, 1, 1),
+			// poly: [[(0, 10), (1, 11)], [(2, 12)]]
+			parquet.ValueOf(0.0).Level(0, 2, 2), parquet.ValueOf(1.0).Level(2, 2, 2), parquet.ValueOf(2.0).Level(1, 2, 2),
+			parquet.ValueOf(10.0).Level(0, 2, 3), parquet.ValueOf(11.0).Level(2, 2, 3), parquet.ValueOf(12.0).Level(1, 2, 3),
+			// pt: (1.5, 2.5)
+			parquet.ValueOf(1.5).Level(0, 0, 4), parquet.ValueOf(2.5).Level(0, 0, 5),
+		},
+		{
+			// arr: NULL
+			parquet.NullValue().Level(0, 0, 0),
+			// n: NULL
+			parquet.NullValue().Level(0, 0, 1),
+			// poly: []
+			parquet.NullValue().Level(0, 0, 2), parquet.NullValue().Level(0, 0, 3),
+			// pt: (0, 0)
+			parquet.ValueOf(0.0).Level(0, 0, 4), parquet.ValueOf(0.0).Level(0, 0, 5),
+		},
+	}
+	a, c := "a", "c"
+	expected := [][]any{
+		{
+			[]*string{&a, nil, &c},
+			int64(5),
+			[][][2]float64{{{0, 10}, {1, 11}}, {{2, 12}}},
+			[2]float64{1.5, 2.5},
+		},
+		{
+			nil,
+			nil,
+			[][][2]float64{},
+			[2]float64{0, 0},
+		},
+	}
+
+	decoder := Decoder{}
+	for i, row := range rows {
+		got := make([]any, len(schema.Fields()))
+		if err := decoder.Row(schema.Fields(), row, func(index int, v any) { got[index] = v }); err != nil {
+			t.Fatalf("row %d: decode fail, err: %s", i, err)
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("row %d: expected %#v, got %#v", i, expected[i], got)
+		}
+	}
+}
+
+func TestDecoderMap(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"m":  parquet.Map(parquet.String(), parquet.Optional(parquet.Uint(8))),
+		"m2": parquet.Map(parquet.Int(64), parquet.List(parquet.String())),
+	})
+	rows := []parquet.Row{
+		{
+			// m: {'a': 1, 'b': NULL}
+			parquet.ValueOf("a").Level(0, 1, 0), parquet.ValueOf("b").Level(1, 1, 0),
+			parquet.ValueOf(int32(1)).Level(0, 2, 1), parquet.NullValue().Level(1, 1, 1),
+			// m2: {7: ['x']}
+			parquet.ValueOf(int64(7)).Level(0, 1, 2),
+			parquet.ValueOf("x").Level(0, 2, 3),
+		},
+		{
+			// m: {}
+			parquet.NullValue().Level(0, 0, 0), parquet.NullValue().Level(0, 0, 1),
+			// m2: {}
+			parquet.NullValue().Level(0, 0, 2), parquet.NullValue().Level(0, 0, 3),
+		},
+	}
+	one := uint8(1)
+	expected := [][]any{
+		{map[string]*uint8{"a": &one, "b": nil}, map[int64][]string{7: {"x"}}},
+		{map[string]*uint8{}, map[int64][]string{}},
+	}
+	for i, row := range rows {
+		got := make([]any, len(schema.Fields()))
+		if err := (&Decoder{}).Row(schema.Fields(), row, func(index int, v any) { got[index] = v }); err != nil {
+			t.Fatalf("row %d: decode fail, err: %s", i, err)
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("row %d: expected %#v, got %#v", i, expected[i], got)
+		}
+	}
+	if got := ClickHouseType(schema.Fields()[0]); got != "Map(String, Nullable(UInt8))" {
+		t.Errorf("expected Map(String, Nullable(UInt8)), got %s", got)
+	}
+	if got := ScanType(schema.Fields()[1]); got != reflect.TypeOf(map[int64][]string(nil)) {
+		t.Errorf("expected map[int64][]string scan type, got %s", got)
+	}
+}
+
+func TestColumnsNested(t *testing.T) {
+	point := parquet.Group{"1": parquet.Leaf(parquet.DoubleType), "2": parquet.Leaf(parquet.DoubleType)}
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"poly": parquet.List(parquet.List(point)),
+		"pt":   point,
+	})
+	cols := Columns(schema.Fields())
+	if cols[0].Type != "Array(Array(Tuple(Float64, Float64)))" || cols[0].ScanType != reflect.TypeOf([][][2]float64{}) {
+		t.Errorf("unexpected polygon metadata %+v", cols[0])
+	}
+	if cols[1].Type != "Tuple(Float64, Float64)" || cols[1].ScanType != reflect.TypeOf([2]float64{}) {
+		t.Errorf("unexpected point metadata %+v", cols[1])
+	}
+}
+
+func TestSetTypes(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{"lc": parquet.String(), "n": parquet.Int(32)})
+	cols := Columns(schema.Fields())
+	SetTypes(cols, map[string]string{"lc": "LowCardinality(String)", "other": "UInt8"})
+	if cols[0].Type != "LowCardinality(String)" || cols[0].ScanType != reflect.TypeOf("") {
+		t.Errorf("unexpected described metadata %+v", cols[0])
+	}
+	if cols[1].Type != "Int32" {
+		t.Errorf("expected the mapped type of an undescribed column, got %s", cols[1].Type)
+	}
+}
+
+func TestElementNames(t *testing.T) {
+	pair := parquet.Group{"id": parquet.Int(64), "name": parquet.Optional(parquet.String())}
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"m":      parquet.Map(parquet.String(), parquet.String()),
+		"nested": parquet.List(pair),
+		"pair":   pair,
+		"s":      parquet.String(),
+	})
+	cols := Columns(schema.Fields())
+	for i, expected := range [][]string{nil, {"id", "name"}, {"id", "name"}, nil} {
+		if !reflect.DeepEqual(cols[i].ElementNames, expected) {
+			t.Errorf("%s: expected element names %q, got %q", cols[i].Name, expected, cols[i].ElementNames)
+		}
+	}
+	if cols[1].Type != "Array(Tuple(Int64, Nullable(String)))" || cols[1].ScanType != reflect.TypeOf([]any{}) {
+		t.Errorf("unexpected nested metadata %+v", cols[1])
+	}
+}
+
+func TestDecoderWidenUnsigned(t *testing.T) {
+	tests := []struct {
+		typ     parquet.Type
+		value   parquet.Value
+		exact   any
+		widened any
+	}{
+		{parquet.Uint(8).Type(), parquet.ValueOf(uint8(200)), uint8(200), int16(200)},
+		{parquet.Uint(16).Type(), parquet.ValueOf(uint16(60000)), uint16(60000), int32(60000)},
+		{parquet.Uint(32).Type(), parquet.ValueOf(uint32(4000000000)), uint32(4000000000), int64(4000000000)},
+		{parquet.Uint(64).Type(), parquet.ValueOf(uint64(5)), uint64(5), int64(5)},
+		{parquet.Uint(64).Type(), parquet.ValueOf(uint64(1 << 63)), uint64(1 << 63), uint64(1 << 63)},
+	}
+	for _, tt := range tests {
+		got, err := (&Decoder{}).Value(tt.typ, tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.exact {
+			t.Errorf("%s: expected %#v, got %#v", tt.typ, tt.exact, got)
+		}
+		got, err = (&Decoder{WidenUnsigned: true}).Value(tt.typ, tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.widened {
+			t.Errorf("%s widened: expected %#v, got %#v", tt.typ, tt.widened, got)
+		}
+	}
+}
+
+func TestDecoderDecimal(t *testing.T) {
+	tests := []struct {
+		node     parquet.Node
+		value    parquet.Value
+		expected string
+	}{
+		{parquet.Decimal(2, 9, parquet.Int32Type), parquet.ValueOf(int32(1234)), "12.34"},
+		{parquet.Decimal(2, 9, parquet.Int32Type), parquet.ValueOf(int32(-5)), "-0.05"},
+		{parquet.Decimal(4, 18, parquet.Int64Type), parquet.ValueOf(int64(123456789)), "12345.6789"},
+		{parquet.Decimal(0, 18, parquet.Int64Type), parquet.ValueOf(int64(-42)), "-42"},
+		// Decimal128 values are big-endian two's complement
+		{parquet.Decimal(3, 38, parquet.FixedLenByteArrayType(16)),
+			parquet.ValueOf([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfb, 0x2e}),
+			"-1.234"},
+	}
+	for _, tt := range tests {
+		got, err := (&Decoder{}).Value(tt.node.Type(), tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, ok := got.(*big.Rat); !ok || r.FloatString(int(tt.node.Type().LogicalType().Decimal.Scale)) != tt.expected {
+			t.Errorf("%s: expected the *big.Rat of %s, got %#v", tt.node.Type(), tt.expected, got)
+		}
+		got, err = (&Decoder{DecimalStrings: true}).Value(tt.node.Type(), tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %q, got %#v", tt.node.Type(), tt.expected, got)
+		}
+	}
+
+	node := parquet.Optional(parquet.Decimal(2, 9, parquet.Int32Type))
+	if p, s, ok := DecimalPrecisionScale(node); !ok || p != 9 || s != 2 {
+		t.Errorf("expected precision 9 and scale 2, got %d, %d, %t", p, s, ok)
+	}
+	if got := ScanType(node); got != reflect.TypeOf((**big.Rat)(nil)) {
+		t.Errorf("expected **big.Rat scan type, got %s", got)
+	}
+	if got := (&Decoder{DecimalStrings: true}).ScanType(node); got != reflect.TypeOf((*string)(nil)) {
+		t.Errorf("expected *string scan type, got %s", got)
+	}
+	if _, _, ok := DecimalPrecisionScale(parquet.Int(32)); ok {
+		t.Errorf("expected no precision for an INT32 node")
+	}
+}
+
+func TestDecoderUUID(t *testing.T) {
+	u := uuid.MustParse("01234567-89ab-cdef-0123-456789abcdef")
+	node := parquet.Optional(parquet.UUID())
+	got, err := (&Decoder{}).Value(node.Type(), parquet.ValueOf(u[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != u {
+		t.Errorf("expected %v, got %#v", u, got)
+	}
+	if got := ScanType(node); got != reflect.TypeOf((*uuid.UUID)(nil)) {
+		t.Errorf("expected *uuid.UUID scan type, got %s", got)
+	}
+	if got := ScanType(parquet.List(parquet.UUID())); got != reflect.TypeOf([]uuid.UUID(nil)) {
+		t.Errorf("expected []uuid.UUID scan type, got %s", got)
+	}
+	d := &Decoder{UUIDStrings: true}
+	got, err = d.Value(node.Type(), parquet.ValueOf(u[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != u.String() {
+		t.Errorf("expected %q, got %#v", u.String(), got)
+	}
+	if got := d.ScanType(node); got != reflect.TypeOf((*string)(nil)) {
+		t.Errorf("expected *string scan type, got %s", got)
+	}
+}
+
+func TestDecoderDate(t *testing.T) {
+	node := parquet.Date()
+	for days, expected := range map[int32]time.Time{
+		0:       time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		19724:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		-25567:  time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),   // lowest Date32
+		2932896: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), // highest Date32
+	} {
+		got, err := (&Decoder{}).Value(node.Type(), parquet.ValueOf(days))
+		if err != nil {
+			t.Fatalf("%d: decode fail, err: %s", days, err)
+		}
+		if got != expected {
+			t.Errorf("%d: expected %v, got %#v", days, expected, got)
+		}
+	}
+	if got := ScanType(node); got != reflect.TypeOf(time.Time{}) {
+		t.Errorf("expected time.Time scan type, got %s", got)
+	}
+	if got := ScanType(parquet.Optional(node)); got != reflect.TypeOf((*time.Time)(nil)) {
+		t.Errorf("expected *time.Time scan type, got %s", got)
+	}
+	if got := (&Decoder{}).elementType(parquet.Date()); got != reflect.TypeOf(time.Time{}) {
+		t.Errorf("expected time.Time elements, got %v", got)
+	}
+}
+
+func TestDecoderLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	node := parquet.Timestamp(parquet.Millisecond)
+	got, err := (&Decoder{Location: loc}).Value(node.Type(), parquet.ValueOf(int64(1704164645006)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := got.(time.Time); ts.Location() != loc || !ts.Equal(time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)) {
+		t.Errorf("expected 2024-01-02 05:04:05.006 UTC+2, got %v", ts)
+	}
+}
+
+func TestDecoderConverters(t *testing.T) {
+	type permissions struct{ Read, Write bool }
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"flags": parquet.Optional(parquet.Int(32)),
+		"name":  parquet.String(),
+	})
+	decoder := Decoder{Converters: map[string]func(parquet.Value) (any, error){
+		"flags": func(v parquet.Value) (any, error) {
+			if v.IsNull() {
+				return permissions{}, nil
+			}
+			return permissions{Read: v.Int32()&1 != 0, Write: v.Int32()&2 != 0}, nil
+		},
+	}}
+	rows := []parquet.Row{
+		{parquet.ValueOf(int32(3)).Level(0, 1, 0), parquet.ValueOf("a").Level(0, 0, 1)},
+		{parquet.NullValue().Level(0, 0, 0), parquet.ValueOf("b").Level(0, 0, 1)},
+	}
+	expected := [][]any{
+		{permissions{Read: true, Write: true}, "a"},
+		{permissions{}, "b"},
+	}
+	for i, row := range rows {
+		got := make([]any, 2)
+		if err := decoder.Row(schema.Fields(), row, func(index int, v any) { got[index] = v }); err != nil {
+			t.Fatalf("row %d: decode fail, err: %s", i, err)
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("row %d: expected %#v, got %#v", i, expected[i], got)
+		}
+	}
+}
+
+func TestDecoderCopiesValues(t *testing.T) {
+	buf := []byte("payload")
+	str := parquet.ValueOf(buf)
+
+	got, err := (&Decoder{}).Value(parquet.ByteArrayType, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsafeGot, err := (&Decoder{UnsafeStrings: true}).Value(parquet.ByteArrayType, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(str.ByteArray(), "XXXXXXX")
+	if string(got.([]byte)) != "payload" {
+		t.Errorf("expected the decoded bytes to be a copy, got %s", got)
+	}
+	if string(unsafeGot.([]byte)) != "XXXXXXX" {
+		t.Errorf("expected the unsafe decoded bytes to reference the buffer, got %s", unsafeGot)
+	}
+}
+
+func TestScanTypeNullable(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"a": parquet.Int(64),
+		"b": parquet.Optional(parquet.Int(64)),
+	})
+	cols := Columns(schema.Fields())
+	if cols[0].ScanType != reflect.TypeOf(int64(0)) || cols[0].Nullable {
+		t.Errorf("expected a non nullable int64 column, got %v %v", cols[0].ScanType, cols[0].Nullable)
+	}
+	if cols[1].ScanType != reflect.TypeOf((*int64)(nil)) || !cols[1].Nullable {
+		t.Errorf("expected a nullable *int64 column, got %v %v", cols[1].ScanType, cols[1].Nullable)
+	}
+}
+
+func TestScanTypeMatchesValue(t *testing.T) {
+	for _, tc := range []struct {
+		node  parquet.Node
+		value parquet.Value
+	}{
+		{parquet.Leaf(parquet.BooleanType), parquet.ValueOf(true)},
+		{parquet.Int(8), parquet.ValueOf(int32(-1))},
+		{parquet.Int(16), parquet.ValueOf(int32(-1))},
+		{parquet.Int(32), parquet.ValueOf(int32(-1))},
+		{parquet.Int(64), parquet.ValueOf(int64(-1))},
+		{parquet.Uint(8), parquet.ValueOf(int32(1))},
+		{parquet.Uint(16), parquet.ValueOf(int32(1))},
+		{parquet.Uint(32), parquet.ValueOf(int32(1))},
+		{parquet.Uint(64), parquet.ValueOf(int64(1))},
+		{parquet.Leaf(parquet.FloatType), parquet.ValueOf(float32(1.5))},
+		{parquet.Leaf(parquet.DoubleType), parquet.ValueOf(1.5)},
+		{parquet.String(), parquet.ValueOf("a")},
+		{parquet.Leaf(parquet.ByteArrayType), parquet.ValueOf([]byte("a"))},
+		{parquet.Date(), parquet.ValueOf(int32(1))},
+		{parquet.Timestamp(parquet.Millisecond), parquet.ValueOf(int64(1))},
+		{parquet.UUID(), parquet.ValueOf(make([]byte, 16))},
+		{parquet.Decimal(2, 9, parquet.Int32Type), parquet.ValueOf(int32(1234))},
+		{parquet.JSON(), parquet.ValueOf([]byte(`{"a":1}`))},
+	} {
+		for _, d := range []*Decoder{{}, {WidenUnsigned: true}, {UUIDStrings: true, JSONAs: jsonval.Map}} {
+			v, err := d.Value(tc.node.Type(), tc.value)
+			if err != nil {
+				t.Fatalf("%s: decode fail, err: %s", tc.node.Type(), err)
+			}
+			if got, expected := d.ScanType(tc.node), reflect.TypeOf(v); got != expected {
+				if d.WidenUnsigned && tc.node.Type().String() == "INT(64,false)" && got == reflect.TypeOf((*any)(nil)).Elem() {
+					// widened UInt64 values are int64 or uint64 depending on whether they fit
+					continue
+				}
+				t.Errorf("%s, %+v: expected the %s scan type of the values, got %s", tc.node.Type(), *d, expected, got)
+			}
+		}
+	}
+}
+
+func TestDecoderTypedLists(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"flags":  parquet.List(parquet.Leaf(parquet.BooleanType)),
+		"maybes": parquet.List(parquet.Optional(parquet.Leaf(parquet.BooleanType))),
+	})
+	rows := []parquet.Row{
+		{
+			// flags: [true, false], maybes: [true, NULL]
+			parquet.ValueOf(true).Level(0, 1, 0), parquet.ValueOf(false).Level(1, 1, 0),
+			parquet.ValueOf(true).Level(0, 2, 1), parquet.NullValue().Level(1, 1, 1),
+		},
+		{
+			// flags: [], maybes: [NULL, NULL]
+			parquet.NullValue().Level(0, 0, 0),
+			parquet.NullValue().Level(0, 1, 1), parquet.NullValue().Level(1, 1, 1),
+		},
+		{
+			// flags: [true], maybes: []
+			parquet.ValueOf(true).Level(0, 1, 0),
+			parquet.NullValue().Level(0, 0, 1),
+		},
+	}
+	yes := true
+	expected := [][]any{
+		{[]bool{true, false}, []*bool{&yes, nil}},
+		{[]bool{}, []*bool{nil, nil}},
+		{[]bool{true}, []*bool{}},
+	}
+	decoder := Decoder{}
+	for i, row := range rows {
+		got := make([]any, 2)
+		if err := decoder.Row(schema.Fields(), row, func(index int, v any) { got[index] = v }); err != nil {
+			t.Fatalf("row %d: decode fail, err: %s", i, err)
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("row %d: expected %#v, got %#v", i, expected[i], got)
+		}
+	}
+
+	cols := Columns(schema.Fields())
+	if cols[0].Type != "Array(Bool)" || cols[0].ScanType != reflect.TypeOf([]bool{}) {
+		t.Errorf("unexpected Array(Bool) metadata %+v", cols[0])
+	}
+	if cols[1].Type != "Array(Nullable(Bool))" || cols[1].ScanType != reflect.TypeOf([]*bool{}) {
+		t.Errorf("unexpected Array(Nullable(Bool)) metadata %+v", cols[1])
+	}
+}
+
+func TestDecoderUntypedLists(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"big": parquet.List(parquet.Uint(64)),
+	})
+	row := parquet.Row{parquet.ValueOf(uint64(5)).Level(0, 1, 0), parquet.ValueOf(uint64(1<<63)).Level(1, 1, 0)}
+	var got any
+	decoder := Decoder{WidenUnsigned: true}
+	if err := decoder.Row(schema.Fields(), row, func(_ int, v any) { got = v }); err != nil {
+		t.Fatalf("decode fail, err: %s", err)
+	}
+	// widened UInt64 values have different types, so they can't share a typed slice
+	if !reflect.DeepEqual(got, []any{int64(5), uint64(1 << 63)}) {
+		t.Errorf("expected [5 9223372036854775808] as []any, got %#v", got)
+	}
+}
+
+func TestDecoderProjection(t *testing.T) {
+	schema := parquet.NewSchema("schema", parquet.Group{
+		"a":   parquet.Int(64),
+		"arr": parquet.List(parquet.String()),
+		"b":   parquet.Optional(parquet.String()),
+	})
+	row := parquet.Row{
+		parquet.ValueOf(int64(1)).Level(0, 0, 0),
+		parquet.ValueOf("x").Level(0, 1, 1), parquet.ValueOf("y").Level(1, 1, 1),
+		parquet.ValueOf("z").Level(0, 1, 2),
+	}
+	fields, projection, err := Project(schema.Fields(), []string{"b", "arr"})
+	if err != nil {
+		t.Fatalf("Project fail, err: %s", err)
+	}
+	if fields[0].Name() != "b" || fields[1].Name() != "arr" || !reflect.DeepEqual(projection, []int{2, 1}) {
+		t.Fatalf("unexpected projection %v", projection)
+	}
+	decoder := Decoder{Projection: projection}
+	got := make([]any, len(projection))
+	if err := decoder.Row(schema.Fields(), row, func(index int, v any) { got[index] = v }); err != nil {
+		t.Fatalf("decode fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(got, []any{"z", []string{"x", "y"}}) {
+		t.Errorf("expected [z [x y]], got %#v", got)
+	}
+
+	if _, _, err := Project(schema.Fields(), []string{"a", "missing"}); err == nil {
+		t.Errorf("expected an error for a missing column")
+	}
+}
+
+func TestDecoderJSON(t *testing.T) {
+	node := parquet.JSON()
+	value := parquet.ValueOf(`{"a":1}`)
+	for mode, expected := range map[string]any{
+		jsonval.String: `{"a":1}`,
+		jsonval.Raw:    json.RawMessage(`{"a":1}`),
+		jsonval.Map:    map[string]any{"a": int64(1)},
+	} {
+		d := &Decoder{JSONAs: mode}
+		got, err := d.Value(node.Type(), value)
+		if err != nil {
+			t.Fatalf("%q: decode fail, err: %s", mode, err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%q: expected %#v, got %#v", mode, expected, got)
+		}
+		if got := d.elementType(node); got != reflect.TypeOf(expected) {
+			t.Errorf("%q: expected %T elements, got %v", mode, expected, got)
+		}
+	}
+	if !IsJSON(node) || IsJSON(parquet.String()) {
+		t.Errorf("expected only the JSON node to be reported as JSON")
+	}
+}
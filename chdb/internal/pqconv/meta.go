@@ -0,0 +1,208 @@
+// Package pqconv holds the Parquet schema and value helpers shared by the
+// chdb session helpers and the database/sql driver.
+package pqconv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ColumnMeta describes a single column of a query result.
+type ColumnMeta struct {
+	// Name of the column as returned by the query.
+	Name string
+	// ClickHouse type of the column, e.g. "Nullable(UInt32)".
+	Type string
+	// Parquet type of the column, e.g. "INT(32,false)".
+	ParquetType string
+	// Nullable reports whether the column may contain NULL values.
+	Nullable bool
+	// ScanType is the Go type values of the column are decoded into.
+	ScanType reflect.Type
+	// ElementNames holds the names of the elements of Tuple and Nested columns, nil for other columns.
+	ElementNames []string
+}
+
+// Columns builds the metadata of every field of a Parquet schema.
+func Columns(fields []parquet.Field) []ColumnMeta {
+	out := make([]ColumnMeta, len(fields))
+	for i, f := range fields {
+		out[i] = ColumnMeta{
+			Name:         f.Name(),
+			Type:         ClickHouseType(f),
+			ParquetType:  f.Type().String(),
+			Nullable:     f.Optional(),
+			ScanType:     ScanType(f),
+			ElementNames: ElementNames(f),
+		}
+	}
+	return out
+}
+
+// SetTypes sets the ClickHouse types of the columns found in types by name, e.g. as reported by DESCRIBE,
+// which tells apart the types Parquet doesn't record, like LowCardinality(String) or IPv4.
+func SetTypes(columns []ColumnMeta, types map[string]string) {
+	for i := range columns {
+		if t, ok := types[columns[i].Name]; ok {
+			columns[i].Type = t
+		}
+	}
+}
+
+// ClickHouseType maps a Parquet field back to the ClickHouse type that produced it.
+// Types which can't be mapped are reported with their Parquet name.
+func ClickHouseType(f parquet.Field) string {
+	return clickHouseType(f)
+}
+
+func clickHouseType(n parquet.Node) string {
+	name := baseClickHouseType(n)
+	if n.Optional() {
+		return "Nullable(" + name + ")"
+	}
+	return name
+}
+
+func baseClickHouseType(n parquet.Node) string {
+	if !n.Leaf() {
+		if isMap(n) {
+			key, value := mapKeyValue(n)
+			return "Map(" + clickHouseType(key) + ", " + clickHouseType(value) + ")"
+		}
+		if isList(n) {
+			return "Array(" + clickHouseType(listElement(n)) + ")"
+		}
+		fields := n.Fields()
+		elems := make([]string, len(fields))
+		for i, f := range fields {
+			elems[i] = clickHouseType(f)
+		}
+		return "Tuple(" + strings.Join(elems, ", ") + ")"
+	}
+	t := n.Type()
+	if lt := t.LogicalType(); lt != nil {
+		switch {
+		case lt.UTF8 != nil:
+			return "String"
+		case lt.Integer != nil:
+			if lt.Integer.IsSigned {
+				return fmt.Sprintf("Int%d", lt.Integer.BitWidth)
+			}
+			return fmt.Sprintf("UInt%d", lt.Integer.BitWidth)
+		case lt.Decimal != nil:
+			return fmt.Sprintf("Decimal(%d, %d)", lt.Decimal.Precision, lt.Decimal.Scale)
+		case lt.Date != nil:
+			return "Date32"
+		case lt.Timestamp != nil:
+			if p := TimestampPrecision(n, ""); p > 0 {
+				return fmt.Sprintf("DateTime64(%d)", p)
+			}
+		case lt.UUID != nil:
+			return "UUID"
+		case lt.Json != nil:
+			return "JSON"
+		case lt.Enum != nil:
+			return "Enum"
+		}
+	}
+	switch t.Kind() {
+	case parquet.Boolean:
+		return "Bool"
+	case parquet.Int32:
+		return "Int32"
+	case parquet.Int64:
+		return "Int64"
+	case parquet.Float:
+		return "Float32"
+	case parquet.Double:
+		return "Float64"
+	case parquet.ByteArray:
+		return "String"
+	case parquet.FixedLenByteArray:
+		return fmt.Sprintf("FixedString(%d)", t.Length())
+	}
+	return t.String()
+}
+
+// ElementNames returns the names of the fields of a group node holding a tuple, or of the tuples of a list of them.
+// It returns nil for other nodes.
+func ElementNames(n parquet.Node) []string {
+	for !n.Leaf() && !isMap(n) && isList(n) {
+		n = listElement(n)
+	}
+	if n.Leaf() || isMap(n) || isPoint(n) {
+		return nil
+	}
+	fields := n.Fields()
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+// ScanType returns the Go type values of the given Parquet node are decoded into by a zero Decoder.
+// Nullable columns are reported as pointers to their base type, e.g. *int64, matching their nullability.
+// Arrays of scalars are reported as typed slices, e.g. []bool or []*string for Array(Nullable(String)).
+func ScanType(n parquet.Node) reflect.Type {
+	return (&Decoder{}).ScanType(n)
+}
+
+// ScanType returns the Go type d decodes values of the given Parquet node into, like the ScanType function.
+// The leaves are typed like the values of Value, and nodes whose values vary in type are reported as any.
+func (d *Decoder) ScanType(n parquet.Node) reflect.Type {
+	if t := geoType(n); t != nil {
+		return t
+	}
+	if !n.Leaf() {
+		if isMap(n) {
+			return d.mapType(mapKeyValue(n))
+		}
+		if isList(n) {
+			return d.listType(n)
+		}
+		return reflect.TypeOf([]any(nil))
+	}
+	t := d.valueType(n.Type())
+	if t == nil {
+		return reflect.TypeOf((*any)(nil)).Elem()
+	}
+	if n.Optional() {
+		return reflect.PointerTo(t)
+	}
+	return t
+}
+
+// IsJSON reports whether n is a leaf annotated with the JSON logical type, whose scan type depends on Decoder.JSONAs.
+func IsJSON(n parquet.Node) bool {
+	return n.Leaf() && isJSON(n.Type())
+}
+
+// TimestampPrecision returns the number of fractional digits of the seconds of a timestamp node, i.e. the precision P
+// of the DateTime64(P) column it comes from. It is read from typ, the ClickHouse type of the column when known, e.g.
+// as reported by DESCRIBE: Parquet timestamps only record a unit, so that without it DateTime64(2) reports 3 and
+// DateTime64(4) reports 6. It returns 0 for other nodes.
+func TimestampPrecision(n parquet.Node, typ string) int {
+	if !n.Leaf() {
+		return 0
+	}
+	lt := n.Type().LogicalType()
+	if lt == nil || lt.Timestamp == nil {
+		return 0
+	}
+	if p, ok := DateTime64Precision(typ); ok {
+		return p
+	}
+	switch {
+	case lt.Timestamp.Unit.Millis != nil:
+		return 3
+	case lt.Timestamp.Unit.Micros != nil:
+		return 6
+	case lt.Timestamp.Unit.Nanos != nil:
+		return 9
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+package pqconv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// UnwrapLowCardinality returns the inner type of a LowCardinality(T) ClickHouse type name.
+// Other type names are returned unchanged.
+func UnwrapLowCardinality(typeName string) string {
+	if strings.HasPrefix(typeName, "LowCardinality(") && strings.HasSuffix(typeName, ")") {
+		return typeName[len("LowCardinality(") : len(typeName)-1]
+	}
+	return typeName
+}
+
+// DateTime64Precision returns the precision P of a DateTime64(P) or DateTime64(P, 'zone') ClickHouse type name,
+// possibly Nullable or LowCardinality. ok is false for other type names.
+func DateTime64Precision(typeName string) (precision int, ok bool) {
+	typeName = UnwrapLowCardinality(typeName)
+	if inner, found := strings.CutPrefix(typeName, "Nullable("); found {
+		typeName = strings.TrimSuffix(inner, ")")
+	}
+	args, found := strings.CutPrefix(typeName, "DateTime64(")
+	if !found {
+		return 0, false
+	}
+	p, _, _ := strings.Cut(strings.TrimSuffix(args, ")"), ",")
+	precision, err := strconv.Atoi(strings.TrimSpace(p))
+	if err != nil || precision < 0 || precision > 9 {
+		return 0, false
+	}
+	return precision, true
+}
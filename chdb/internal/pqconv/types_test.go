@@ -0,0 +1,40 @@
+package pqconv
+
+import "testing"
+
+func TestUnwrapLowCardinality(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"LowCardinality(String)", "String"},
+		{"LowCardinality(Nullable(String))", "Nullable(String)"},
+		{"String", "String"},
+		{"Nullable(String)", "Nullable(String)"},
+		{"INT(32,false)", "INT(32,false)"},
+	}
+	for _, tt := range tests {
+		if got := UnwrapLowCardinality(tt.in); got != tt.want {
+			t.Errorf("UnwrapLowCardinality(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDateTime64Precision(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"DateTime64(2)", 2, true},
+		{"DateTime64(4, 'Asia/Tokyo')", 4, true},
+		{"Nullable(DateTime64(9))", 9, true},
+		{"DateTime", 0, false},
+		{"DateTime64(x)", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := DateTime64Precision(tt.in); got != tt.want || ok != tt.ok {
+			t.Errorf("DateTime64Precision(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package sqlfmt
+
+import "strings"
+
+// QuotedEnd returns the index following the quoted string or identifier starting at start.
+// Quotes are escaped with a backslash or by doubling them.
+func QuotedEnd(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// CommentEnd returns the index following the comment starting at start, or start if there is none. Line comments
+// end after their newline.
+func CommentEnd(query string, start int) int {
+	switch {
+	case strings.HasPrefix(query[start:], "--"):
+		end := strings.IndexByte(query[start:], '\n')
+		if end < 0 {
+			return len(query)
+		}
+		return start + end + 1
+	case strings.HasPrefix(query[start:], "/*"):
+		end := strings.Index(query[start+2:], "*/")
+		if end < 0 {
+			return len(query)
+		}
+		return start + 2 + end + 2
+	}
+	return start
+}
+
+// SplitStatements splits a script into its semicolon-separated statements, ignoring the semicolons inside string
+// literals, quoted identifiers and comments. The statements are trimmed, and the ones only made of whitespace and
+// comments are dropped.
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		start      int
+		empty      = true // whether the current statement only holds whitespace and comments so far
+	)
+	for i := 0; i < len(script); {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = QuotedEnd(script, i)
+			empty = false
+		case CommentEnd(script, i) > i:
+			i = CommentEnd(script, i)
+		case c == ';':
+			if !empty {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			i++
+			start, empty = i, true
+		default:
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				empty = false
+			}
+			i++
+		}
+	}
+	if !empty {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+	return statements
+}
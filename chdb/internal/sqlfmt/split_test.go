@@ -0,0 +1,28 @@
+package sqlfmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		script   string
+		expected []string
+	}{
+		{"", nil},
+		{"SELECT 1", []string{"SELECT 1"}},
+		{" SELECT 1 ;\n\nSELECT 2;  ", []string{"SELECT 1", "SELECT 2"}},
+		{"SELECT 'a;b', `c;d`, \"e;f\"; SELECT 'it''s;', 'x\\';'", []string{"SELECT 'a;b', `c;d`, \"e;f\"", "SELECT 'it''s;', 'x\\';'"}},
+		{"-- create t;\nCREATE TABLE t (id UInt8) ENGINE = Memory; /* ; */ INSERT INTO t VALUES (1);",
+			[]string{"-- create t;\nCREATE TABLE t (id UInt8) ENGINE = Memory", "/* ; */ INSERT INTO t VALUES (1)"}},
+		{"SELECT 1;;\n-- trailing comment\n", []string{"SELECT 1"}},
+		{"SELECT 1 -- no newline", []string{"SELECT 1 -- no newline"}},
+		{"SELECT 'unterminated;", []string{"SELECT 'unterminated;"}},
+	}
+	for _, tc := range tests {
+		if got := SplitStatements(tc.script); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("SplitStatements(%q): expected %q, got %q", tc.script, tc.expected, got)
+		}
+	}
+}
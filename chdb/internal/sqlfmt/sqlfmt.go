@@ -0,0 +1,134 @@
+// Package sqlfmt renders identifiers and Go values as ClickHouse SQL, for the queries built
+// by the chdb session helpers and by the database/sql driver.
+package sqlfmt
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bytesType  = reflect.TypeOf([]byte(nil))
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// QuoteIdentifier quotes a ClickHouse identifier (database, table or column name) with backticks.
+// Dotted names such as "db.table" are quoted part by part.
+func QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = "`" + strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(p) + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+// QuoteString quotes a value as a ClickHouse string literal.
+func QuoteString(value string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(value) + "'"
+}
+
+// ValidateSettingName checks that a setting name only contains letters, digits and underscores, since setting names
+// are spliced into SETTINGS clauses and connection parameters.
+func ValidateSettingName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty setting name")
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return fmt.Errorf("invalid setting name %q", name)
+		}
+	}
+	return nil
+}
+
+// maxDateTime64Nanos is the last time a DateTime64(9) can hold, as its values count nanoseconds in an Int64.
+var maxDateTime64Nanos = time.Unix(0, math.MaxInt64).UTC()
+
+// Literal renders a Go value as a ClickHouse literal. time.Time values are rendered with nanosecond precision, or
+// microsecond precision after 2262, []byte values as raw strings, nil pointers and interfaces as NULL, slices as
+// arrays and maps as maps. driver.Valuer values, such as sql.NullString or uuid.UUID, are rendered as the value
+// they return.
+func Literal(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "NULL", nil
+	}
+	if v.Type().Implements(valuerType) && v.CanInterface() {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return "NULL", nil
+		}
+		value, err := v.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return "", err
+		}
+		return Literal(reflect.ValueOf(value))
+	}
+	switch v.Type() {
+	case timeType:
+		t := v.Interface().(time.Time).UTC()
+		if t.After(maxDateTime64Nanos) {
+			// DateTime64(9) can't hold times after 2262, fall back to microseconds which reach 2299
+			return fmt.Sprintf("toDateTime64(%s, 6, 'UTC')", QuoteString(t.Format("2006-01-02 15:04:05.000000"))), nil
+		}
+		return fmt.Sprintf("toDateTime64(%s, 9, 'UTC')", QuoteString(t.Format("2006-01-02 15:04:05.000000000"))), nil
+	case bytesType:
+		if v.IsNil() {
+			return "''", nil
+		}
+		return "unhex('" + hex.EncodeToString(v.Bytes()) + "')", nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return QuoteString(v.String()), nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "NULL", nil
+		}
+		return Literal(v.Elem())
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			lit, err := Literal(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			elems[i] = lit
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := Literal(iter.Key())
+			if err != nil {
+				return "", err
+			}
+			value, err := Literal(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+", "+value)
+		}
+		// keep the generated query stable
+		sort.Strings(pairs)
+		return "map(" + strings.Join(pairs, ", ") + ")", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -0,0 +1,70 @@
+package sqlfmt
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{int8(-3), "-3"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+		{float32(1.5), "1.5"},
+		{true, "true"},
+		{"a'b", "'a\\'b'"},
+		{(*int)(nil), "NULL"},
+		{[]byte("hi"), "unhex('6869')"},
+		{[]int{1, 2}, "[1, 2]"},
+		{map[string]int{"b": 2, "a": 1}, "map('a', 1, 'b', 2)"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), "toDateTime64('2024-01-02 03:04:05.000000006', 9, 'UTC')"},
+		{time.Date(2299, 12, 31, 1, 2, 3, 456789000, time.UTC), "toDateTime64('2299-12-31 01:02:03.456789', 6, 'UTC')"},
+		{[]any{1, nil, "x"}, "[1, NULL, 'x']"},
+		{nil, "NULL"},
+		{sql.NullString{String: "x", Valid: true}, "'x'"},
+		{sql.NullInt64{}, "NULL"},
+		{uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), "'6ba7b810-9dad-11d1-80b4-00c04fd430c8'"},
+		{[]uuid.UUID{uuid.Nil}, "['00000000-0000-0000-0000-000000000000']"},
+		{(*sql.NullString)(nil), "NULL"},
+	}
+	for _, tt := range tests {
+		got, err := Literal(reflect.ValueOf(tt.value))
+		if err != nil {
+			t.Fatalf("Literal(%v) fail, err: %s", tt.value, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Literal(%v) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+	if _, err := Literal(reflect.ValueOf(struct{}{})); err == nil {
+		t.Errorf("expected an error for an unsupported type")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := QuoteIdentifier("db.my`table"); got != "`db`.`my\\`table`" {
+		t.Errorf("unexpected identifier %s", got)
+	}
+	if got := QuoteString(`it's a \ test`); got != `'it\'s a \\ test'` {
+		t.Errorf("unexpected string %s", got)
+	}
+}
+
+func TestValidateSettingName(t *testing.T) {
+	for _, name := range []string{"max_threads", "max_memory_usage", "allow_experimental_JSON_type"} {
+		if err := ValidateSettingName(name); err != nil {
+			t.Errorf("expected %q to be accepted, got %s", name, err)
+		}
+	}
+	for _, name := range []string{"", "max_threads=1", "max threads", "a'b"} {
+		if err := ValidateSettingName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
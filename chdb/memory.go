@@ -0,0 +1,42 @@
+package chdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WithMaxServerMemoryUsage caps the memory used by the whole session to the given number of bytes,
+// through the max_server_memory_usage setting. Queries exceeding it fail with a MEMORY_LIMIT_EXCEEDED error.
+func WithMaxServerMemoryUsage(bytes int64) Option {
+	return func(s *Session) {
+		s.maxMemory = bytes
+	}
+}
+
+// peakMemoryMarker tags the queries of PeakMemoryUsage, so that they are excluded from the lookup.
+const peakMemoryMarker = "chdb-go:peak-memory"
+
+// PeakMemoryUsage returns the peak memory, in bytes, used by the last query run on the session,
+// as recorded in system.query_log. System statements are not taken into account.
+// It requires the query log to be enabled, which is the default.
+func (s *Session) PeakMemoryUsage() (int64, error) {
+	res, err := s.Query("SYSTEM FLUSH LOGS")
+	if err != nil {
+		return 0, err
+	}
+	res.Free()
+	query := fmt.Sprintf(`SELECT /* %s */ memory_usage FROM system.query_log
+		WHERE type = 'QueryFinish' AND query_kind != 'System' AND query NOT LIKE '%%%s%%'
+		ORDER BY event_time_microseconds DESC LIMIT 1`, peakMemoryMarker, peakMemoryMarker)
+	res, err = s.Query(query, "TSVRaw")
+	if err != nil {
+		return 0, err
+	}
+	defer res.Free()
+	out := strings.TrimSpace(res.String())
+	if out == "" {
+		return 0, fmt.Errorf("no query found in system.query_log")
+	}
+	return strconv.ParseInt(out, 10, 64)
+}
@@ -0,0 +1,43 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPeakMemoryUsage(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession("", WithMaxServerMemoryUsage(4<<30))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	if _, err := sess.Query("SELECT groupArray(number) FROM numbers(5000000) FORMAT Null"); err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	peak, err := sess.PeakMemoryUsage()
+	if err != nil {
+		t.Fatalf("PeakMemoryUsage fail, err: %s", err)
+	}
+	// 5M UInt64 take 40MB
+	if peak < 10<<20 {
+		t.Errorf("expected a peak memory usage of tens of MB, got %d", peak)
+	}
+
+	// the introspection queries are not reported
+	again, err := sess.PeakMemoryUsage()
+	if err != nil {
+		t.Fatalf("PeakMemoryUsage fail, err: %s", err)
+	}
+	if again != peak {
+		t.Errorf("expected the same peak on a second call, got %d then %d", peak, again)
+	}
+
+	_, err = sess.Query("SELECT groupArray(number) FROM numbers(5000000) FORMAT Null SETTINGS max_memory_usage = 1000000")
+	var qerr *QueryError
+	if !errors.As(err, &qerr) || qerr.Name != "MEMORY_LIMIT_EXCEEDED" {
+		t.Errorf("expected a MEMORY_LIMIT_EXCEEDED error, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package chdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// MergeFrom inserts the data of the given tables of the chDB database at otherPath into the tables
+// with the same names of the session. Tables must exist on both sides with the same columns, in the same order
+// and with the same types, otherwise an error is returned before anything is inserted.
+//
+// Since chDB only allows one connection at a time, the session connection is closed while the other database is
+// read, and reopened afterwards: running queries are waited for, and new ones wait for the connection to be reopened.
+// The data is staged in a temporary directory in Native format. Snapshots of the session are closed. If the
+// connection can't be reopened, the session is closed.
+func (s *Session) MergeFrom(otherPath string, tables []string) error {
+	if s.closed.Load() {
+		return ErrSessionClosed
+	}
+	if s.isReadOnly() {
+		return ErrReadOnlySession
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	schemas := make([]string, len(tables))
+	for i, table := range tables {
+		schema, err := s.queryString("DESCRIBE TABLE " + quoteIdentifier(table) + " SETTINGS describe_compact_output = 1")
+		if err != nil {
+			return fmt.Errorf("describe table %s: %w", table, err)
+		}
+		schemas[i] = schema
+	}
+
+	staging, err := os.MkdirTemp("", "chdb_merge_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := s.withConnClosed(func() error {
+		return exportTables(otherPath, tables, schemas, staging)
+	}); err != nil {
+		return err
+	}
+
+	for i, table := range tables {
+		query := fmt.Sprintf("INSERT INTO %s SELECT * FROM file(%s, 'Native')", quoteIdentifier(table), quoteString(stagingFile(staging, i)))
+		if err := s.run(query); err != nil {
+			return fmt.Errorf("merge table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func stagingFile(dir string, i int) string {
+	return filepath.Join(dir, strconv.Itoa(i)+".native")
+}
+
+// exportTables opens the database at path and writes the data of every table into the staging directory,
+// after checking that its schema matches the expected one.
+func exportTables(path string, tables, schemas []string, staging string) error {
+	other, err := initConnection(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer other.Close()
+
+	for i, table := range tables {
+		res, err := other.Query("DESCRIBE TABLE "+quoteIdentifier(table)+" SETTINGS describe_compact_output = 1", "TSVRaw")
+		if err != nil {
+			return fmt.Errorf("describe table %s of %s: %w", table, path, err)
+		}
+		schema := res.String()
+		res.Free()
+		if len(schema) > 0 && schema[len(schema)-1] == '\n' {
+			schema = schema[:len(schema)-1]
+		}
+		if schema != schemas[i] {
+			return fmt.Errorf("schema mismatch for table %s: %q in the session, %q in %s", table, schemas[i], schema, path)
+		}
+		if err := exportTable(other, table, stagingFile(staging, i)); err != nil {
+			return fmt.Errorf("export table %s of %s: %w", table, path, err)
+		}
+	}
+	return nil
+}
+
+func exportTable(conn chdbpurego.ChdbConn, table, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stream, err := conn.QueryStreaming("SELECT * FROM "+quoteIdentifier(table), "Native")
+	if err != nil {
+		return err
+	}
+	defer stream.Free()
+	for {
+		chunk := stream.GetNext()
+		if chunk == nil {
+			if err := stream.Error(); err != nil {
+				return err
+			}
+			break
+		}
+		done, err := handleChunk(chunk, func(buf []byte) error {
+			_, err := f.Write(buf)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+	return f.Close()
+}
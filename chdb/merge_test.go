@@ -0,0 +1,61 @@
+package chdb
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeFrom(t *testing.T) {
+	closeSharedSession()
+
+	otherPath := filepath.Join(t.TempDir(), "shard")
+	other, err := OpenSession(otherPath)
+	if err != nil {
+		t.Fatalf("open other session fail, err: %s", err)
+	}
+	for _, q := range []string{
+		"CREATE TABLE events (id UInt32, name String) ENGINE = MergeTree() ORDER BY id",
+		"INSERT INTO events VALUES (3, 'c'), (4, 'd')",
+		"CREATE TABLE mismatch (id UInt64) ENGINE = MergeTree() ORDER BY id",
+	} {
+		if _, err := other.Query(q); err != nil {
+			t.Fatalf("prepare other database fail, err: %s", err)
+		}
+	}
+	other.Close()
+
+	sess, err := OpenSession(filepath.Join(t.TempDir(), "main"))
+	if err != nil {
+		t.Fatalf("open session fail, err: %s", err)
+	}
+	defer sess.Close()
+	for _, q := range []string{
+		"CREATE TABLE events (id UInt32, name String) ENGINE = MergeTree() ORDER BY id",
+		"INSERT INTO events VALUES (1, 'a'), (2, 'b')",
+		"CREATE TABLE mismatch (id UInt32) ENGINE = MergeTree() ORDER BY id",
+	} {
+		if _, err := sess.Query(q); err != nil {
+			t.Fatalf("prepare database fail, err: %s", err)
+		}
+	}
+
+	if err := sess.MergeFrom(otherPath, []string{"events"}); err != nil {
+		t.Fatalf("MergeFrom fail, err: %s", err)
+	}
+	ret, err := sess.Query("SELECT id, name FROM events ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query after merge fail, err: %s", err)
+	}
+	if ret.String() != "1,\"a\"\n2,\"b\"\n3,\"c\"\n4,\"d\"\n" {
+		t.Errorf("unexpected merged rows: %s", ret.String())
+	}
+
+	err = sess.MergeFrom(otherPath, []string{"mismatch"})
+	if err == nil || !strings.Contains(err.Error(), "schema mismatch") {
+		t.Errorf("expected a schema mismatch error, got %v", err)
+	}
+	if _, err := sess.Query("SELECT count() FROM events"); err != nil {
+		t.Errorf("session should still work after a failed merge, err: %s", err)
+	}
+}
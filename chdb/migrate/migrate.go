@@ -0,0 +1,220 @@
+// Package migrate applies versioned schema migrations to a chdb session.
+//
+// Migrations are SQL scripts named <version>_<name>.up.sql and <version>_<name>.down.sql, e.g.
+// 0001_create_events.up.sql, read from the root of an fs.FS such as an embed.FS or os.DirFS. The up script of a
+// migration is required and its down script is optional. Scripts may hold several semicolon-separated statements.
+//
+// The applied migrations are recorded in the schema_migrations table of the current database of the session.
+// Statements are not transactional: when a script fails, its statements preceding the failure stay applied and the
+// migration is not recorded.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chdb-io/chdb-go/chdb"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+// Table is the name of the table recording the applied migrations.
+const Table = "schema_migrations"
+
+// Latest is the target version applying every migration.
+const Latest uint64 = math.MaxUint64
+
+// ErrNoDownMigration is returned when reverting a migration without a down script.
+var ErrNoDownMigration = errors.New("no down migration")
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string // empty if the migration can't be reverted
+}
+
+// Load reads the migrations of the root directory of fsys, sorted by version. Files not named like migrations are
+// ignored. Two migrations with the same version or a down script without an up one are errors.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[uint64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		version, name, direction, ok := parseFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		m, found := byVersion[version]
+		if !found {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %d", m.Name, name, version)
+		}
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if direction == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseFileName splits a migration file name into its version, name and direction, up or down.
+func parseFileName(fileName string) (version uint64, name, direction string, ok bool) {
+	base, ok := strings.CutSuffix(fileName, ".sql")
+	if !ok {
+		return 0, "", "", false
+	}
+	direction = strings.TrimPrefix(path.Ext(base), ".")
+	if direction != "up" && direction != "down" {
+		return 0, "", "", false
+	}
+	base = strings.TrimSuffix(base, path.Ext(base))
+	digits, name, _ := strings.Cut(base, "_")
+	version, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || version == 0 {
+		return 0, "", "", false
+	}
+	return version, name, direction, true
+}
+
+// Migrator applies migrations to a session.
+type Migrator struct {
+	sess *chdb.Session
+}
+
+// New returns a migrator of the current database of the session.
+func New(sess *chdb.Session) *Migrator {
+	return &Migrator{sess: sess}
+}
+
+// Migrate applies the up scripts of the migrations of fsys up to the target version included, and reverts the applied
+// migrations above it with their down scripts, from the newest one. Use Latest to apply every migration. Pending
+// migrations older than an applied one are applied too.
+func Migrate(sess *chdb.Session, fsys fs.FS, target uint64) error {
+	return New(sess).Migrate(fsys, target)
+}
+
+// Migrate applies the migrations of fsys up to target, see the Migrate function.
+func (m *Migrator) Migrate(fsys fs.FS, target uint64) error {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+	return m.Apply(migrations, target)
+}
+
+// Apply applies the given migrations up to target, like Migrate does with the migrations of a file system.
+func (m *Migrator) Apply(migrations []Migration, target uint64) error {
+	applied, err := m.Applied()
+	if err != nil {
+		return err
+	}
+	isApplied := make(map[uint64]bool, len(applied))
+	for _, version := range applied {
+		isApplied[version] = true
+	}
+	byVersion := make(map[uint64]Migration, len(migrations))
+	for _, mig := range migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	for i := len(applied) - 1; i >= 0 && applied[i] > target; i-- {
+		mig, ok := byVersion[applied[i]]
+		if !ok {
+			return fmt.Errorf("revert migration %d: not found", applied[i])
+		}
+		if mig.Down == "" {
+			return fmt.Errorf("revert migration %d_%s: %w", mig.Version, mig.Name, ErrNoDownMigration)
+		}
+		if err := m.run(mig, mig.Down, false); err != nil {
+			return err
+		}
+	}
+	for _, mig := range migrations {
+		if mig.Version > target || isApplied[mig.Version] {
+			continue
+		}
+		if err := m.run(mig, mig.Up, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Version returns the version of the newest applied migration, 0 if none was applied.
+func (m *Migrator) Version() (uint64, error) {
+	applied, err := m.Applied()
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1], nil
+}
+
+// Applied returns the versions of the applied migrations, in ascending order.
+func (m *Migrator) Applied() ([]uint64, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	// the table is a log of the applications and reverts of the migrations, the latest entry of a version wins
+	values, err := m.sess.QuerySingleColumn("SELECT version FROM " + Table +
+		" GROUP BY version HAVING argMax(applied, seq) ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]uint64, len(values))
+	for i, v := range values {
+		versions[i] = v.(uint64)
+	}
+	return versions, nil
+}
+
+// init creates the migrations table if it doesn't exist.
+func (m *Migrator) init() error {
+	_, err := m.sess.Exec("CREATE TABLE IF NOT EXISTS " + Table +
+		" (seq UInt64, version UInt64, name String, applied Bool, at DateTime64(3) DEFAULT now64(3))" +
+		" ENGINE = MergeTree ORDER BY seq")
+	return err
+}
+
+// run runs the script of a migration and records that it was applied, or reverted.
+func (m *Migrator) run(mig Migration, script string, up bool) error {
+	action := "apply"
+	if !up {
+		action = "revert"
+	}
+	if _, err := m.sess.ExecScript(script); err != nil {
+		return fmt.Errorf("%s migration %d_%s: %w", action, mig.Version, mig.Name, err)
+	}
+	_, err := m.sess.Exec(fmt.Sprintf("INSERT INTO %s (seq, version, name, applied) SELECT"+
+		" (SELECT count() FROM %s) + 1, %d, %s, %t", Table, Table, mig.Version, sqlfmt.QuoteString(mig.Name), up))
+	if err != nil {
+		return fmt.Errorf("record migration %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
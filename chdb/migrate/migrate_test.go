@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/chdb-io/chdb-go/chdb"
+)
+
+var migrations = fstest.MapFS{
+	"0001_create_events.up.sql": {Data: []byte(`
+		CREATE TABLE events (id UInt64, name String) ENGINE = MergeTree ORDER BY id;
+		INSERT INTO events VALUES (1, 'a;b');`)},
+	"0001_create_events.down.sql": {Data: []byte("DROP TABLE events")},
+	"0002_add_ts.up.sql":          {Data: []byte("ALTER TABLE events ADD COLUMN ts DateTime DEFAULT now()")},
+	"0002_add_ts.down.sql":        {Data: []byte("ALTER TABLE events DROP COLUMN ts")},
+	"0003_create_users.up.sql":    {Data: []byte("CREATE TABLE users (id UInt64) ENGINE = Memory")},
+	"README.md":                   {Data: []byte("not a migration")},
+	"0004_notes.txt":              {Data: []byte("not a migration either")},
+}
+
+func TestLoad(t *testing.T) {
+	loaded, err := Load(migrations)
+	if err != nil {
+		t.Fatalf("Load fail, err: %s", err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("expected 3 migrations, got %d", len(loaded))
+	}
+	for i, expected := range []struct {
+		version uint64
+		name    string
+		down    bool
+	}{{1, "create_events", true}, {2, "add_ts", true}, {3, "create_users", false}} {
+		m := loaded[i]
+		if m.Version != expected.version || m.Name != expected.name || (m.Down != "") != expected.down || m.Up == "" {
+			t.Errorf("migration %d: unexpected %+v", i, m)
+		}
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"duplicate version": {"1_a.up.sql": {Data: []byte("SELECT 1")}, "1_b.up.sql": {Data: []byte("SELECT 1")}},
+		"missing up":        {"1_a.down.sql": {Data: []byte("SELECT 1")}},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	sess, err := chdb.NewSession()
+	if err != nil {
+		t.Fatalf("new session fail, err: %s", err)
+	}
+	defer sess.Cleanup()
+
+	m := New(sess)
+	if err := m.Migrate(migrations, 2); err != nil {
+		t.Fatalf("Migrate fail, err: %s", err)
+	}
+	if version, err := m.Version(); err != nil || version != 2 {
+		t.Errorf("expected version 2, got %d, err: %v", version, err)
+	}
+	var count int
+	if err := sess.QueryScalar("SELECT count() FROM events WHERE name = 'a;b' AND ts > 0", &count); err != nil || count != 1 {
+		t.Errorf("expected the migrations to be applied, got %d rows, err: %v", count, err)
+	}
+
+	// migrating again is a no-op
+	if err := Migrate(sess, migrations, Latest); err != nil {
+		t.Fatalf("Migrate fail, err: %s", err)
+	}
+	if applied, err := m.Applied(); err != nil || len(applied) != 3 {
+		t.Errorf("expected 3 applied migrations, got %v, err: %v", applied, err)
+	}
+
+	// the last migration has no down script
+	if err := m.Migrate(migrations, 1); !errors.Is(err, ErrNoDownMigration) {
+		t.Fatalf("expected ErrNoDownMigration, got %v", err)
+	}
+	if _, err := sess.Exec("DROP TABLE users"); err != nil {
+		t.Fatal(err)
+	}
+	withDown := fstest.MapFS{"0003_create_users.down.sql": {Data: []byte("SELECT 1")}}
+	for name, file := range migrations {
+		withDown[name] = file
+	}
+	if err := m.Migrate(withDown, 1); err != nil {
+		t.Fatalf("Migrate down fail, err: %s", err)
+	}
+	if version, err := m.Version(); err != nil || version != 1 {
+		t.Errorf("expected version 1, got %d, err: %v", version, err)
+	}
+	if err := sess.QueryScalar("SELECT count() FROM system.columns WHERE table = 'events' AND name = 'ts'", &count); err != nil || count != 0 {
+		t.Errorf("expected the ts column to be dropped, got %d, err: %v", count, err)
+	}
+
+	// a failing migration is not recorded
+	broken := fstest.MapFS{"0002_broken.up.sql": {Data: []byte("ALTER TABLE missing ADD COLUMN x UInt8")}}
+	if err := m.Migrate(broken, Latest); err == nil {
+		t.Errorf("expected an error for a failing migration")
+	}
+	if version, err := m.Version(); err != nil || version != 1 {
+		t.Errorf("expected version 1, got %d, err: %v", version, err)
+	}
+}
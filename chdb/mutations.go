@@ -0,0 +1,47 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMutationsTimeout is returned by WaitForMutations when mutations are still pending after the timeout.
+var ErrMutationsTimeout = errors.New("timeout waiting for mutations")
+
+// WaitForMutations polls system.mutations until the pending mutations of table, e.g. issued by ALTER TABLE ... DELETE,
+// are done. The table may be qualified with its database, it defaults to the current one.
+// It returns an error if a mutation failed, or ErrMutationsTimeout once timeout is elapsed.
+func (s *Session) WaitForMutations(table string, timeout time.Duration) error {
+	database := "currentDatabase()"
+	if db, name, ok := strings.Cut(table, "."); ok {
+		database, table = quoteString(db), name
+	}
+	query := fmt.Sprintf("SELECT count(), anyIf(latest_fail_reason, latest_fail_reason != '') FROM system.mutations WHERE database = %s AND table = %s AND NOT is_done",
+		database, quoteString(table))
+
+	deadline := time.Now().Add(timeout)
+	interval := 10 * time.Millisecond
+	for {
+		res, err := s.Query(query, "TSVRaw")
+		if err != nil {
+			return err
+		}
+		pending, reason, _ := strings.Cut(strings.TrimSuffix(res.String(), "\n"), "\t")
+		res.Free()
+		if reason != "" {
+			return fmt.Errorf("mutation of %s failed: %s", table, reason)
+		}
+		if pending == "0" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s mutations pending on %s", ErrMutationsTimeout, pending, table)
+		}
+		time.Sleep(interval)
+		if interval < 200*time.Millisecond {
+			interval *= 2
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package chdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitForMutations(t *testing.T) {
+	sess := testSession(t)
+	sess.Query("DROP TABLE IF EXISTS TestWaitForMutations")
+	if _, err := sess.Query("CREATE TABLE TestWaitForMutations (id UInt32) ENGINE = MergeTree() ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("INSERT INTO TestWaitForMutations SELECT number FROM numbers(100)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("ALTER TABLE TestWaitForMutations DELETE WHERE id < 50"); err != nil {
+		t.Fatalf("alter delete fail, err: %s", err)
+	}
+	if err := sess.WaitForMutations("TestWaitForMutations", 10*time.Second); err != nil {
+		t.Fatalf("WaitForMutations fail, err: %s", err)
+	}
+	ret, err := sess.Query("SELECT count(), min(id) FROM TestWaitForMutations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.String() != "50,50\n" {
+		t.Errorf("expected the deleted rows to be gone, got %s", ret.String())
+	}
+
+	if err := sess.WaitForMutations("TestWaitForMutationsMissing", time.Second); err != nil {
+		t.Errorf("expected no pending mutations for an unknown table, got %s", err)
+	}
+}
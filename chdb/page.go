@@ -0,0 +1,33 @@
+package chdb
+
+import (
+	"fmt"
+)
+
+// QueryPage returns the rows of the given page of the query result, pages being numbered from 0,
+// along with the total number of rows of the result.
+// The query is wrapped in a subquery, so queries with their own ORDER BY or LIMIT clause are paged as they are.
+// A page past the end of the result is empty.
+func (s *Session) QueryPage(queryStr string, page, pageSize int) ([][]any, int64, error) {
+	if page < 0 || pageSize <= 0 {
+		return nil, 0, fmt.Errorf("invalid page %d of size %d", page, pageSize)
+	}
+	queryStr = trimQuery(queryStr)
+	total, err := s.CountRows(queryStr)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := [][]any{}
+	if int64(page)*int64(pageSize) >= total {
+		return rows, total, nil
+	}
+	pageQuery := fmt.Sprintf("SELECT * FROM (\n%s\n) LIMIT %d OFFSET %d", queryStr, pageSize, int64(page)*int64(pageSize))
+	err = s.forEachRow(pageQuery, nil, func(row []any) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
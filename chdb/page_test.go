@@ -0,0 +1,43 @@
+package chdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryPage(t *testing.T) {
+	sess := testSession(t)
+	query := "SELECT number AS id, toString(number) AS name FROM numbers(25) ORDER BY id;"
+	tests := []struct {
+		page     int
+		expected [][]any
+	}{
+		{0, [][]any{{uint64(0), "0"}, {uint64(1), "1"}, {uint64(2), "2"}, {uint64(3), "3"}, {uint64(4), "4"}, {uint64(5), "5"}, {uint64(6), "6"}, {uint64(7), "7"}, {uint64(8), "8"}, {uint64(9), "9"}}},
+		{2, [][]any{{uint64(20), "20"}, {uint64(21), "21"}, {uint64(22), "22"}, {uint64(23), "23"}, {uint64(24), "24"}}},
+		{3, [][]any{}},
+	}
+	for _, tt := range tests {
+		rows, total, err := sess.QueryPage(query, tt.page, 10)
+		if err != nil {
+			t.Fatalf("QueryPage(%d) fail, err: %s", tt.page, err)
+		}
+		if total != 25 {
+			t.Errorf("page %d: expected a total of 25, got %d", tt.page, total)
+		}
+		if !reflect.DeepEqual(rows, tt.expected) {
+			t.Errorf("page %d: expected %v, got %v", tt.page, tt.expected, rows)
+		}
+	}
+
+	rows, total, err := sess.QueryPage("SELECT number FROM numbers(100) ORDER BY number LIMIT 15", 1, 10)
+	if err != nil {
+		t.Fatalf("QueryPage with a LIMIT fail, err: %s", err)
+	}
+	if total != 15 || len(rows) != 5 || rows[0][0] != uint64(10) {
+		t.Errorf("expected the 5 last rows of 15, got %d rows of %d: %v", len(rows), total, rows)
+	}
+
+	if _, _, err := sess.QueryPage(query, -1, 10); err == nil {
+		t.Errorf("expected an error for a negative page")
+	}
+}
@@ -0,0 +1,100 @@
+package chdb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+	"github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+)
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]map[string]string{}
+)
+
+// RegisterProfile registers a named bundle of query settings, e.g. "bulk_load" or "interactive",
+// to be applied with Session.QueryWithProfile. Registering an existing name replaces its settings.
+// It fails, leaving the registered profiles unchanged, when a setting name is invalid.
+func RegisterProfile(name string, settings map[string]string) error {
+	copied := make(map[string]string, len(settings))
+	for k, v := range settings {
+		if err := sqlfmt.ValidateSettingName(k); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		copied[k] = v
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[name] = copied
+	return nil
+}
+
+// settingsClause returns the SETTINGS clause of the given profile, with the settings sorted by name.
+func settingsClause(profile string) (string, error) {
+	profilesMu.RLock()
+	settings, ok := profiles[profile]
+	profilesMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown settings profile %q", profile)
+	}
+	return formatSettings(settings)
+}
+
+// formatSettings renders settings as a SETTINGS clause, sorted by name. It returns an empty string for no settings,
+// and fails when a setting name is invalid.
+func formatSettings(settings map[string]string) (string, error) {
+	if len(settings) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		if err := sqlfmt.ValidateSettingName(name); err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + " = " + settingValue(settings[name])
+	}
+	return " SETTINGS " + strings.Join(pairs, ", "), nil
+}
+
+// settingValue renders a setting value as a literal: plain integers and decimals, e.g. 3 or -1.5, and booleans are
+// left as is, anything else is quoted, including what ParseFloat would also take for a number, such as Inf or 0x1p-2.
+func settingValue(value string) string {
+	if plainNumber.MatchString(value) || value == "true" || value == "false" {
+		return value
+	}
+	return quoteString(value)
+}
+
+var plainNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// QueryWithProfile runs the query like Query, with the settings of the given registered profile appended
+// as a SETTINGS clause. The query must not already end with a SETTINGS or FORMAT clause.
+func (s *Session) QueryWithProfile(queryStr, profile string, outputFormats ...string) (result chdbpurego.ChdbResult, err error) {
+	clause, err := settingsClause(profile)
+	if err != nil {
+		return nil, err
+	}
+	queryStr = strings.TrimRight(strings.TrimSpace(queryStr), ";")
+	return s.Query(queryStr+clause, outputFormats...)
+}
+
+// QueryWithSettings runs the query like Query in the given format, with the given settings appended as a SETTINGS
+// clause, e.g. a higher max_memory_usage for a single heavy query. The settings of the session are left unchanged.
+// An empty format falls back to the session default one. The query must not already end with a SETTINGS or
+// FORMAT clause.
+func (s *Session) QueryWithSettings(queryStr string, settings map[string]string, format string) (chdbpurego.ChdbResult, error) {
+	clause, err := formatSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(trimQuery(queryStr)+clause, format)
+}
@@ -0,0 +1,95 @@
+package chdb
+
+import (
+	"testing"
+)
+
+func TestQueryWithProfile(t *testing.T) {
+	RegisterProfile("TestQueryWithProfile", map[string]string{
+		"max_threads":            "3",
+		"date_time_input_format": "best_effort",
+		"optimize_read_in_order": "false",
+	})
+	sess := testSession(t)
+
+	ret, err := sess.QueryWithProfile("SELECT getSetting('max_threads'), getSetting('date_time_input_format'), getSetting('optimize_read_in_order');", "TestQueryWithProfile")
+	if err != nil {
+		t.Fatalf("QueryWithProfile fail, err: %s", err)
+	}
+	if ret.String() != "3,\"best_effort\",false\n" {
+		t.Errorf("profile settings not applied, got: %s", ret.String())
+	}
+
+	if _, err := sess.QueryWithProfile("SELECT 1", "TestQueryWithProfileMissing"); err == nil {
+		t.Errorf("expected an error for an unknown profile")
+	}
+}
+
+func TestSettingsClause(t *testing.T) {
+	RegisterProfile("TestSettingsClause", map[string]string{"b": "x'y", "a": "1.5"})
+	clause, err := settingsClause("TestSettingsClause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clause != " SETTINGS a = 1.5, b = 'x\\'y'" {
+		t.Errorf("unexpected clause: %s", clause)
+	}
+
+	if err := RegisterProfile("TestSettingsClauseInvalid", map[string]string{"max_threads = 1, readonly": "1"}); err == nil {
+		t.Errorf("expected an error for an invalid setting name")
+	}
+	if _, err := settingsClause("TestSettingsClauseInvalid"); err == nil {
+		t.Errorf("expected the invalid profile not to be registered")
+	}
+}
+
+func TestSettingValue(t *testing.T) {
+	for value, expected := range map[string]string{
+		"3":      "3",
+		"-1.5":   "-1.5",
+		"true":   "true",
+		"2G":     "'2G'",
+		"Inf":    "'Inf'",
+		"NaN":    "'NaN'",
+		"0x1p-2": "'0x1p-2'",
+		"1e3":    "'1e3'",
+		"1_000":  "'1_000'",
+		"+1":     "'+1'",
+		"1.":     "'1.'",
+		"x'y":    "'x\\'y'",
+	} {
+		if got := settingValue(value); got != expected {
+			t.Errorf("settingValue(%q): expected %s, got %s", value, expected, got)
+		}
+	}
+}
+
+func TestQueryWithSettings(t *testing.T) {
+	sess := testSession(t)
+
+	ret, err := sess.QueryWithSettings("SELECT getSetting('max_threads'), getSetting('max_memory_usage');", map[string]string{
+		"max_threads":      "3",
+		"max_memory_usage": "2G",
+	}, "CSV")
+	if err != nil {
+		t.Fatalf("QueryWithSettings fail, err: %s", err)
+	}
+	if ret.String() != "3,2000000000\n" {
+		t.Errorf("settings not applied, got: %s", ret.String())
+	}
+
+	// the settings only apply to that query
+	ret, err = sess.Query("SELECT getSetting('max_threads') = 3", "CSV")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "false\n" {
+		t.Errorf("expected the session settings to be left unchanged, got %s", ret.String())
+	}
+
+	for _, name := range []string{"", "max_threads = 1, max_memory_usage", "max-threads"} {
+		if _, err := sess.QueryWithSettings("SELECT 1", map[string]string{name: "1"}, "CSV"); err == nil {
+			t.Errorf("expected an error for the setting %q", name)
+		}
+	}
+}
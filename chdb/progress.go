@@ -0,0 +1,46 @@
+package chdb
+
+import "time"
+
+// Progress reports how far a query run with QueryContext got. The chDB library doesn't report the progress of a
+// running query, so it is gathered from the chunks of the result as they are output.
+type Progress struct {
+	// RowsRead and BytesRead are the amounts of data read by the query for the chunks output so far.
+	RowsRead  uint64
+	BytesRead uint64
+	// TotalRows is the estimated number of rows the query reads, from EXPLAIN ESTIMATE, or 0 when unknown,
+	// e.g. for queries not reading MergeTree tables. RowsRead may exceed it.
+	TotalRows uint64
+	// Elapsed is the time since the query started.
+	Elapsed time.Duration
+}
+
+// QueryOption configures a query run with QueryContext.
+type QueryOption func(*queryConfig)
+
+// queryConfig holds the options of a query.
+type queryConfig struct {
+	progress func(Progress)
+}
+
+// WithProgress makes QueryContext call fn with the progress of the query after every chunk of its result, from the
+// goroutine running the query. fn should return quickly, since the next chunk is only fetched afterwards.
+// Estimating the total rows runs an extra EXPLAIN ESTIMATE before the query.
+//
+// fn is only called when the query outputs a chunk: queries which output their rows as they read them, e.g. scans
+// and filters, report their progress as they run, while aggregations and sorts, which only output their result
+// once every row is read, report it once at the end. It doesn't suit progress bars of such queries.
+func WithProgress(fn func(Progress)) QueryOption {
+	return func(c *queryConfig) {
+		c.progress = fn
+	}
+}
+
+// estimateTotalRows returns the estimated number of rows read by the query, 0 when it can't be estimated.
+func (s *Session) estimateTotalRows(queryStr string) uint64 {
+	estimate, err := s.EstimateCost(queryStr)
+	if err != nil || estimate.Rows < 0 {
+		return 0
+	}
+	return uint64(estimate.Rows)
+}
@@ -0,0 +1,45 @@
+package chdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryContextWithProgress(t *testing.T) {
+	sess := testSession(t)
+
+	if _, err := sess.Exec("CREATE TABLE TestProgress (id UInt64) ENGINE = MergeTree ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	defer sess.DropTable("TestProgress", true)
+	if _, err := sess.Exec("INSERT INTO TestProgress SELECT number FROM numbers(200000)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var updates []Progress
+	ret, err := sess.QueryContext(context.Background(), "SELECT id FROM TestProgress SETTINGS max_block_size = 10000", "CSV",
+		WithProgress(func(p Progress) { updates = append(updates, p) }))
+	if err != nil {
+		t.Fatalf("QueryContext fail, err: %s", err)
+	}
+	if len(updates) == 0 {
+		t.Fatalf("expected progress updates")
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].RowsRead < updates[i-1].RowsRead || updates[i].Elapsed < updates[i-1].Elapsed {
+			t.Errorf("expected the progress to grow, got %+v after %+v", updates[i], updates[i-1])
+		}
+	}
+	last := updates[len(updates)-1]
+	if last.RowsRead != ret.RowsRead() || last.TotalRows != 200000 {
+		t.Errorf("expected %d rows read out of 200000, got %+v", ret.RowsRead(), last)
+	}
+
+	updates = nil
+	if _, err := sess.QueryContext(context.Background(), "SELECT 1", "CSV", WithProgress(func(p Progress) { updates = append(updates, p) })); err != nil {
+		t.Fatalf("QueryContext fail, err: %s", err)
+	}
+	if len(updates) == 0 || updates[0].TotalRows != 0 {
+		t.Errorf("expected an unknown total for a query not reading tables, got %+v", updates)
+	}
+}
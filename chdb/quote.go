@@ -0,0 +1,14 @@
+package chdb
+
+import "github.com/chdb-io/chdb-go/chdb/internal/sqlfmt"
+
+// quoteIdentifier quotes a ClickHouse identifier (database, table or column name) with backticks.
+// Dotted names such as "db.table" are quoted part by part.
+func quoteIdentifier(name string) string {
+	return sqlfmt.QuoteIdentifier(name)
+}
+
+// quoteString quotes a value as a ClickHouse string literal.
+func quoteString(value string) string {
+	return sqlfmt.QuoteString(value)
+}
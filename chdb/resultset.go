@@ -0,0 +1,141 @@
+package chdb
+
+import (
+	"encoding/gob"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"time"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+// ResultSet is a materialized query result which can be encoded with encoding/gob, e.g. to cache results
+// across processes. The concrete types of its values are registered with gob.
+type ResultSet struct {
+	// Columns of the result, with their ClickHouse types.
+	Columns []ColumnInfo
+	// Rows holds the values of every row, decoded like QuerySingleColumn does, with NULL as nil.
+	// Elements of Nullable arrays and Nullable map values are stored as nil or as their value, in a []any or a
+	// map[K]any.
+	Rows [][]any
+}
+
+// gobScalars are the values of the leaves decoded into a ResultSet. They are registered with gob along with the
+// slices and the maps holding them.
+var gobScalars = []any{
+	false, int8(0), int16(0), int32(0), int64(0), uint8(0), uint16(0), uint32(0), uint64(0),
+	float32(0), float64(0), "", []byte(nil), time.Time{}, uuid.UUID{}, netip.Addr{}, new(big.Int), [2]float64{},
+}
+
+// gobMapKeys are the keys of the maps decoded into a ResultSet.
+var gobMapKeys = []any{
+	false, int8(0), int16(0), int32(0), int64(0), uint8(0), uint16(0), uint32(0), uint64(0),
+	"", time.Time{}, uuid.UUID{},
+}
+
+func init() {
+	values := []reflect.Type{anyType, reflect.TypeOf([]any(nil))}
+	for _, v := range gobScalars {
+		t := reflect.TypeOf(v)
+		values = append(values, t, reflect.SliceOf(t))
+	}
+	for _, t := range values {
+		if t != anyType {
+			gob.Register(reflect.Zero(t).Interface())
+		}
+	}
+	for _, v := range []any{[][2]float64(nil), [][][2]float64(nil), [][][][2]float64(nil)} {
+		gob.Register(v)
+	}
+	for _, k := range gobMapKeys {
+		for _, t := range values {
+			gob.Register(reflect.Zero(reflect.MapOf(reflect.TypeOf(k), t)).Interface())
+		}
+	}
+}
+
+// QueryResultSet runs the query and returns its whole result as a ResultSet.
+func (s *Session) QueryResultSet(queryStr string) (*ResultSet, error) {
+	rs := &ResultSet{Rows: [][]any{}}
+	err := s.forEachRow(queryStr, func(fields []parquet.Field) error {
+		if rs.Columns == nil {
+			columns := make([]ColumnInfo, len(fields))
+			for i, c := range pqconv.Columns(fields) {
+				columns[i] = ColumnInfo{Name: c.Name, Type: c.Type}
+			}
+			rs.Columns = columns
+		}
+		return nil
+	}, func(row []any) error {
+		for i, v := range row {
+			row[i] = gobValue(v)
+		}
+		rs.Rows = append(rs.Rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// gobValue converts the slices and maps of pointers decoded for Nullable arrays and map values, which gob can't encode
+// when they hold nil elements, into []any and map[K]any.
+func gobValue(v any) any {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Slice && hasNilElements(rv.Type()):
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = gobElement(rv.Index(i))
+		}
+		return out
+	case rv.Kind() == reflect.Map && hasNilElements(rv.Type()):
+		out := reflect.MakeMapWithSize(reflect.MapOf(rv.Type().Key(), anyType), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			elem := reflect.New(anyType).Elem()
+			if v := gobElement(iter.Value()); v != nil {
+				elem.Set(reflect.ValueOf(v))
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		return out.Interface()
+	}
+	return v
+}
+
+var (
+	anyType        = reflect.TypeOf((*any)(nil)).Elem()
+	gobEncoderType = reflect.TypeOf((*gob.GobEncoder)(nil)).Elem()
+)
+
+// hasNilElements reports whether the elements of a slice or map type, or of the slices and maps it holds, may be
+// nil pointers or interfaces. Pointers encoding themselves, such as *big.Int, are kept as is.
+func hasNilElements(t reflect.Type) bool {
+	elem := t.Elem()
+	switch elem.Kind() {
+	case reflect.Pointer:
+		return !elem.Implements(gobEncoderType)
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Map:
+		return hasNilElements(elem)
+	}
+	return false
+}
+
+// gobElement returns an element of a slice or map converted by gobValue: nil for a nil pointer or interface,
+// the value it points to otherwise.
+func gobElement(elem reflect.Value) any {
+	if elem.Kind() == reflect.Interface || elem.Kind() == reflect.Pointer && !elem.Type().Implements(gobEncoderType) {
+		if elem.IsNil() {
+			return nil
+		}
+		elem = elem.Elem()
+	}
+	return gobValue(elem.Interface())
+}
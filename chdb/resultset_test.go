@@ -0,0 +1,113 @@
+package chdb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestQueryResultSetGob(t *testing.T) {
+	sess := testSession(t)
+
+	rs, err := sess.QueryResultSet(`SELECT number AS id, toString(number) AS name, toFloat64(number) / 2 AS half,
+		if(number = 1, NULL, toInt32(number)) AS maybe, number % 2 = 0 AS even,
+		toDateTime64('2024-01-02 03:04:05.678', 3, 'UTC') AS ts, [toString(number), 'x'] AS tags,
+		[NULL, toInt64(number)]::Array(Nullable(Int64)) AS sparse, (1.5, 2.5)::Point AS pt
+		FROM numbers(2)`)
+	if err != nil {
+		t.Fatalf("QueryResultSet fail, err: %s", err)
+	}
+	if len(rs.Columns) != 9 || rs.Columns[0] != (ColumnInfo{Name: "id", Type: "UInt64"}) || rs.Columns[3].Type != "Nullable(Int32)" {
+		t.Errorf("unexpected columns %+v", rs.Columns)
+	}
+	if len(rs.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rs.Rows))
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rs); err != nil {
+		t.Fatalf("gob encode fail, err: %s", err)
+	}
+	var decoded ResultSet
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("gob decode fail, err: %s", err)
+	}
+	if !reflect.DeepEqual(decoded.Columns, rs.Columns) {
+		t.Errorf("expected columns %+v, got %+v", rs.Columns, decoded.Columns)
+	}
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	expected := [][]any{
+		{uint64(0), "0", 0.0, int32(0), true, ts, []string{"0", "x"}, []any{nil, int64(0)}, [2]float64{1.5, 2.5}},
+		{uint64(1), "1", 0.5, nil, false, ts, []string{"1", "x"}, []any{nil, int64(1)}, [2]float64{1.5, 2.5}},
+	}
+	for i, row := range decoded.Rows {
+		for j, v := range row {
+			if tv, ok := v.(time.Time); ok && tv.Equal(ts) {
+				continue
+			}
+			if !reflect.DeepEqual(v, expected[i][j]) {
+				t.Errorf("row %d column %s: expected %#v, got %#v", i, decoded.Columns[j].Name, expected[i][j], v)
+			}
+		}
+	}
+}
+
+func TestGobValue(t *testing.T) {
+	one := int64(1)
+	if got := gobValue([]*int64{nil, &one}); !reflect.DeepEqual(got, []any{nil, int64(1)}) {
+		t.Errorf("expected [<nil> 1], got %#v", got)
+	}
+	if got := gobValue([]any{[]*int64{&one}, "a"}); !reflect.DeepEqual(got, []any{[]any{int64(1)}, "a"}) {
+		t.Errorf("expected [[1] a], got %#v", got)
+	}
+	if got := gobValue([]string{"a"}); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("expected typed slices to be kept, got %#v", got)
+	}
+}
+
+func TestResultSetGobTypes(t *testing.T) {
+	name := "a"
+	one := int64(1)
+	id := uuid.MustParse("5f0e2b9c-4f4a-4c1e-9d0a-6a3f1c2b7e10")
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, v := range []any{
+		id,
+		netip.MustParseAddr("192.168.1.1"),
+		netip.MustParseAddr("2001:db8::1"),
+		big.NewInt(-170141183460469231),
+		[]uuid.UUID{id},
+		[]netip.Addr{netip.MustParseAddr("10.0.0.1")},
+		[]*big.Int{big.NewInt(7)},
+		map[string]uint8{"a": 1},
+		map[uint64]string{1: "a"},
+		map[string][]string{"a": {"b"}},
+		map[uuid.UUID]int64{id: 1},
+		map[time.Time]float64{ts: 1.5},
+		map[int8]*big.Int{1: big.NewInt(2)},
+		map[uint64]*string{1: &name, 2: nil},
+		map[string][]*int64{"a": {nil, &one}},
+		map[string]any{"a": []any{int64(1), "b"}},
+		[]any{map[string]int32{"a": 1}},
+	} {
+		rs := &ResultSet{Columns: []ColumnInfo{{Name: "v"}}, Rows: [][]any{{gobValue(v)}}}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(rs); err != nil {
+			t.Errorf("%T: gob encode fail, err: %s", v, err)
+			continue
+		}
+		var decoded ResultSet
+		if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+			t.Errorf("%T: gob decode fail, err: %s", v, err)
+			continue
+		}
+		if got := decoded.Rows[0][0]; !reflect.DeepEqual(got, rs.Rows[0][0]) {
+			t.Errorf("%T: expected %#v, got %#v", v, rs.Rows[0][0], got)
+		}
+	}
+}
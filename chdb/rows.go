@@ -0,0 +1,67 @@
+package chdb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// forEachRow streams the query result in Parquet format and calls fn with the decoded values of every row.
+// The row slice is not reused and can be retained. If onSchema is not nil, it is called with the schema fields
+// of every chunk before its rows.
+func (s *Session) forEachRow(queryStr string, onSchema func(fields []parquet.Field) error, fn func(row []any) error) error {
+	// decimals are kept as their exact text, which the rows of the session have always held
+	decoder := pqconv.Decoder{DecimalStrings: true}
+	return s.forEachChunk(queryStr, "Parquet", func(buf []byte) error {
+		if err := pqconv.CheckChunk(buf); err != nil {
+			return err
+		}
+		reader := parquet.NewGenericReader[any](bytes.NewReader(buf))
+		defer reader.Close()
+		fields := reader.Schema().Fields()
+		if onSchema != nil {
+			if err := onSchema(fields); err != nil {
+				return err
+			}
+		}
+		rows := make([]parquet.Row, 128)
+		for {
+			n, err := reader.ReadRows(rows)
+			for _, r := range rows[:n] {
+				values := make([]any, len(fields))
+				if err := decoder.Row(fields, r, func(index int, v any) {
+					values[index] = v
+				}); err != nil {
+					return err
+				}
+				if err := fn(values); err != nil {
+					return err
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// StreamWithMeta streams the query result, calling onMeta once with the metadata of the result columns
+// before onRow is called with the values of every row, e.g. to write headers before the rows.
+// Values are decoded like QuerySingleColumn does, and the row slice can be retained.
+// The stream is stopped as soon as onRow returns an error, which is returned as is.
+func (s *Session) StreamWithMeta(queryStr string, onMeta func(columns []ColumnMeta), onRow func(row []any) error) error {
+	delivered := false
+	return s.forEachRow(queryStr, func(fields []parquet.Field) error {
+		if !delivered {
+			delivered = true
+			onMeta(pqconv.Columns(fields))
+		}
+		return nil
+	}, onRow)
+}
@@ -0,0 +1,52 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStreamWithMeta(t *testing.T) {
+	sess := testSession(t)
+
+	var (
+		metaCalls int
+		columns   []ColumnMeta
+		rows      int
+	)
+	err := sess.StreamWithMeta("SELECT number AS id, toString(number) AS name FROM numbers(100000)", func(cols []ColumnMeta) {
+		if rows > 0 {
+			t.Errorf("expected the metadata before the rows, got it after %d rows", rows)
+		}
+		metaCalls++
+		columns = cols
+	}, func(row []any) error {
+		if metaCalls == 0 {
+			t.Fatalf("expected the metadata before the first row")
+		}
+		if row[0] != uint64(rows) {
+			t.Fatalf("expected row %d, got %v", rows, row[0])
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamWithMeta fail, err: %s", err)
+	}
+	if metaCalls != 1 {
+		t.Errorf("expected the metadata to be delivered once, got %d", metaCalls)
+	}
+	if len(columns) != 2 || columns[0].Name != "id" || columns[0].Type != "UInt64" || columns[1].Name != "name" || columns[1].Type != "String" {
+		t.Errorf("unexpected columns %+v", columns)
+	}
+	if rows != 100000 {
+		t.Errorf("expected 100000 rows, got %d", rows)
+	}
+
+	errStop := errors.New("stop")
+	err = sess.StreamWithMeta("SELECT number FROM numbers(10)", func([]ColumnMeta) {}, func([]any) error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected the row callback error, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package chdb
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/chdb-io/chdb-go/chdb/internal/pqconv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// QueryStructs runs the query and appends one element per result row to the slice pointed to by dest,
+// whose elements are structs or pointers to structs. Columns are mapped to fields like CreateTableFromStruct does:
+// from the `chdb` struct tags, falling back to the field names. Names are compared after normalization when the
+// session was opened with WithColumnNameNormalizer. Result columns without a matching field are ignored.
+func (s *Session) QueryStructs(queryStr string, dest any) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.IsNil() || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice, got %T", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	fields, err := structFields(structType)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]structField, len(fields))
+	for _, f := range fields {
+		byName[s.normalizeColumn(f.column)] = f
+	}
+
+	// mapping holds the field of every result column, nil for the unmatched ones
+	var mapping []*structField
+	return s.forEachRow(queryStr, func(columns []parquet.Field) error {
+		mapping = make([]*structField, len(columns))
+		for i, c := range columns {
+			if f, ok := byName[s.normalizeColumn(c.Name())]; ok {
+				mapping[i] = &f
+			}
+		}
+		return nil
+	}, func(row []any) error {
+		elem := reflect.New(structType)
+		for i, v := range row {
+			f := mapping[i]
+			if f == nil {
+				continue
+			}
+			if err := pqconv.Assign(elem.Elem().FieldByIndex(f.index).Addr().Interface(), v); err != nil {
+				return fmt.Errorf("field %s: %w", f.column, err)
+			}
+		}
+		if elemType.Kind() == reflect.Pointer {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+		return nil
+	})
+}
+
+// QueryRows runs the query and returns its rows decoded into values of type T, a struct or a pointer to a struct,
+// with columns mapped to fields like QueryStructs does. The mapping of the fields of T is computed once and cached.
+func QueryRows[T any](s *Session, queryStr string) ([]T, error) {
+	var rows []T
+	if err := s.QueryStructs(queryStr, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// normalizeColumn applies the column name normalizer of the session, if any.
+func (s *Session) normalizeColumn(name string) string {
+	if s.normalizeName == nil {
+		return name
+	}
+	return s.normalizeName(name)
+}
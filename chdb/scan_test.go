@@ -0,0 +1,90 @@
+package chdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type scanModel struct {
+	UserID uint64   `chdb:"user_id"`
+	Name   string   `chdb:"name"`
+	Score  *int64   `chdb:"score"`
+	Tags   []string `chdb:"tags"`
+	Ratio  float64  `chdb:"-"`
+}
+
+func TestQueryStructs(t *testing.T) {
+	sess := testSession(t)
+
+	var rows []scanModel
+	err := sess.QueryStructs("SELECT number AS user_id, toString(number) AS name, if(number = 1, NULL, toInt64(number)) AS score, ['a'] AS tags, 1.5 AS other FROM numbers(2)", &rows)
+	if err != nil {
+		t.Fatalf("QueryStructs fail, err: %s", err)
+	}
+	zero := int64(0)
+	expected := []scanModel{
+		{UserID: 0, Name: "0", Score: &zero, Tags: []string{"a"}},
+		{UserID: 1, Name: "1", Tags: []string{"a"}},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rows)
+	}
+
+	var ptrs []*scanModel
+	if err := sess.QueryStructs("SELECT 7 AS user_id", &ptrs); err != nil {
+		t.Fatalf("QueryStructs fail, err: %s", err)
+	}
+	if len(ptrs) != 1 || ptrs[0].UserID != 7 {
+		t.Errorf("expected a single row with user_id 7, got %+v", ptrs)
+	}
+
+	if err := sess.QueryStructs("SELECT 1 AS user_id", rows); err == nil {
+		t.Errorf("expected an error for a non pointer destination")
+	}
+}
+
+func TestQueryRows(t *testing.T) {
+	sess := testSession(t)
+	rows, err := QueryRows[scanModel](sess, "SELECT number AS user_id, toString(number) AS name FROM numbers(3)")
+	if err != nil {
+		t.Fatalf("QueryRows fail, err: %s", err)
+	}
+	if len(rows) != 3 || rows[2].UserID != 2 || rows[2].Name != "2" {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+	ptrs, err := QueryRows[*scanModel](sess, "SELECT 7 AS user_id")
+	if err != nil || len(ptrs) != 1 || ptrs[0].UserID != 7 {
+		t.Errorf("expected a single row with user_id 7, got %+v, err: %v", ptrs, err)
+	}
+	if _, err := QueryRows[int](sess, "SELECT 1"); err == nil {
+		t.Errorf("expected an error for a non struct type")
+	}
+	// the fields of a type are mapped once
+	first, _ := structFields(reflect.TypeOf(scanModel{}))
+	second, _ := structFields(reflect.TypeOf(&scanModel{}))
+	if &first[0] != &second[0] {
+		t.Errorf("expected the cached fields to be reused")
+	}
+}
+
+func TestQueryStructsWithColumnNameNormalizer(t *testing.T) {
+	// chDB holds a single connection, which the isolated session takes over until it is closed
+	closeSharedSession()
+
+	sess, err := NewIsolatedSession("", WithColumnNameNormalizer(func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	}))
+	if err != nil {
+		t.Fatalf("NewIsolatedSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	var rows []scanModel
+	if err := sess.QueryStructs("SELECT 42 AS UserID, 'bob' AS Name", &rows); err != nil {
+		t.Fatalf("QueryStructs fail, err: %s", err)
+	}
+	if len(rows) != 1 || rows[0].UserID != 42 || rows[0].Name != "bob" {
+		t.Errorf("expected UserID to be mapped to user_id, got %+v", rows)
+	}
+}
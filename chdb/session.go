@@ -1,93 +1,548 @@
 package chdb
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
 )
 
 var (
+	// globalSession is the session registered by OpenSession. It is guarded by connOwnerMu, as it may be released
+	// by the signal handler of WithSignalCleanup.
 	globalSession *Session
+
+	// connOwner is the session holding the native connection: chDB supports a single connection per process, and
+	// opening one closes the previous one.
+	connOwner   *Session
+	connOwnerMu sync.Mutex
 )
 
+const defaultOutputFormat = "CSV"
+
+// ErrSessionClosed is returned when querying a session after Close or Cleanup was called.
+var ErrSessionClosed = errors.New("session is closed")
+
+// QueryError is the error returned when chDB fails to run a query, exposing the ClickHouse error code and name.
+type QueryError = chdbpurego.QueryError
+
+// ErrConnectionInUse is returned when opening a session while another one holds the chDB connection, as chDB supports
+// a single connection per process.
+var ErrConnectionInUse = errors.New("another session holds the chDB connection")
+
+// ErrNoSessionPath is returned by OpenSession when no path is provided and WithNoTempFallback is set.
+var ErrNoSessionPath = errors.New("session path is required")
+
+// Session is a chDB session, holding a connection to its data directory.
+// It is safe to run queries from several goroutines: they are serialized on the connection,
+// or limited to WithMaxConcurrentQueries calls at a time. Close waits for the running queries to return.
 type Session struct {
 	conn    chdbpurego.ChdbConn
 	connStr string
 	path    string
 	isTemp  bool
+	cleanup func(path string) error
+	closeMu sync.Mutex  // serializes Close and Cleanup, which WithSignalCleanup may call concurrently
+	closed  atomic.Bool // read by the queries without holding closeMu
+
+	defaultFormat  string
+	noTempFallback bool
+	rewriter       func(queryStr string) (string, error)
+	normalizeName  func(name string) string
+	breaker        *circuitBreaker
+	signalCleanup  bool
+	maxMemory      int64
+	settings       []string // connection parameters, as key=value
+	udfPath        string
+	maxQueries     int
+	slots          chan struct{} // limits the calls running on conn, see limitedConn
+	tempDir        func() (string, error)
+	stopSignals    func()
+	readOnly       bool
+	queriesMu      sync.Mutex
+	queries        map[string]context.CancelCauseFunc // queries run by QueryContext with an id, see KillQuery
+	snapshotsMu    sync.Mutex
+	snapshots      map[*Session]struct{} // open snapshots of the session
+
+	// snapshot is set on the read-only sessions returned by Snapshot.
+	snapshot *snapshotState
+}
+
+// Option configures a Session created with OpenSession.
+type Option func(*Session)
+
+// WithCleanupFunc overrides how the session directory is removed by Close and Cleanup.
+// It is useful when the directory is managed externally, in which case a no-op function can be passed.
+func WithCleanupFunc(fn func(path string) error) Option {
+	return func(s *Session) {
+		s.cleanup = fn
+	}
+}
+
+// WithDefaultFormat sets the output format used by Query and QueryStream when none is provided, e.g. "Parquet" or
+// "JSONEachRow". It defaults to "CSV". Opening the session fails with ErrInvalidFormat for an unknown format.
+func WithDefaultFormat(format string) Option {
+	return func(s *Session) {
+		s.defaultFormat = format
+	}
+}
+
+// WithConnStr makes the session connect with the given raw connection string instead of one derived from its path.
+// The session path is still extracted from the connection string so that Cleanup can remove it.
+// See chdbpurego.NewConnectionFromConnString for the connection string format.
+func WithConnStr(connStr string) Option {
+	return func(s *Session) {
+		s.connStr = connStr
+	}
+}
+
+// WithNoTempFallback makes OpenSession return ErrNoSessionPath when no path is provided,
+// instead of creating a temporary directory. In-memory sessions opened with WithConnStr can still be opened, but
+// LoadData, InsertArrow and the Appender, which stage their data in the session directory, fail with ErrNoSessionPath.
+func WithNoTempFallback() Option {
+	return func(s *Session) {
+		s.noTempFallback = true
+	}
+}
+
+// WithTempDirFunc makes OpenSession create the temporary directory with fn instead of os.MkdirTemp("", "chdb_"),
+// when no path is provided. The directory is still considered temporary and is removed by Close.
+func WithTempDirFunc(fn func() (string, error)) Option {
+	return func(s *Session) {
+		s.tempDir = fn
+	}
+}
+
+// WithQueryRewriter makes the session pass every query through fn before running it, e.g. to rewrite table names
+// or to add row-level security predicates. A query is rejected when fn returns an error, which is returned as is.
+func WithQueryRewriter(fn func(queryStr string) (string, error)) Option {
+	return func(s *Session) {
+		s.rewriter = fn
+	}
+}
+
+// WithColumnNameNormalizer makes QueryStructs match result columns to struct fields by comparing their names
+// after passing both through fn, e.g. strings.ToLower to match columns case-insensitively.
+func WithColumnNameNormalizer(fn func(name string) string) Option {
+	return func(s *Session) {
+		s.normalizeName = fn
+	}
+}
+
+// WithSetting passes a setting to the underlying connection, the same way as --name=value on the clickhouse-local
+// command line, e.g. WithSetting("max_threads", "4"). It can be given more than once.
+func WithSetting(name, value string) Option {
+	return func(s *Session) {
+		s.settings = append(s.settings, name+"="+value)
+	}
+}
+
+// WithReadOnly opens the session in read-only mode: the connection gets the readonly=1 setting, so that ClickHouse
+// rejects writes and setting changes, and the statements other than reads (SELECT, WITH, SHOW, DESCRIBE, EXPLAIN,
+// EXISTS) are rejected with ErrReadOnlySession before reaching it. It suits processes only analyzing a database
+// written by another one.
+func WithReadOnly() Option {
+	return func(s *Session) {
+		s.readOnly = true
+		s.settings = append(s.settings, "readonly=1")
+	}
+}
+
+// WithLogLevel sets the log level of the underlying connection, e.g. "trace" or "error".
+func WithLogLevel(level string) Option {
+	return WithSetting("log-level", level)
+}
+
+// WithUDFPath makes the session load user defined functions from the given directory,
+// which holds their scripts and their *.xml configuration files.
+func WithUDFPath(path string) Option {
+	return func(s *Session) {
+		s.udfPath = path
+	}
+}
+
+// SessionOptions configures a session created with NewSessionWithOptions.
+type SessionOptions struct {
+	// Path of the session. If empty, a temporary directory is created.
+	Path string
+	// UDFPath is the directory of the user defined functions, see WithUDFPath.
+	UDFPath string
+	// LogLevel is the log level of the underlying connection, e.g. "trace" or "error".
+	LogLevel string
+	// DefaultFormat is the output format used when none is provided to Query or QueryStream. It defaults to "CSV".
+	DefaultFormat string
+	// MaxConcurrentQueries is the number of queries that may run at the same time, see WithMaxConcurrentQueries.
+	// It defaults to 1.
+	MaxConcurrentQueries int
+	// Settings are passed to the underlying connection as --name=value command line arguments.
+	Settings map[string]string
+	// ReadOnly opens the session in read-only mode, see WithReadOnly.
+	ReadOnly bool
+}
+
+// Options returns the options equivalent to o, to be passed to OpenSession with o.Path.
+func (o SessionOptions) Options() []Option {
+	var opts []Option
+	if o.UDFPath != "" {
+		opts = append(opts, WithUDFPath(o.UDFPath))
+	}
+	if o.LogLevel != "" {
+		opts = append(opts, WithLogLevel(o.LogLevel))
+	}
+	if o.DefaultFormat != "" {
+		opts = append(opts, WithDefaultFormat(o.DefaultFormat))
+	}
+	if o.MaxConcurrentQueries > 0 {
+		opts = append(opts, WithMaxConcurrentQueries(o.MaxConcurrentQueries))
+	}
+	if o.ReadOnly {
+		opts = append(opts, WithReadOnly())
+	}
+	names := make([]string, 0, len(o.Settings))
+	for name := range o.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		opts = append(opts, WithSetting(name, o.Settings[name]))
+	}
+	return opts
 }
 
 // NewSession creates a new session with the given path.
 // If path is empty, a temporary directory is created.
 // Note: The temporary directory is removed when Close is called.
 func NewSession(paths ...string) (*Session, error) {
+	path := ""
+	if len(paths) > 0 {
+		path = paths[0]
+	}
+	return OpenSession(path)
+}
+
+// NewSessionWithOptions creates a new session configured with opts.
+// If a session is already open it is returned as is, and the options are ignored.
+func NewSessionWithOptions(opts SessionOptions) (*Session, error) {
+	return OpenSession(opts.Path, opts.Options()...)
+}
+
+// OpenSession creates a new session with the given path, configured with the given options.
+// If path is empty, a temporary directory is created.
+// The session is registered as the global one: while it is open, it is returned as is by later calls,
+// whatever their path, and their options are ignored. It fails with ErrConnectionInUse while a session opened with
+// NewIsolatedSession is open. Use NewIsolatedSession to open a session which isn't registered as the global one.
+func OpenSession(path string, opts ...Option) (*Session, error) {
+	connOwnerMu.Lock()
+	defer connOwnerMu.Unlock()
 	if globalSession != nil {
 		return globalSession, nil
 	}
+	sess, err := openSessionLocked(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	globalSession = sess
+	return globalSession, nil
+}
 
-	path := ""
-	if len(paths) > 0 {
-		path = paths[0]
+// NewIsolatedSession creates a new session with the given path, configured with the given options, without
+// registering it as the global session: it is never returned by NewSession or OpenSession, and is not closed by
+// CloseGlobalSession. chDB supports a single connection per process, so it fails with ErrConnectionInUse while
+// another session, global or isolated, is open, and OpenSession fails the same way while it is open.
+// If path is empty, a temporary directory is created.
+func NewIsolatedSession(path string, opts ...Option) (*Session, error) {
+	return openSession(path, opts)
+}
+
+// openSession opens a session, without registering it.
+func openSession(path string, opts []Option) (*Session, error) {
+	connOwnerMu.Lock()
+	defer connOwnerMu.Unlock()
+	return openSessionLocked(path, opts)
+}
+
+// openSessionLocked opens a session with connOwnerMu held.
+func openSessionLocked(path string, opts []Option) (_ *Session, err error) {
+	sess := &Session{cleanup: os.RemoveAll, defaultFormat: defaultOutputFormat, maxQueries: defaultMaxConcurrentQueries}
+	for _, opt := range opts {
+		opt(sess)
+	}
+	if err := validateFormat(sess.defaultFormat); err != nil {
+		return nil, err
 	}
+	if connOwner != nil {
+		return nil, ErrConnectionInUse
+	}
+
 	isTemp := false
-	if path == "" {
+	if sess.connStr != "" {
+		p, err := pathFromConnStr(sess.connStr)
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	} else if path == "" {
+		if sess.noTempFallback {
+			return nil, ErrNoSessionPath
+		}
 		// Create a temporary directory
-		tempDir, err := os.MkdirTemp("", "chdb_")
+		var tempDir string
+		if sess.tempDir != nil {
+			tempDir, err = sess.tempDir()
+		} else {
+			tempDir, err = os.MkdirTemp("", "chdb_")
+		}
 		if err != nil {
 			return nil, err
 		}
 		path = tempDir
 		isTemp = true
+		// the session isn't returned when the open fails, so nothing else would remove the directory
+		defer func() {
+			if err != nil {
+				_ = sess.cleanup(tempDir)
+			}
+		}()
+	}
+	connStr := sess.connStr
+	if connStr == "" {
+		connStr = path
+	}
+	connStr, err = sess.appendSettings(connStr)
+	if err != nil {
+		return nil, err
 	}
-	connStr := path
 
 	conn, err := initConnection(connStr)
 	if err != nil {
 		return nil, err
 	}
-	globalSession = &Session{connStr: connStr, path: path, isTemp: isTemp, conn: conn}
-	return globalSession, nil
+	connOwner = sess
+	sess.slots = make(chan struct{}, sess.maxQueries)
+	sess.connStr, sess.path, sess.isTemp, sess.conn = connStr, path, isTemp, newLimitedConn(conn, sess.slots)
+	if sess.signalCleanup {
+		sess.watchSignals()
+	}
+	return sess, nil
+}
+
+// connInUse reports whether a session holds the native connection.
+func connInUse() bool {
+	connOwnerMu.Lock()
+	defer connOwnerMu.Unlock()
+	return connOwner != nil
 }
 
-// Query calls `query_conn` function with the current connection and a default output format of "CSV" if not provided.
+// appendSettings appends the settings of the session to the parameters of connStr.
+// The UDF path goes last, as the connection passes the arguments following it as server settings.
+func (s *Session) appendSettings(connStr string) (string, error) {
+	settings := append([]string(nil), s.settings...)
+	if s.maxMemory > 0 {
+		settings = append(settings, fmt.Sprintf("max_server_memory_usage=%d", s.maxMemory))
+	}
+	if s.udfPath != "" {
+		settings = append(settings, "udf_path="+s.udfPath)
+	}
+	for _, setting := range settings {
+		if strings.ContainsAny(setting, "&?") {
+			return "", fmt.Errorf("invalid session setting %q", setting)
+		}
+		sep := "?"
+		if strings.Contains(connStr, "?") {
+			sep = "&"
+		}
+		connStr += sep + setting
+	}
+	return connStr, nil
+}
+
+// pathFromConnStr extracts the database path of a connection string, resolved to an absolute path.
+// An empty path is returned for in-memory connection strings.
+func pathFromConnStr(connStr string) (string, error) {
+	path := strings.TrimPrefix(connStr, "file:")
+	if strings.HasPrefix(path, "///") {
+		path = path[2:]
+	}
+	path, _, _ = strings.Cut(path, "?")
+	if path == "" || path == ":memory:" {
+		return "", nil
+	}
+	return filepath.Abs(path)
+}
+
+// Query calls `query_conn` function with the current connection and the session default output format if not provided.
 func (s *Session) Query(queryStr string, outputFormats ...string) (result chdbpurego.ChdbResult, err error) {
-	outputFormat := "CSV" // Default value
-	if len(outputFormats) > 0 {
-		outputFormat = outputFormats[0]
+	if s.closed.Load() {
+		return nil, ErrSessionClosed
+	}
+	format := s.outputFormat(outputFormats)
+	if err := validateFormat(format); err != nil {
+		return nil, err
+	}
+	if queryStr, err = s.rewrite(queryStr); err != nil {
+		return nil, err
+	}
+	if s.isReadOnly() && !isReadQuery(queryStr) {
+		return nil, ErrReadOnlySession
+	}
+	if err := s.breaker.allow(); err != nil {
+		return nil, err
+	}
+	defer func() { s.breaker.done(err) }()
+	if s.snapshot != nil {
+		err = s.snapshot.inDatabase(func(conn chdbpurego.ChdbConn) error {
+			result, err = conn.Query(queryStr, format)
+			return err
+		})
+		return result, err
 	}
-	return s.conn.Query(queryStr, outputFormat)
+	return s.conn.Query(queryStr, format)
 }
 
-// QueryStream calls `query_conn` function with the current connection and a default output format of "CSV" if not provided.
+// QueryStream calls `query_conn` function with the current connection and the session default output format if not provided.
 // The result is a stream of data that can be read in chunks.
 // This is useful for large datasets that cannot be loaded into memory all at once.
 func (s *Session) QueryStream(queryStr string, outputFormats ...string) (result chdbpurego.ChdbStreamResult, err error) {
-	outputFormat := "CSV" // Default value
-	if len(outputFormats) > 0 {
-		outputFormat = outputFormats[0]
+	if s.closed.Load() {
+		return nil, ErrSessionClosed
 	}
-	return s.conn.QueryStreaming(queryStr, outputFormat)
+	format := s.outputFormat(outputFormats)
+	if err := validateFormat(format); err != nil {
+		return nil, err
+	}
+	if queryStr, err = s.rewrite(queryStr); err != nil {
+		return nil, err
+	}
+	if s.isReadOnly() && !isReadQuery(queryStr) {
+		return nil, ErrReadOnlySession
+	}
+	if err := s.breaker.allow(); err != nil {
+		return nil, err
+	}
+	defer func() { s.breaker.done(err) }()
+	if s.snapshot != nil {
+		err = s.snapshot.inDatabase(func(conn chdbpurego.ChdbConn) error {
+			result, err = conn.QueryStreaming(queryStr, format)
+			return err
+		})
+		return result, err
+	}
+	return s.conn.QueryStreaming(queryStr, format)
+}
+
+// rewrite applies the query rewriter of the session, if any.
+func (s *Session) rewrite(queryStr string) (string, error) {
+	if s.rewriter == nil {
+		return queryStr, nil
+	}
+	return s.rewriter(queryStr)
+}
+
+// outputFormat returns the first of the given formats, or the session default one.
+func (s *Session) outputFormat(outputFormats []string) string {
+	if len(outputFormats) > 0 && outputFormats[0] != "" {
+		return outputFormats[0]
+	}
+	return s.defaultFormat
+}
+
+// isReadOnly reports whether only read queries are allowed on the session, i.e. it is a snapshot or was opened
+// with WithReadOnly.
+func (s *Session) isReadOnly() bool {
+	return s.readOnly || s.snapshot != nil
+}
+
+// DefaultFormat returns the output format used when none is provided to Query or QueryStream.
+func (s *Session) DefaultFormat() string {
+	return s.defaultFormat
 }
 
 // Close closes the session and removes the temporary directory
 //
 //	temporary directory is created when NewSession was called with an empty path.
 func (s *Session) Close() {
-	// Remove the temporary directory if it starts with "chdb_"
-	s.conn.Close()
-	if s.isTemp && filepath.Base(s.path)[:5] == "chdb_" {
-		s.Cleanup()
+	if s.snapshot != nil {
+		s.closeSnapshot()
+		return
+	}
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	// Remove the temporary directory if it starts with "chdb_", or if it was created by the WithTempDirFunc function
+	s.closeConn()
+	if s.isTemp && (s.tempDir != nil || strings.HasPrefix(filepath.Base(s.path), "chdb_")) {
+		s.removeDir()
 	}
-	globalSession = nil
+	s.release()
 }
 
 // Cleanup closes the session and removes the directory.
 func (s *Session) Cleanup() {
-	// Remove the session directory, no matter if it is temporary or not
-	_ = os.RemoveAll(s.path)
+	if s.snapshot != nil {
+		s.closeSnapshot()
+		return
+	}
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+	s.removeDir()
+}
+
+// removeDir removes the session directory, no matter if it is temporary or not, and closes the session.
+func (s *Session) removeDir() {
+	_ = s.cleanup(s.path)
+	s.closeConn()
+	s.release()
+}
+
+// closeConn closes the underlying connection, only once.
+func (s *Session) closeConn() {
+	if s.closed.Swap(true) {
+		return
+	}
 	s.conn.Close()
-	globalSession = nil
+	connOwnerMu.Lock()
+	if connOwner == s {
+		connOwner = nil
+	}
+	connOwnerMu.Unlock()
+}
+
+// withConnClosed runs fn with the native connection of the session closed, e.g. to let chDB open another database or
+// to archive the data directory, and reopens it afterwards, see limitedConn.reopen. The snapshots of the session are
+// invalidated first. If the connection can't be reopened, the session is closed.
+func (s *Session) withConnClosed(fn func() error) error {
+	conn, ok := s.conn.(*limitedConn)
+	if !ok {
+		return errors.New("the connection of the session can't be reopened")
+	}
+	s.invalidateSnapshots()
+	fnErr, err := conn.reopen(fn, func() (chdbpurego.ChdbConn, error) {
+		return initConnection(s.connStr)
+	})
+	if err != nil {
+		s.Close()
+		return fmt.Errorf("reopen session: %w", err)
+	}
+	return fnErr
+}
+
+// release unregisters the session if it is the global one.
+func (s *Session) release() {
+	if s.stopSignals != nil {
+		s.stopSignals()
+		s.stopSignals = nil
+	}
+	connOwnerMu.Lock()
+	if globalSession == s {
+		globalSession = nil
+	}
+	connOwnerMu.Unlock()
 }
 
 // Path returns the path of the session.
@@ -1,9 +1,12 @@
 package chdb
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -132,3 +135,446 @@ func TestSessionCleanup(t *testing.T) {
 		t.Errorf("Session directory should be removed after Cleanup: %s", session.Path())
 	}
 }
+
+// testSession returns the shared test session, opening a new one if an earlier test closed it.
+func testSession(t *testing.T) *Session {
+	t.Helper()
+	if globalSession != nil {
+		return globalSession
+	}
+	sess, err := NewSession()
+	if err != nil {
+		t.Fatalf("open session fail, err: %s", err)
+	}
+	session = sess
+	return sess
+}
+
+func TestQueryColumns(t *testing.T) {
+	sess := testSession(t)
+	cols, err := sess.QueryColumns("SELECT toUInt32(1) AS id, 'abc' AS name, toNullable(toInt64(2)) AS score, 1.5::Float64 AS ratio, true AS flag, toLowCardinality('x') AS lc")
+	if err != nil {
+		t.Fatalf("QueryColumns fail, err: %s", err)
+	}
+	expected := []struct {
+		name     string
+		chType   string
+		nullable bool
+		scanType reflect.Type
+	}{
+		{"id", "UInt32", false, reflect.TypeOf(int32(0))},
+		{"name", "String", false, reflect.TypeOf("")},
+		{"score", "Nullable(Int64)", true, reflect.TypeOf((*int64)(nil))},
+		{"ratio", "Float64", false, reflect.TypeOf(float64(0))},
+		{"flag", "Bool", false, reflect.TypeOf(false)},
+		// LowCardinality is reported as described, and still scans as its inner type
+		{"lc", "LowCardinality(String)", false, reflect.TypeOf("")},
+	}
+	if len(cols) != len(expected) {
+		t.Fatalf("expected %d columns, got %d", len(expected), len(cols))
+	}
+	for i, exp := range expected {
+		col := cols[i]
+		if col.Name != exp.name {
+			t.Errorf("column %d: expected name %s, got %s", i, exp.name, col.Name)
+		}
+		if col.Type != exp.chType {
+			t.Errorf("column %s: expected type %s, got %s", exp.name, exp.chType, col.Type)
+		}
+		if col.Nullable != exp.nullable {
+			t.Errorf("column %s: expected nullable %v, got %v", exp.name, exp.nullable, col.Nullable)
+		}
+		if col.ScanType != exp.scanType {
+			t.Errorf("column %s: expected scan type %v, got %v", exp.name, exp.scanType, col.ScanType)
+		}
+	}
+}
+
+func TestDescribeQuery(t *testing.T) {
+	sess := testSession(t)
+	cols, err := sess.DescribeQuery("SELECT number AS id, toString(number) AS name, number * 1.5 AS score, [id] AS ids, NULL AS missing FROM numbers(10);")
+	if err != nil {
+		t.Fatalf("DescribeQuery fail, err: %s", err)
+	}
+	expected := []ColumnInfo{
+		{Name: "id", Type: "UInt64"},
+		{Name: "name", Type: "String"},
+		{Name: "score", Type: "Float64"},
+		{Name: "ids", Type: "Array(UInt64)"},
+		{Name: "missing", Type: "Nullable(Nothing)"},
+	}
+	if !reflect.DeepEqual(cols, expected) {
+		t.Errorf("expected columns %v, got %v", expected, cols)
+	}
+
+	if _, err := sess.DescribeQuery("SELECT * FROM TestDescribeQueryMissing"); err == nil {
+		t.Errorf("expected an error describing a query on a missing table")
+	}
+}
+
+// closeSharedSession closes the currently open session, so that a test can open one with its own options.
+func closeSharedSession() {
+	if globalSession != nil {
+		globalSession.Close()
+	}
+}
+
+func TestSessionWithCleanupFunc(t *testing.T) {
+	closeSharedSession()
+
+	var cleaned []string
+	sess, err := OpenSession("", WithCleanupFunc(func(path string) error {
+		cleaned = append(cleaned, path)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer os.RemoveAll(sess.Path())
+
+	sess.Close()
+
+	if len(cleaned) != 1 || cleaned[0] != sess.Path() {
+		t.Fatalf("expected cleanup func to be called once with %s, got %v", sess.Path(), cleaned)
+	}
+	if _, err := os.Stat(sess.Path()); err != nil {
+		t.Errorf("Session directory should be left in place by the custom cleanup func: %s", err)
+	}
+}
+
+func TestQueryAfterCleanup(t *testing.T) {
+	sess := testSession(t)
+	sess.Cleanup()
+
+	if _, err := sess.Query("SELECT 1"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from Query, got %v", err)
+	}
+	if _, err := sess.QueryStream("SELECT 1"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed from QueryStream, got %v", err)
+	}
+	// closing an already cleaned up session must be safe
+	sess.Close()
+}
+
+func TestSessionWithConnStr(t *testing.T) {
+	closeSharedSession()
+
+	path := filepath.Join(os.TempDir(), "chdb_test_connstr")
+	defer os.RemoveAll(path)
+	connStr := "file:" + path + "?verbose&log-level=test"
+
+	sess, err := OpenSession("", WithConnStr(connStr))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer sess.Cleanup()
+
+	if sess.ConnStr() != connStr {
+		t.Errorf("expected connection string %s, got %s", connStr, sess.ConnStr())
+	}
+	if sess.Path() != path {
+		t.Errorf("expected session path %s, got %s", path, sess.Path())
+	}
+	if sess.IsTemp() {
+		t.Errorf("Session should not be temporary")
+	}
+	ret, err := sess.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "1\n" {
+		t.Errorf("Query result should be 1\n, got %s", ret.String())
+	}
+}
+
+func TestNewSessionWithOptions(t *testing.T) {
+	closeSharedSession()
+
+	path := filepath.Join(t.TempDir(), "chdb_options")
+	sess, err := NewSessionWithOptions(SessionOptions{
+		Path:          path,
+		LogLevel:      "error",
+		DefaultFormat: "TSV",
+		Settings:      map[string]string{"max_threads": "3", "max_block_size": "1000"},
+	})
+	if err != nil {
+		t.Fatalf("NewSessionWithOptions fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	expected := path + "?log-level=error&max_block_size=1000&max_threads=3"
+	if sess.ConnStr() != expected {
+		t.Errorf("expected connection string %s, got %s", expected, sess.ConnStr())
+	}
+	ret, err := sess.Query("SELECT getSetting('max_threads')")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "3\n" {
+		t.Errorf("expected the max_threads setting to be applied, got %q", ret.String())
+	}
+}
+
+func TestSessionReadOnly(t *testing.T) {
+	closeSharedSession()
+
+	path := filepath.Join(t.TempDir(), "chdb_readonly")
+	sess, err := NewIsolatedSession(path)
+	if err != nil {
+		t.Fatalf("NewIsolatedSession fail, err: %s", err)
+	}
+	if _, err := sess.Exec("CREATE TABLE t (id UInt32) ENGINE = MergeTree ORDER BY id"); err != nil {
+		t.Fatalf("create table fail, err: %s", err)
+	}
+	if _, err := sess.Exec("INSERT INTO t VALUES (1), (2)"); err != nil {
+		t.Fatalf("insert fail, err: %s", err)
+	}
+	sess.Close()
+
+	readOnly, err := NewSessionWithOptions(SessionOptions{Path: path, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("NewSessionWithOptions fail, err: %s", err)
+	}
+	defer readOnly.Close()
+
+	ret, err := readOnly.Query("SELECT count() FROM t")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "2\n" {
+		t.Errorf("expected 2 rows, got %q", ret.String())
+	}
+	for _, query := range []string{"INSERT INTO t VALUES (3)", "DROP TABLE t", "SET max_threads = 1"} {
+		if _, err := readOnly.Query(query); !errors.Is(err, ErrReadOnlySession) {
+			t.Errorf("%s: expected ErrReadOnlySession, got %v", query, err)
+		}
+	}
+	if _, err := readOnly.Snapshot(); !errors.Is(err, ErrReadOnlySession) {
+		t.Errorf("expected ErrReadOnlySession for a snapshot, got %v", err)
+	}
+	ret, err = readOnly.Query("SELECT getSetting('readonly')")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "1\n" {
+		t.Errorf("expected the readonly setting to be applied, got %q", ret.String())
+	}
+}
+
+func TestSessionWithInvalidSetting(t *testing.T) {
+	closeSharedSession()
+
+	if _, err := OpenSession(t.TempDir(), WithSetting("max_threads", "1&readonly=1")); err == nil {
+		t.Fatalf("expected an error for a setting value containing '&'")
+	}
+	if globalSession != nil {
+		t.Fatalf("no session should be registered after the error")
+	}
+}
+
+func TestNewIsolatedSession(t *testing.T) {
+	shared := testSession(t)
+
+	path := filepath.Join(t.TempDir(), "chdb_isolated")
+	if _, err := NewIsolatedSession(path); !errors.Is(err, ErrConnectionInUse) {
+		t.Fatalf("expected ErrConnectionInUse while the global session is open, got %v", err)
+	}
+	if _, err := shared.Query("SELECT 1"); err != nil {
+		t.Fatalf("the global session must stay usable, err: %s", err)
+	}
+
+	closeSharedSession()
+	sess, err := NewIsolatedSession(path)
+	if err != nil {
+		t.Fatalf("NewIsolatedSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	if globalSession != nil {
+		t.Fatalf("expected the isolated session not to be registered as the global one")
+	}
+	if _, err := OpenSession(""); !errors.Is(err, ErrConnectionInUse) {
+		t.Errorf("expected ErrConnectionInUse while the isolated session is open, got %v", err)
+	}
+	if sess.Path() != path {
+		t.Errorf("expected session path %s, got %s", path, sess.Path())
+	}
+	ret, err := sess.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "1\n" {
+		t.Errorf("Query result should be 1\n, got %s", ret.String())
+	}
+	sess.Close()
+
+	reopened, err := OpenSession("")
+	if err != nil {
+		t.Fatalf("expected a session to open once the isolated one is closed, err: %s", err)
+	}
+	reopened.Close()
+}
+
+func TestSessionWithDefaultFormat(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession("", WithDefaultFormat("TSV"))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	if sess.DefaultFormat() != "TSV" {
+		t.Errorf("expected default format TSV, got %s", sess.DefaultFormat())
+	}
+	ret, err := sess.Query("SELECT 'abc'")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "abc\n" {
+		t.Errorf("expected the default TSV format to be applied, got %q", ret.String())
+	}
+	ret, err = sess.Query("SELECT 'abc'", "CSV")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "\"abc\"\n" {
+		t.Errorf("expected the explicit CSV format to override the default, got %q", ret.String())
+	}
+
+	if _, err := NewIsolatedSession("", WithDefaultFormat("Csv")); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("expected ErrInvalidFormat for an unknown default format, got %v", err)
+	}
+}
+
+func TestSessionWithNoTempFallback(t *testing.T) {
+	closeSharedSession()
+
+	if _, err := OpenSession("", WithNoTempFallback()); !errors.Is(err, ErrNoSessionPath) {
+		t.Fatalf("expected ErrNoSessionPath without a path, got %v", err)
+	}
+	if globalSession != nil {
+		t.Fatalf("no session should be registered after the error")
+	}
+
+	path := filepath.Join(t.TempDir(), "chdb_no_temp")
+	sess, err := OpenSession(path, WithNoTempFallback())
+	if err != nil {
+		t.Fatalf("OpenSession with an explicit path fail, err: %s", err)
+	}
+	defer sess.Close()
+	if sess.IsTemp() || sess.Path() != path {
+		t.Errorf("expected a non temporary session at %s, got %s", path, sess.Path())
+	}
+}
+
+func TestSessionWithQueryRewriter(t *testing.T) {
+	closeSharedSession()
+
+	errForbidden := errors.New("forbidden statement")
+	sess, err := OpenSession("", WithQueryRewriter(func(queryStr string) (string, error) {
+		if strings.HasPrefix(queryStr, "DROP") {
+			return "", errForbidden
+		}
+		if strings.HasPrefix(queryStr, "SELECT") && strings.HasSuffix(queryStr, "FROM TestQueryRewriter") {
+			return queryStr + " WHERE tenant = 'a'", nil
+		}
+		return queryStr, nil
+	}))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	defer sess.Close()
+
+	if _, err := sess.Query("CREATE TABLE TestQueryRewriter (tenant String, id UInt32) ENGINE = MergeTree() ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("INSERT INTO TestQueryRewriter VALUES ('a', 1), ('b', 2), ('a', 3)"); err != nil {
+		t.Fatal(err)
+	}
+	ret, err := sess.Query("SELECT count() FROM TestQueryRewriter")
+	if err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	if ret.String() != "2\n" {
+		t.Errorf("expected the rewritten query to only count tenant a rows, got %s", ret.String())
+	}
+	if _, err := sess.Query("DROP TABLE TestQueryRewriter"); !errors.Is(err, errForbidden) {
+		t.Errorf("expected the rewriter to reject the query, got %v", err)
+	}
+	if _, err := sess.QueryStream("DROP TABLE TestQueryRewriter"); !errors.Is(err, errForbidden) {
+		t.Errorf("expected the rewriter to reject the streaming query, got %v", err)
+	}
+}
+
+func TestQueryErrorCode(t *testing.T) {
+	sess := testSession(t)
+	tests := []struct {
+		query string
+		code  int
+		name  string
+	}{
+		{"SELECT * FROM TestQueryErrorCodeMissing", 60, "UNKNOWN_TABLE"},
+		{"SELEC 1", 62, "SYNTAX_ERROR"},
+	}
+	for _, tt := range tests {
+		_, err := sess.Query(tt.query)
+		var qerr *QueryError
+		if !errors.As(err, &qerr) {
+			t.Fatalf("%s: expected a QueryError, got %v", tt.query, err)
+		}
+		if qerr.Code != tt.code || qerr.Name != tt.name {
+			t.Errorf("%s: expected error %d %s, got %d %s", tt.query, tt.code, tt.name, qerr.Code, qerr.Name)
+		}
+	}
+}
+
+func TestSessionWithTempDirFunc(t *testing.T) {
+	closeSharedSession()
+
+	base := t.TempDir()
+	calls := 0
+	sess, err := OpenSession("", WithTempDirFunc(func() (string, error) {
+		calls++
+		return os.MkdirTemp(base, "approved_")
+	}))
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	if calls != 1 || filepath.Dir(sess.Path()) != base {
+		t.Fatalf("expected the custom temp dir func to be used, got %s after %d calls", sess.Path(), calls)
+	}
+	if !sess.IsTemp() {
+		t.Errorf("session should be temporary")
+	}
+	if _, err := sess.Query("SELECT 1"); err != nil {
+		t.Fatalf("Query fail, err: %s", err)
+	}
+	sess.Close()
+	if _, err := os.Stat(sess.Path()); !os.IsNotExist(err) {
+		t.Errorf("custom temporary directory should be removed after Close: %s", sess.Path())
+	}
+
+	closeSharedSession()
+	if _, err := OpenSession("", WithTempDirFunc(func() (string, error) {
+		return "", errors.New("not allowed")
+	})); err == nil {
+		t.Errorf("expected the temp dir func error to be returned")
+	}
+}
+
+func TestSessionFailedOpenRemovesTempDir(t *testing.T) {
+	closeSharedSession()
+
+	var dir string
+	if _, err := NewIsolatedSession("", WithSetting("max_threads", "4&5"), WithTempDirFunc(func() (string, error) {
+		var err error
+		dir, err = os.MkdirTemp(t.TempDir(), "failed_")
+		return dir, err
+	})); err == nil {
+		t.Fatalf("expected an error for the invalid setting")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("the temporary directory of the failed open should be removed: %s", dir)
+	}
+}
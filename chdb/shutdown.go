@@ -0,0 +1,49 @@
+package chdb
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// CloseGlobalSession closes the currently open session, if any, removing its directory when it is temporary.
+// It is a no-op when no session is open.
+func CloseGlobalSession() {
+	connOwnerMu.Lock()
+	sess := globalSession
+	connOwnerMu.Unlock()
+	if sess != nil {
+		sess.Close()
+	}
+}
+
+// WithSignalCleanup makes the session close itself when the process receives SIGINT or SIGTERM,
+// so that temporary directories are removed on graceful termination. The signal is then raised again
+// with the default behavior restored, so the process still terminates as it would have.
+func WithSignalCleanup() Option {
+	return func(s *Session) {
+		s.signalCleanup = true
+	}
+}
+
+// watchSignals closes the session on SIGINT or SIGTERM until stopSignals is called. The session may then be closed
+// by the signal and by its owner at the same time, which closeMu serializes.
+func (s *Session) watchSignals() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	s.stopSignals = func() {
+		signal.Stop(signals)
+		close(done)
+	}
+	go func() {
+		select {
+		case sig := <-signals:
+			s.Close()
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				p.Signal(sig)
+			}
+		case <-done:
+		}
+	}()
+}
@@ -0,0 +1,57 @@
+package chdb
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestCloseGlobalSession(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession("", WithSignalCleanup())
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	if _, err := os.Stat(sess.Path()); err != nil {
+		t.Fatalf("session directory should exist: %s", err)
+	}
+
+	CloseGlobalSession()
+	if _, err := os.Stat(sess.Path()); !os.IsNotExist(err) {
+		t.Errorf("temporary directory should be removed by CloseGlobalSession: %s", sess.Path())
+	}
+	if globalSession != nil {
+		t.Errorf("global session should be unregistered")
+	}
+	if sess.stopSignals != nil {
+		t.Errorf("signal watching should be stopped once the session is closed")
+	}
+	// closing again without a session is a no-op
+	CloseGlobalSession()
+}
+
+func TestCloseConcurrently(t *testing.T) {
+	closeSharedSession()
+
+	sess, err := OpenSession("", WithSignalCleanup())
+	if err != nil {
+		t.Fatalf("OpenSession fail, err: %s", err)
+	}
+	// the signal watcher may close the session while its owner does
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess.Close()
+		}()
+	}
+	wg.Wait()
+	if _, err := os.Stat(sess.Path()); !os.IsNotExist(err) {
+		t.Errorf("temporary directory should be removed: %s", sess.Path())
+	}
+	if globalSession != nil || sess.stopSignals != nil {
+		t.Errorf("session should be released once closed")
+	}
+}
@@ -0,0 +1,177 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// ErrReadOnlySession is returned when running a statement other than a read on a read-only session, i.e. a snapshot
+// or a session opened with WithReadOnly.
+var ErrReadOnlySession = errors.New("session is read-only")
+
+var snapshotSeq atomic.Int64
+
+// Snapshot returns a read-only view of the current database of the session, as it is at the time of the call.
+//
+// The tables of the current database are copied into a new database, which the returned session queries
+// instead of the original one; views, dictionaries and temporary tables are not copied. Reads on the snapshot
+// are not affected by later writes to the original session. The tables are copied one after the other, so the
+// snapshot is consistent as long as no writes are issued concurrently with Snapshot; there is no isolation
+// across tables beyond that.
+//
+// The snapshot shares the connection of the session: only read queries (SELECT, WITH, SHOW, DESCRIBE, EXPLAIN, EXISTS)
+// are allowed on it, and it stops working once the session is closed. Close drops the copied database.
+func (s *Session) Snapshot() (*Session, error) {
+	if s.closed.Load() {
+		return nil, ErrSessionClosed
+	}
+	if s.isReadOnly() {
+		return nil, ErrReadOnlySession
+	}
+	origin, err := s.queryString("SELECT currentDatabase()")
+	if err != nil {
+		return nil, err
+	}
+	tables, err := s.queryString(`SELECT name FROM system.tables WHERE database = currentDatabase() AND NOT is_temporary
+		AND engine NOT IN ('View', 'MaterializedView', 'LiveView', 'WindowView', 'Dictionary')`)
+	if err != nil {
+		return nil, err
+	}
+
+	database := fmt.Sprintf("chdb_snapshot_%d_%d", time.Now().UnixNano(), snapshotSeq.Add(1))
+	if err := s.run("CREATE DATABASE " + quoteIdentifier(database)); err != nil {
+		return nil, err
+	}
+	for _, table := range strings.Split(tables, "\n") {
+		if table == "" {
+			continue
+		}
+		src := quoteIdentifier(origin) + "." + quoteIdentifier(table)
+		dst := quoteIdentifier(database) + "." + quoteIdentifier(table)
+		if err := s.run("CREATE TABLE " + dst + " AS " + src); err != nil {
+			s.run("DROP DATABASE IF EXISTS " + quoteIdentifier(database))
+			return nil, fmt.Errorf("snapshot table %s: %w", table, err)
+		}
+		if err := s.run("INSERT INTO " + dst + " SELECT * FROM " + src); err != nil {
+			s.run("DROP DATABASE IF EXISTS " + quoteIdentifier(database))
+			return nil, fmt.Errorf("snapshot table %s: %w", table, err)
+		}
+	}
+
+	snap := &Session{
+		conn:          s.conn,
+		slots:         s.slots,
+		connStr:       s.connStr,
+		path:          s.path,
+		defaultFormat: s.defaultFormat,
+		rewriter:      s.rewriter,
+		normalizeName: s.normalizeName,
+		breaker:       s.breaker,
+		snapshot:      &snapshotState{parent: s, database: database, origin: origin},
+	}
+	s.snapshotsMu.Lock()
+	defer s.snapshotsMu.Unlock()
+	if s.snapshots == nil {
+		s.snapshots = map[*Session]struct{}{}
+	}
+	s.snapshots[snap] = struct{}{}
+	return snap, nil
+}
+
+// snapshotState holds what a snapshot session needs to run its queries against its own database.
+type snapshotState struct {
+	parent   *Session
+	database string
+	origin   string
+}
+
+// inDatabase runs fn with the snapshot database as the current one, restoring the original database afterwards.
+// The current database belongs to the connection shared with the parent session and its other snapshots, so every
+// slot of the connection is held meanwhile: fn runs its query on the given connection, and no other call runs in
+// the snapshot database or switches it away before fn returns.
+func (st *snapshotState) inDatabase(fn func(conn chdbpurego.ChdbConn) error) error {
+	if st.parent.closed.Load() {
+		return ErrSessionClosed
+	}
+	limited, ok := st.parent.conn.(*limitedConn)
+	if !ok {
+		return errors.New("snapshot: the session connection doesn't support exclusive calls")
+	}
+	return limited.exclusive(func(conn chdbpurego.ChdbConn) error {
+		if err := runOn(conn, "USE "+quoteIdentifier(st.database)); err != nil {
+			return err
+		}
+		defer runOn(conn, "USE "+quoteIdentifier(st.origin))
+		return fn(conn)
+	})
+}
+
+// closeSnapshot drops the snapshot database, leaving the shared connection open.
+func (s *Session) closeSnapshot() {
+	parent := s.snapshot.parent
+	parent.snapshotsMu.Lock()
+	delete(parent.snapshots, s)
+	parent.snapshotsMu.Unlock()
+	s.dropSnapshot()
+}
+
+func (s *Session) dropSnapshot() {
+	if s.closed.Swap(true) {
+		return
+	}
+	if !s.snapshot.parent.closed.Load() {
+		s.snapshot.parent.run("DROP DATABASE IF EXISTS " + quoteIdentifier(s.snapshot.database))
+	}
+}
+
+// invalidateSnapshots closes the open snapshots of the session, dropping their databases.
+func (s *Session) invalidateSnapshots() {
+	s.snapshotsMu.Lock()
+	snapshots := s.snapshots
+	s.snapshots = nil
+	s.snapshotsMu.Unlock()
+	for snap := range snapshots {
+		snap.dropSnapshot()
+	}
+}
+
+// run executes a statement on the connection, discarding its result.
+func (s *Session) run(queryStr string) error {
+	return runOn(s.conn, queryStr)
+}
+
+func runOn(conn chdbpurego.ChdbConn, queryStr string) error {
+	res, err := conn.Query(queryStr, "CSV")
+	if err != nil {
+		return err
+	}
+	res.Free()
+	return nil
+}
+
+// queryString executes a query on the connection and returns its raw output.
+func (s *Session) queryString(queryStr string) (string, error) {
+	res, err := s.conn.Query(queryStr, "TSVRaw")
+	if err != nil {
+		return "", err
+	}
+	defer res.Free()
+	return strings.TrimSuffix(res.String(), "\n"), nil
+}
+
+// isReadQuery reports whether the statement only reads data, judging from its first keyword.
+func isReadQuery(queryStr string) bool {
+	queryStr = strings.TrimLeft(queryStr, " \t\r\n(")
+	keyword, _, _ := strings.Cut(queryStr, " ")
+	keyword, _, _ = strings.Cut(keyword, "\n")
+	switch strings.ToUpper(strings.TrimSpace(keyword)) {
+	case "SELECT", "WITH", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "EXISTS":
+		return true
+	}
+	return false
+}
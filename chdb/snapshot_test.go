@@ -0,0 +1,72 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSessionSnapshot(t *testing.T) {
+	sess := testSession(t)
+	if _, err := sess.Query("CREATE TABLE IF NOT EXISTS TestSessionSnapshot (id UInt32) ENGINE = MergeTree() ORDER BY id;"); err != nil {
+		t.Fatal(err)
+	}
+	sess.Query("TRUNCATE TABLE TestSessionSnapshot;")
+	if _, err := sess.Query("INSERT INTO TestSessionSnapshot VALUES (1), (2);"); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := sess.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot fail, err: %s", err)
+	}
+	defer snap.Close()
+
+	if _, err := sess.Query("INSERT INTO TestSessionSnapshot VALUES (3);"); err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := snap.Query("SELECT count() FROM TestSessionSnapshot;")
+	if err != nil {
+		t.Fatalf("snapshot Query fail, err: %s", err)
+	}
+	if ret.String() != "2\n" {
+		t.Errorf("snapshot should not see later writes, got count %s", ret.String())
+	}
+	ret, err = sess.Query("SELECT count() FROM TestSessionSnapshot;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.String() != "3\n" {
+		t.Errorf("expected 3 rows in the original table, got %s", ret.String())
+	}
+
+	if _, err := snap.Query("INSERT INTO TestSessionSnapshot VALUES (4);"); !errors.Is(err, ErrReadOnlySession) {
+		t.Errorf("expected ErrReadOnlySession writing to a snapshot, got %v", err)
+	}
+
+	snap.Close()
+	if _, err := snap.Query("SELECT 1"); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed after closing the snapshot, got %v", err)
+	}
+	if _, err := sess.Query("SELECT count() FROM TestSessionSnapshot;"); err != nil {
+		t.Errorf("closing a snapshot should leave the session open, err: %s", err)
+	}
+}
+
+func TestIsReadQuery(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT 1":                        true,
+		"  with x AS (SELECT 1) SELECT":   true,
+		"(SELECT 1) UNION ALL (SELECT 2)": true,
+		"SHOW TABLES":                     true,
+		"DESCRIBE\nt":                     true,
+		"INSERT INTO t VALUES (1)":        false,
+		"DROP TABLE t":                    false,
+		"":                                false,
+	}
+	for query, expected := range tests {
+		if got := isReadQuery(query); got != expected {
+			t.Errorf("isReadQuery(%q) = %v, expected %v", query, got, expected)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Source is a remote dataset read through a table function, such as an S3Source or a URLSource.
+type Source interface {
+	// SQL returns the table function call reading the source, to be used in the FROM clause of a query.
+	SQL() string
+	// collection returns the parameters of the named collection of the source.
+	collection() ([][2]string, error)
+}
+
+// S3Credentials are the AWS credentials of an S3Source.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // optional, for temporary credentials
+}
+
+// S3Source is a dataset stored in S3, or in an S3 compatible storage, read with the s3 table function.
+type S3Source struct {
+	Bucket string
+	// Key of the object, which may hold globs such as data/*.parquet or data/{2023,2024}/*.csv. It is not escaped.
+	Key    string
+	Region string
+	// Endpoint overrides the AWS endpoint, e.g. http://localhost:9000 for a MinIO server. The bucket is appended as
+	// the first segment of its path.
+	Endpoint string
+	// Format of the objects, detected from the key extension when empty.
+	Format string
+	// Structure of the objects, e.g. "id UInt64, name String", inferred from the data when empty.
+	Structure string
+	// Credentials signing the requests. Public buckets are read without signing when nil.
+	Credentials *S3Credentials
+}
+
+// URL returns the URL of the objects of the source.
+func (src S3Source) URL() string {
+	key := strings.TrimPrefix(src.Key, "/")
+	switch {
+	case src.Endpoint != "":
+		return strings.TrimSuffix(src.Endpoint, "/") + "/" + src.Bucket + "/" + key
+	case src.Region != "":
+		return "https://" + src.Bucket + ".s3." + src.Region + ".amazonaws.com/" + key
+	default:
+		return "https://" + src.Bucket + ".s3.amazonaws.com/" + key
+	}
+}
+
+// SQL returns the s3 table function call reading the source, with its arguments escaped.
+func (src S3Source) SQL() string {
+	args := []string{quoteString(src.URL())}
+	if c := src.Credentials; c != nil {
+		args = append(args, quoteString(c.AccessKeyID), quoteString(c.SecretAccessKey))
+		if c.SessionToken != "" {
+			args = append(args, quoteString(c.SessionToken))
+		}
+	} else {
+		args = append(args, "NOSIGN")
+	}
+	return "s3(" + strings.Join(append(args, formatArgs(src.Format, src.Structure)...), ", ") + ")"
+}
+
+func (src S3Source) collection() ([][2]string, error) {
+	params := [][2]string{{"url", src.URL()}}
+	if c := src.Credentials; c != nil {
+		params = append(params, [2]string{"access_key_id", c.AccessKeyID}, [2]string{"secret_access_key", c.SecretAccessKey})
+		if c.SessionToken != "" {
+			params = append(params, [2]string{"session_token", c.SessionToken})
+		}
+	} else {
+		params = append(params, [2]string{"no_sign_request", "1"})
+	}
+	return appendFormatParams(params, src.Format, src.Structure), nil
+}
+
+// URLSource is a dataset served over HTTP, read with the url table function.
+type URLSource struct {
+	URL string
+	// Format of the data, detected from the URL extension when empty.
+	Format string
+	// Structure of the data, e.g. "id UInt64, name String", inferred from the data when empty.
+	Structure string
+	// Headers sent with the requests, e.g. an Authorization header. They are sent sorted by name.
+	Headers map[string]string
+}
+
+// SQL returns the url table function call reading the source, with its arguments escaped.
+func (src URLSource) SQL() string {
+	args := append([]string{quoteString(src.URL)}, formatArgs(src.Format, src.Structure)...)
+	if len(src.Headers) > 0 {
+		names := make([]string, 0, len(src.Headers))
+		for name := range src.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		headers := make([]string, len(names))
+		for i, name := range names {
+			headers[i] = quoteString(name) + " = " + quoteString(src.Headers[name])
+		}
+		args = append(args, "headers("+strings.Join(headers, ", ")+")")
+	}
+	return "url(" + strings.Join(args, ", ") + ")"
+}
+
+func (src URLSource) collection() ([][2]string, error) {
+	if len(src.Headers) > 0 {
+		return nil, errors.New("the headers of a URL source can't be stored in a named collection")
+	}
+	return appendFormatParams([][2]string{{"url", src.URL}}, src.Format, src.Structure), nil
+}
+
+// formatArgs returns the format and structure arguments of a table function. The format is auto when only the
+// structure is given.
+func formatArgs(format, structure string) []string {
+	switch {
+	case structure != "":
+		if format == "" {
+			format = "auto"
+		}
+		return []string{quoteString(format), quoteString(structure)}
+	case format != "":
+		return []string{quoteString(format)}
+	}
+	return nil
+}
+
+// appendFormatParams appends the format and structure of a source to the parameters of its named collection.
+func appendFormatParams(params [][2]string, format, structure string) [][2]string {
+	if format != "" {
+		params = append(params, [2]string{"format", format})
+	}
+	if structure != "" {
+		params = append(params, [2]string{"structure", structure})
+	}
+	return params
+}
+
+// RegisterSource stores the parameters of the source, credentials included, in the named collection name of the
+// session, replacing any existing one. The source can then be read without repeating its credentials in the queries,
+// with s3(name) for an S3Source or url(name) for a URLSource. Collections are persisted in plain text in the
+// session directory, which should be protected accordingly.
+func (s *Session) RegisterSource(name string, src Source) error {
+	params, err := src.collection()
+	if err != nil {
+		return err
+	}
+	assignments := make([]string, len(params))
+	for i, p := range params {
+		assignments[i] = p[0] + " = " + quoteString(p[1])
+	}
+	if err := s.exec("DROP NAMED COLLECTION IF EXISTS " + quoteIdentifier(name)); err != nil {
+		return fmt.Errorf("drop named collection %s: %w", name, err)
+	}
+	return s.exec("CREATE NAMED COLLECTION " + quoteIdentifier(name) + " AS " + strings.Join(assignments, ", "))
+}
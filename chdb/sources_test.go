@@ -0,0 +1,48 @@
+package chdb
+
+import (
+	"testing"
+)
+
+func TestSourceSQL(t *testing.T) {
+	for _, tc := range []struct {
+		src      Source
+		expected string
+	}{
+		{S3Source{Bucket: "public", Key: "data/*.parquet"}, "s3('https://public.s3.amazonaws.com/data/*.parquet', NOSIGN)"},
+		{S3Source{Bucket: "b", Key: "/k.csv", Region: "eu-west-1", Format: "CSVWithNames", Structure: "id UInt64",
+			Credentials: &S3Credentials{AccessKeyID: "AKIA", SecretAccessKey: "it's\\secret"}},
+			`s3('https://b.s3.eu-west-1.amazonaws.com/k.csv', 'AKIA', 'it\'s\\secret', 'CSVWithNames', 'id UInt64')`},
+		{S3Source{Bucket: "b", Key: "k", Endpoint: "http://localhost:9000/", Structure: "id UInt64",
+			Credentials: &S3Credentials{AccessKeyID: "a", SecretAccessKey: "s", SessionToken: "t"}},
+			"s3('http://localhost:9000/b/k', 'a', 's', 't', 'auto', 'id UInt64')"},
+		{URLSource{URL: "https://example.com/data.csv"}, "url('https://example.com/data.csv')"},
+		{URLSource{URL: "https://example.com/data", Format: "JSONEachRow", Headers: map[string]string{"X-B": "2", "Authorization": "Bearer 'x'"}},
+			`url('https://example.com/data', 'JSONEachRow', headers('Authorization' = 'Bearer \'x\'', 'X-B' = '2'))`},
+	} {
+		if got := tc.src.SQL(); got != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, got)
+		}
+	}
+}
+
+func TestRegisterSource(t *testing.T) {
+	sess := testSession(t)
+
+	src := S3Source{Bucket: "b", Key: "k.parquet", Credentials: &S3Credentials{AccessKeyID: "a", SecretAccessKey: "s"}}
+	for i := 0; i < 2; i++ {
+		// registering twice replaces the collection
+		if err := sess.RegisterSource("TestRegisterSource", src); err != nil {
+			t.Fatalf("RegisterSource fail, err: %s", err)
+		}
+	}
+	defer sess.Exec("DROP NAMED COLLECTION IF EXISTS TestRegisterSource")
+	var count int
+	if err := sess.QueryScalar("SELECT count() FROM system.named_collections WHERE name = 'TestRegisterSource'", &count); err != nil || count != 1 {
+		t.Errorf("expected the named collection to be registered, got %d, err: %v", count, err)
+	}
+
+	if err := sess.RegisterSource("TestRegisterSourceHeaders", URLSource{URL: "https://example.com", Headers: map[string]string{"a": "b"}}); err == nil {
+		t.Errorf("expected an error registering a URL source with headers")
+	}
+}
@@ -0,0 +1,116 @@
+package chdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// forEachChunk streams the result of the query in the given format, calling fn with the buffer of every chunk.
+// The buffer is owned by the native result, which is freed once fn returns.
+// The stream is cancelled as soon as fn returns an error.
+func (s *Session) forEachChunk(queryStr, format string, fn func(buf []byte) error) error {
+	stream, err := s.QueryStream(queryStr, format)
+	if err != nil {
+		return err
+	}
+	defer stream.Free()
+	for {
+		chunk := stream.GetNext()
+		if chunk == nil {
+			return stream.Error()
+		}
+		done, err := handleChunk(chunk, fn)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// handleChunk calls fn with the buffer of a chunk of a stream and frees the chunk. It reports whether the chunk
+// ends the stream.
+func handleChunk(chunk chdbpurego.ChdbResult, fn func(buf []byte) error) (done bool, err error) {
+	defer chunk.Free()
+	if err := chunk.Error(); err != nil {
+		return true, fmt.Errorf("error in chunk: %w", err)
+	}
+	if chunk.RowsRead() == 0 && chunk.Len() == 0 {
+		return true, nil
+	}
+	return false, fn(chunk.Buf())
+}
+
+// QueryStreamFunc streams the result of the query in the given format, calling fn with every chunk as it is produced.
+// The next chunk is only fetched once fn returns, so a slow fn slows the query down instead of buffering the result.
+// The chunk is owned by the native result and must be copied to be retained after fn returns. The stream is
+// cancelled as soon as fn returns an error, which is returned as is. An empty format falls back to the session
+// default one.
+func (s *Session) QueryStreamFunc(queryStr, format string, fn func(chunk []byte) error) error {
+	return s.forEachChunk(queryStr, format, fn)
+}
+
+// lineSplitter splits a stream of chunks into lines, carrying the lines split across chunk boundaries.
+type lineSplitter struct {
+	pending []byte
+}
+
+// feed calls fn with every complete line of the chunk. Lines are copied and can be retained.
+func (l *lineSplitter) feed(chunk []byte, fn func(line []byte) error) error {
+	for len(chunk) > 0 {
+		idx := bytes.IndexByte(chunk, '\n')
+		if idx < 0 {
+			l.pending = append(l.pending, chunk...)
+			return nil
+		}
+		line := append(l.pending, chunk[:idx]...)
+		l.pending = nil
+		chunk = chunk[idx+1:]
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush calls fn with the last line of the stream if it wasn't terminated by a newline.
+func (l *lineSplitter) flush(fn func(line []byte) error) error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	line := l.pending
+	l.pending = nil
+	return fn(line)
+}
+
+// ForEachJSONRow streams the query result in JSONEachRow format and calls fn with every row, without parsing it.
+// Rows split across chunks are reassembled before being passed to fn.
+// Iteration stops at the first error returned by fn.
+func (s *Session) ForEachJSONRow(queryStr string, fn func(row json.RawMessage) error) error {
+	var splitter lineSplitter
+	emit := func(line []byte) error {
+		return fn(json.RawMessage(line))
+	}
+	err := s.forEachChunk(queryStr, "JSONEachRow", func(buf []byte) error {
+		return splitter.feed(buf, emit)
+	})
+	if err != nil {
+		return err
+	}
+	return splitter.flush(emit)
+}
+
+// QueryToWriter streams the query result in the given format to w, chunk by chunk, without decoding it.
+// It is meant for human readable formats such as PrettyCompact or Vertical, e.g. to print results in a terminal.
+// An empty format falls back to the session default one.
+func (s *Session) QueryToWriter(w io.Writer, queryStr, format string) error {
+	return s.forEachChunk(queryStr, format, func(buf []byte) error {
+		_, err := w.Write(buf)
+		return err
+	})
+}
@@ -0,0 +1,192 @@
+package chdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+func TestForEachJSONRow(t *testing.T) {
+	sess := testSession(t)
+
+	var out bytes.Buffer
+	out.WriteByte('[')
+	count := 0
+	err := sess.ForEachJSONRow("SELECT number AS id, toString(number) AS name FROM numbers(5)", func(row json.RawMessage) error {
+		if count > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(row)
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachJSONRow fail, err: %s", err)
+	}
+	out.WriteByte(']')
+
+	var rows []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatalf("reassembled rows are not a valid JSON array: %s, err: %s", out.String(), err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		if row.ID != i {
+			t.Errorf("row %d: expected id %d, got %d", i, i, row.ID)
+		}
+	}
+}
+
+func TestLineSplitter(t *testing.T) {
+	chunks := []string{`{"a":1}` + "\n" + `{"a"`, `:2}`, "\n", `{"a":3}` + "\n" + `{"a":4}`}
+	var (
+		splitter lineSplitter
+		lines    []string
+	)
+	collect := func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	}
+	for _, chunk := range chunks {
+		if err := splitter.feed([]byte(chunk), collect); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := splitter.flush(collect); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`{"a":1}`, `{"a":2}`, `{"a":3}`, `{"a":4}`}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d: expected %s, got %s", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestQueryToWriter(t *testing.T) {
+	sess := testSession(t)
+
+	var out bytes.Buffer
+	if err := sess.QueryToWriter(&out, "SELECT number AS id, toString(number) AS name FROM numbers(3)", "PrettyCompactNoEscapes"); err != nil {
+		t.Fatalf("QueryToWriter fail, err: %s", err)
+	}
+	got := out.String()
+	for _, expected := range []string{"┌─id─┬─name─┐", "│  0 │ 0    │", "│  2 │ 2    │", "└────┴──────┘"} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, got)
+		}
+	}
+
+	out.Reset()
+	if err := sess.QueryToWriter(&out, "SELECT 1 AS a, 'x' AS b", "Vertical"); err != nil {
+		t.Fatalf("QueryToWriter fail, err: %s", err)
+	}
+	if !strings.Contains(out.String(), "Row 1:") || !strings.Contains(out.String(), "b: x") {
+		t.Errorf("unexpected Vertical output:\n%s", out.String())
+	}
+}
+
+func TestQueryStreamFunc(t *testing.T) {
+	sess := testSession(t)
+
+	var out bytes.Buffer
+	err := sess.QueryStreamFunc("SELECT number FROM numbers(5)", "CSV", func(chunk []byte) error {
+		out.Write(chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("QueryStreamFunc fail, err: %s", err)
+	}
+	if out.String() != "0\n1\n2\n3\n4\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = sess.QueryStreamFunc("SELECT number FROM numbers(10000000)", "CSV", func(chunk []byte) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected the stream to stop after the first chunk, got %d calls, err: %v", calls, err)
+	}
+}
+
+// freeCountingResult is a fake chunk counting how many times it is freed.
+type freeCountingResult struct {
+	bufferResult
+	freed *int
+}
+
+func (r *freeCountingResult) Free() { *r.freed++ }
+
+// chunksConn is a fake connection streaming the given chunks.
+type chunksConn struct {
+	recordingConn
+	chunks []string
+	freed  int
+}
+
+func (c *chunksConn) QueryStreaming(queryStr string, formatStr string) (chdbpurego.ChdbStreamResult, error) {
+	stream := &chunksStream{}
+	for _, data := range append(c.chunks, "") {
+		chunk := &freeCountingResult{freed: &c.freed}
+		chunk.buf.WriteString(data)
+		chunk.rowsRead = uint64(len(data))
+		stream.chunks = append(stream.chunks, chunk)
+	}
+	return stream, nil
+}
+
+type chunksStream struct {
+	chunks []chdbpurego.ChdbResult
+}
+
+func (s *chunksStream) GetNext() chdbpurego.ChdbResult {
+	if len(s.chunks) == 0 {
+		return nil
+	}
+	next := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return next
+}
+func (s *chunksStream) Error() error { return nil }
+func (s *chunksStream) Cancel()      {}
+func (s *chunksStream) Free()        {}
+
+func TestForEachChunkFreesChunks(t *testing.T) {
+	conn := &chunksConn{chunks: []string{"a", "b", "c"}}
+	sess := &Session{conn: conn, defaultFormat: defaultOutputFormat}
+
+	var got string
+	if err := sess.QueryStreamFunc("SELECT 1", "CSV", func(chunk []byte) error {
+		got += string(chunk)
+		return nil
+	}); err != nil {
+		t.Fatalf("QueryStreamFunc fail, err: %s", err)
+	}
+	if got != "abc" || conn.freed != 4 {
+		t.Errorf("expected abc and 4 chunks freed, got %q and %d", got, conn.freed)
+	}
+
+	conn.freed = 0
+	stop := errors.New("stop")
+	if err := sess.QueryStreamFunc("SELECT 1", "CSV", func([]byte) error { return stop }); !errors.Is(err, stop) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if conn.freed != 1 {
+		t.Errorf("expected the chunk to be freed when fn fails, got %d frees", conn.freed)
+	}
+}
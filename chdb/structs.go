@@ -0,0 +1,165 @@
+package chdb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const structTagKey = "chdb"
+
+// structField maps a struct field to a table column.
+type structField struct {
+	column string
+	index  []int
+	typ    reflect.Type
+}
+
+// fieldPlans caches the fields of the struct types mapped by structFields, which must not be modified.
+var fieldPlans sync.Map
+
+// structFields returns the columns mapped by the exported fields of a struct type, computed once per type.
+// The column name is taken from the `chdb:"name"` tag, falling back to the field name.
+// Fields tagged with `chdb:"-"` are skipped, embedded structs are flattened.
+func structFields(t reflect.Type) ([]structField, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if fields, ok := fieldPlans.Load(t); ok {
+		return fields.([]structField), nil
+	}
+	fields, err := mapStructFields(t)
+	if err != nil {
+		return nil, err
+	}
+	fieldPlans.Store(t, fields)
+	return fields, nil
+}
+
+func mapStructFields(t reflect.Type) ([]structField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %s", t)
+	}
+	var fields []structField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || (f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		column := f.Name
+		if tag, ok := f.Tag.Lookup(structTagKey); ok {
+			if tag == "-" {
+				continue
+			}
+			if name, _, _ := strings.Cut(tag, ","); name != "" {
+				column = name
+			}
+		}
+		fields = append(fields, structField{column: column, index: f.Index, typ: f.Type})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("struct %s has no exported fields", t)
+	}
+	return fields, nil
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// clickHouseType maps a Go type to the ClickHouse type used to store it.
+// Pointers are mapped to Nullable and slices to Array.
+func clickHouseType(t reflect.Type) (string, error) {
+	switch t {
+	case timeType:
+		return "DateTime64(9)", nil
+	case bytesType:
+		return "String", nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "Bool", nil
+	case reflect.Int8:
+		return "Int8", nil
+	case reflect.Int16:
+		return "Int16", nil
+	case reflect.Int32:
+		return "Int32", nil
+	case reflect.Int, reflect.Int64:
+		return "Int64", nil
+	case reflect.Uint8:
+		return "UInt8", nil
+	case reflect.Uint16:
+		return "UInt16", nil
+	case reflect.Uint32:
+		return "UInt32", nil
+	case reflect.Uint, reflect.Uint64:
+		return "UInt64", nil
+	case reflect.Float32:
+		return "Float32", nil
+	case reflect.Float64:
+		return "Float64", nil
+	case reflect.String:
+		return "String", nil
+	case reflect.Pointer:
+		inner, err := clickHouseType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(inner, "Array(") || strings.HasPrefix(inner, "Map(") {
+			return "", fmt.Errorf("type %s can't be nullable", inner)
+		}
+		return "Nullable(" + inner + ")", nil
+	case reflect.Slice, reflect.Array:
+		inner, err := clickHouseType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "Array(" + inner + ")", nil
+	case reflect.Map:
+		key, err := clickHouseType(t.Key())
+		if err != nil {
+			return "", err
+		}
+		value, err := clickHouseType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "Map(" + key + ", " + value + ")", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// CreateTableFromStruct creates a table whose columns are derived from the fields of the given struct.
+// Column names are taken from the `chdb` struct tags, falling back to the field names.
+// Pointer fields are created as Nullable columns and slices as Array columns.
+// If engine is empty, MergeTree is used. MergeTree engines without an ORDER BY clause are ordered by tuple().
+func (s *Session) CreateTableFromStruct(table string, model any, engine string) error {
+	fields, err := structFields(reflect.TypeOf(model))
+	if err != nil {
+		return err
+	}
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		chType, err := clickHouseType(f.typ)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.column, err)
+		}
+		columns[i] = quoteIdentifier(f.column) + " " + chType
+	}
+	if engine == "" {
+		engine = "MergeTree"
+	}
+	if strings.Contains(engine, "MergeTree") && !strings.Contains(strings.ToUpper(engine), "ORDER BY") {
+		engine += " ORDER BY tuple()"
+	}
+	query := fmt.Sprintf("CREATE TABLE %s (%s) ENGINE = %s", quoteIdentifier(table), strings.Join(columns, ", "), engine)
+	res, err := s.Query(query)
+	if err != nil {
+		return err
+	}
+	res.Free()
+	return nil
+}
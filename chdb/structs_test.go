@@ -0,0 +1,58 @@
+package chdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testModel struct {
+	ID        uint64    `chdb:"id"`
+	Name      string    `chdb:"name"`
+	Score     *float64  `chdb:"score"`
+	Tags      []string  `chdb:"tags"`
+	CreatedAt time.Time `chdb:"created_at"`
+	Payload   []byte
+	Ignored   string `chdb:"-"`
+	internal  int
+}
+
+func TestCreateTableFromStruct(t *testing.T) {
+	sess := testSession(t)
+	if err := sess.CreateTableFromStruct("TestCreateTableFromStruct", testModel{}, ""); err != nil {
+		t.Fatalf("CreateTableFromStruct fail, err: %s", err)
+	}
+	ret, err := sess.Query("DESCRIBE TABLE TestCreateTableFromStruct", "TSV")
+	if err != nil {
+		t.Fatalf("describe table fail, err: %s", err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(ret.String()), "\n") {
+		cols := strings.Split(line, "\t")
+		got = append(got, cols[0]+" "+cols[1])
+	}
+	expected := []string{
+		"id UInt64",
+		"name String",
+		"score Nullable(Float64)",
+		"tags Array(String)",
+		"created_at DateTime64(9)",
+		"Payload String",
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected columns %v, got %v", expected, got)
+	}
+}
+
+func TestCreateTableFromStructUnsupported(t *testing.T) {
+	sess := testSession(t)
+	if err := sess.CreateTableFromStruct("TestCreateTableFromStructUnsupported", 42, ""); err == nil {
+		t.Fatalf("expected an error for a non struct model")
+	}
+	type badModel struct {
+		Values *[]int
+	}
+	if err := sess.CreateTableFromStruct("TestCreateTableFromStructUnsupported", badModel{}, ""); err == nil {
+		t.Fatalf("expected an error for a nullable array field")
+	}
+}
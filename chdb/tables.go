@@ -0,0 +1,56 @@
+package chdb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exec runs a statement through Exec, discarding its statistics.
+func (s *Session) exec(queryStr string) error {
+	_, err := s.Exec(queryStr)
+	return err
+}
+
+// RenameTable renames the table from to to. Both names may be qualified with a database, e.g. "db.table",
+// to move the table across databases.
+func (s *Session) RenameTable(from, to string) error {
+	return s.exec("RENAME TABLE " + quoteIdentifier(from) + " TO " + quoteIdentifier(to))
+}
+
+// DropTable drops the table. With ifExists, dropping a missing table is not an error.
+func (s *Session) DropTable(name string, ifExists bool) error {
+	query := "DROP TABLE "
+	if ifExists {
+		query += "IF EXISTS "
+	}
+	return s.exec(query + quoteIdentifier(name))
+}
+
+// TruncateTable removes all the rows of the table, keeping its definition.
+func (s *Session) TruncateTable(name string) error {
+	return s.exec("TRUNCATE TABLE " + quoteIdentifier(name))
+}
+
+// AttachFileTable creates the table name backed by the file table function, so that the local files at path can be
+// queried with a stable table name instead of file() calls, e.g. AttachFileTable("events", "data/events", "Parquet").
+// The path may hold globs such as *.csv, and a directory stands for all of its files. The files are read at every
+// query, so later changes are visible. An empty format is detected from the file extensions. The columns are inferred
+// from the files, unless a schema is given, e.g. "id UInt64, name String".
+func (s *Session) AttachFileTable(name, path, format string, schema ...string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(abs); err == nil && info.IsDir() {
+		abs = filepath.Join(abs, "*")
+	}
+	if format == "" {
+		format = "auto"
+	}
+	args := []string{quoteString(abs), quoteString(format)}
+	if len(schema) > 0 && schema[0] != "" {
+		args = append(args, quoteString(schema[0]))
+	}
+	return s.exec("CREATE TABLE " + quoteIdentifier(name) + " AS file(" + strings.Join(args, ", ") + ")")
+}
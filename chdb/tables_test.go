@@ -0,0 +1,84 @@
+package chdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestTableHelpers(t *testing.T) {
+	sess := testSession(t)
+
+	if _, err := sess.Query("CREATE TABLE `TestTableHelpers old` (id UInt64) ENGINE = MergeTree ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("INSERT INTO `TestTableHelpers old` SELECT number FROM numbers(10)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sess.RenameTable("TestTableHelpers old", "TestTableHelpers"); err != nil {
+		t.Fatalf("RenameTable fail, err: %s", err)
+	}
+	if n, err := sess.CountRows("SELECT * FROM TestTableHelpers"); err != nil || n != 10 {
+		t.Fatalf("expected 10 rows in the renamed table, got %d, err: %v", n, err)
+	}
+
+	if err := sess.TruncateTable("TestTableHelpers"); err != nil {
+		t.Fatalf("TruncateTable fail, err: %s", err)
+	}
+	if n, err := sess.CountRows("SELECT * FROM TestTableHelpers"); err != nil || n != 0 {
+		t.Fatalf("expected the truncated table to be empty, got %d, err: %v", n, err)
+	}
+
+	if err := sess.DropTable("TestTableHelpers", false); err != nil {
+		t.Fatalf("DropTable fail, err: %s", err)
+	}
+	if err := sess.DropTable("TestTableHelpers", true); err != nil {
+		t.Errorf("expected dropping a missing table with ifExists to succeed, got %s", err)
+	}
+	var queryErr *QueryError
+	if err := sess.DropTable("TestTableHelpers", false); !errors.As(err, &queryErr) {
+		t.Errorf("expected a QueryError dropping a missing table, got %v", err)
+	}
+	if err := sess.RenameTable("TestTableHelpers", "TestTableHelpersRenamed"); err == nil {
+		t.Errorf("expected an error renaming a missing table")
+	}
+}
+
+func TestAttachFileTable(t *testing.T) {
+	sess := testSession(t)
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("part%d.parquet", i))
+		query := fmt.Sprintf("INSERT INTO FUNCTION file(%s, 'Parquet') SELECT number AS id, toString(number) AS name FROM numbers(%d, 5)", quoteString(file), i*5)
+		if _, err := sess.Exec(query); err != nil {
+			t.Fatalf("write %s fail, err: %s", file, err)
+		}
+	}
+
+	if err := sess.AttachFileTable("TestAttachFileTable", dir, "Parquet"); err != nil {
+		t.Fatalf("AttachFileTable fail, err: %s", err)
+	}
+	defer sess.DropTable("TestAttachFileTable", true)
+	var count, sum uint64
+	if err := sess.QueryRow("SELECT count(), sum(id) FROM TestAttachFileTable", &count, &sum); err != nil {
+		t.Fatalf("QueryRow fail, err: %s", err)
+	}
+	if count != 10 || sum != 45 {
+		t.Errorf("expected 10 rows summing to 45, got %d rows summing to %d", count, sum)
+	}
+
+	if err := sess.AttachFileTable("TestAttachFileTableSchema", filepath.Join(dir, "part1.parquet"), "", "id UInt32"); err != nil {
+		t.Fatalf("AttachFileTable with a schema fail, err: %s", err)
+	}
+	defer sess.DropTable("TestAttachFileTableSchema", true)
+	columns, err := sess.QueryColumns("SELECT * FROM TestAttachFileTableSchema")
+	if err != nil {
+		t.Fatalf("QueryColumns fail, err: %s", err)
+	}
+	if len(columns) != 1 || columns[0].Name != "id" || columns[0].Type != "UInt32" {
+		t.Errorf("expected the schema to be applied, got %+v", columns)
+	}
+}
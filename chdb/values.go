@@ -0,0 +1,40 @@
+package chdb
+
+import (
+	"errors"
+	"strings"
+
+	chdbpurego "github.com/chdb-io/chdb-go/chdb-purego"
+)
+
+// QueryValues runs the query with the Values output format and returns its rows as tuples, e.g. (1,'a'),(2,'b'),
+// ready to be spliced into an INSERT ... VALUES statement. It returns ErrEmptyResult when the query returns no rows.
+func (s *Session) QueryValues(queryStr string) (string, error) {
+	res, err := s.Query(queryStr, "Values")
+	if err != nil {
+		return "", err
+	}
+	defer res.Free()
+	values := strings.TrimSpace(res.String())
+	if values == "" {
+		return "", ErrEmptyResult
+	}
+	return values, nil
+}
+
+// ErrEmptyResult is returned by QueryValues, QueryExpectRows, QueryRow and QueryScalar when the query returns no rows.
+var ErrEmptyResult = errors.New("query returned no rows")
+
+// QueryExpectRows runs the query like Query, returning ErrEmptyResult instead of the result when the query
+// read no rows or produced no output, for jobs where an empty result indicates a problem.
+func (s *Session) QueryExpectRows(queryStr, format string) (chdbpurego.ChdbResult, error) {
+	res, err := s.Query(queryStr, format)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsRead() == 0 || res.Len() == 0 {
+		res.Free()
+		return nil, ErrEmptyResult
+	}
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package chdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueryValues(t *testing.T) {
+	sess := testSession(t)
+
+	values, err := sess.QueryValues("SELECT number AS id, concat('it''s ', toString(number)) AS name FROM numbers(3)")
+	if err != nil {
+		t.Fatalf("QueryValues fail, err: %s", err)
+	}
+	if values != `(0,'it\'s 0'),(1,'it\'s 1'),(2,'it\'s 2')` {
+		t.Errorf("unexpected values: %s", values)
+	}
+
+	// the values can be spliced into another INSERT
+	if _, err := sess.Query("CREATE TABLE IF NOT EXISTS TestQueryValues (id UInt64, name String) ENGINE = Memory"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Query("INSERT INTO TestQueryValues VALUES " + values); err != nil {
+		t.Fatalf("insert values fail, err: %s", err)
+	}
+
+	if _, err := sess.QueryValues("SELECT 1 WHERE 0"); !errors.Is(err, ErrEmptyResult) {
+		t.Errorf("expected an error for an empty result")
+	}
+}
+
+func TestQueryExpectRows(t *testing.T) {
+	sess := testSession(t)
+
+	res, err := sess.QueryExpectRows("SELECT number FROM numbers(3)", "CSV")
+	if err != nil {
+		t.Fatalf("QueryExpectRows fail, err: %s", err)
+	}
+	if res.String() != "0\n1\n2\n" {
+		t.Errorf("unexpected result: %q", res.String())
+	}
+	res.Free()
+
+	if _, err := sess.QueryExpectRows("SELECT number FROM numbers(0)", "CSV"); !errors.Is(err, ErrEmptyResult) {
+		t.Errorf("expected ErrEmptyResult, got %v", err)
+	}
+	if _, err := sess.QueryExpectRows("SELECT * FROM TestQueryExpectRowsMissing", "CSV"); err == nil || errors.Is(err, ErrEmptyResult) {
+		t.Errorf("expected a query error, got %v", err)
+	}
+}
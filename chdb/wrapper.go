@@ -10,6 +10,9 @@ func Query(queryStr string, outputFormats ...string) (result chdbpurego.ChdbResu
 	if len(outputFormats) > 0 {
 		outputFormat = outputFormats[0]
 	}
+	if err := validateFormat(outputFormat); err != nil {
+		return nil, err
+	}
 	// tempSession, err := initConnection(":memory:?verbose&log-level=test")
 	tempSession, err := initConnection(":memory:")
 	if err != nil {
@@ -25,6 +28,9 @@ func QueryStream(queryStr string, outputFormats ...string) (result chdbpurego.Ch
 	if len(outputFormats) > 0 {
 		outputFormat = outputFormats[0]
 	}
+	if err := validateFormat(outputFormat); err != nil {
+		return nil, err
+	}
 	// tempSession, err := initConnection(":memory:?verbose&log-level=test")
 	tempSession, err := initConnection(":memory:")
 	if err != nil {
@@ -34,6 +40,15 @@ func QueryStream(queryStr string, outputFormats ...string) (result chdbpurego.Ch
 	return tempSession.QueryStreaming(queryStr, outputFormat)
 }
 
+// Initialize loads the chDB native library once per process, see chdbpurego.Initialize.
+// Sessions call it implicitly; calling it at startup reports a missing or incompatible library before the first query.
+func Initialize() error {
+	return chdbpurego.Initialize()
+}
+
 func initConnection(connStr string) (result chdbpurego.ChdbConn, err error) {
+	if err := Initialize(); err != nil {
+		return nil, err
+	}
 	return chdbpurego.NewConnectionFromConnString(connStr)
 }